@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/internal/migration"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+// migrate copies the tasks and auth tables from MySQL into Oracle, then
+// prints a row count and checksum verification report.
+func main() {
+	checkpointPath := flag.String("checkpoint", "migration.checkpoint.json", "file used to resume an interrupted migration")
+	batchSize := flag.Int("batch", migration.DefaultBatchSize, "rows copied per Oracle transaction")
+	tableNames := flag.String("tables", "", "comma separated source tables to migrate, all when empty")
+	reset := flag.Bool("reset", false, "ignore the checkpoint and start over")
+	verifyOnly := flag.Bool("verify-only", false, "only print the verification report")
+	flag.Parse()
+
+	logger.InitLogger()
+	config := configs.Get()
+	logger.SetLogLevel(config)
+
+	var names []string
+	if *tableNames != "" {
+		names = strings.Split(*tableNames, ",")
+	}
+	tables, unknown := migration.SelectTables(names)
+	if len(unknown) > 0 {
+		log.Fatal().Strs("tables", unknown).Msg("Unknown tables")
+	}
+
+	checkpoint, err := migration.LoadCheckpoint(*checkpointPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed reading checkpoint")
+	}
+	if *reset {
+		checkpoint.Reset()
+	}
+
+	source := infras.CreateMySQLReadConn(*config)
+	defer source.Close()
+	target := infras.CreateOracleWriteConn(*config)
+	defer target.Close()
+
+	migrator := migration.NewMigrator(source, target, checkpoint, *batchSize)
+	if !*verifyOnly {
+		err = migrator.Run(tables)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Migration failed, rerun to resume from the checkpoint")
+		}
+	}
+
+	report, err := migrator.Verify(tables)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Verification failed")
+	}
+	report.Print(os.Stdout)
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
@@ -19,17 +19,26 @@ type Config struct {
 			Enable           bool     `mapstructure:"ENABLE"`
 			MaxAgeSeconds    int      `mapstructure:"MAX_AGE_SECONDS"`
 		}
-		Name     string `mapstructure:"NAME"`
-		Revision string `mapstructure:"REVISION"`
-		URL      string `mapstructure:"URL"`
+		CursorSecret string `mapstructure:"CURSOR_SECRET"`
+		Name         string `mapstructure:"NAME"`
+		Revision     string `mapstructure:"REVISION"`
+		URL          string `mapstructure:"URL"`
 	}
 
 	Cache struct {
+		Backend string `mapstructure:"BACKEND"`
+		Memory  struct {
+			MaxEntries int `mapstructure:"MAX_ENTRIES"`
+		}
 		Redis struct {
 			Primary struct {
-				Host     string `mapstructure:"HOST"`
-				Port     string `mapstructure:"PORT"`
-				Password string `mapstructure:"PASSWORD"`
+				Host              string `mapstructure:"HOST"`
+				Port              string `mapstructure:"PORT"`
+				Password          string `mapstructure:"PASSWORD"`
+				DB                int    `mapstructure:"DB"`
+				DefaultTTLSeconds int    `mapstructure:"DEFAULT_TTL_SECONDS"`
+				RetryCount        int    `mapstructure:"RETRY_COUNT"`
+				TimeoutSeconds    int    `mapstructure:"TIMEOUT_SECONDS"`
 			}
 		}
 	}
@@ -54,13 +63,23 @@ type Config struct {
 			}
 		}
 		Oracle struct {
-			Read struct {
+			ConnectRetries         int `mapstructure:"CONNECT_RETRIES"`
+			ConnectBackoffSeconds  int `mapstructure:"CONNECT_BACKOFF_SECONDS"`
+			QueryTimeoutSeconds    int `mapstructure:"QUERY_TIMEOUT_SECONDS"`
+			ReadAfterWriteSeconds  int `mapstructure:"READ_AFTER_WRITE_SECONDS"`
+			ReplicaLagCheckSeconds int `mapstructure:"REPLICA_LAG_CHECK_SECONDS"`
+			Read                   struct {
 				Host     string `mapstructure:"HOST"`
 				Port     string `mapstructure:"PORT"`
 				Username string `mapstructure:"USER"`
 				Password string `mapstructure:"PASSWORD"`
 				Name     string `mapstructure:"NAME"`
 				Timezone string `mapstructure:"TIMEZONE"`
+
+				MaxOpenConnections     int `mapstructure:"MAX_OPEN_CONNECTIONS"`
+				MaxIdleConnections     int `mapstructure:"MAX_IDLE_CONNECTIONS"`
+				ConnMaxLifetimeSeconds int `mapstructure:"CONN_MAX_LIFETIME_SECONDS"`
+				ConnMaxIdleTimeSeconds int `mapstructure:"CONN_MAX_IDLE_TIME_SECONDS"`
 			}
 			Write struct {
 				Host     string `mapstructure:"HOST"`
@@ -69,6 +88,11 @@ type Config struct {
 				Password string `mapstructure:"PASSWORD"`
 				Name     string `mapstructure:"NAME"`
 				Timezone string `mapstructure:"TIMEZONE"`
+
+				MaxOpenConnections     int `mapstructure:"MAX_OPEN_CONNECTIONS"`
+				MaxIdleConnections     int `mapstructure:"MAX_IDLE_CONNECTIONS"`
+				ConnMaxLifetimeSeconds int `mapstructure:"CONN_MAX_LIFETIME_SECONDS"`
+				ConnMaxIdleTimeSeconds int `mapstructure:"CONN_MAX_IDLE_TIME_SECONDS"`
 			}
 		}
 	}
@@ -80,6 +104,42 @@ type Config struct {
 			ExpirationDays int `mapstructure:"EXPIRATION_DAYS"`
 		} `mapstructure:"REFRESH_TOKEN"`
 	}
+	Outbox struct {
+		Enable              bool   `mapstructure:"ENABLE"`
+		Publisher           string `mapstructure:"PUBLISHER"`
+		BatchSize           int    `mapstructure:"BATCH_SIZE"`
+		PollIntervalSeconds int    `mapstructure:"POLL_INTERVAL_SECONDS"`
+		MaxBackoffSeconds   int    `mapstructure:"MAX_BACKOFF_SECONDS"`
+		MaxAttempts         int    `mapstructure:"MAX_ATTEMPTS"`
+		ClaimTimeoutSeconds int    `mapstructure:"CLAIM_TIMEOUT_SECONDS"`
+		Webhook             struct {
+			URL            string `mapstructure:"URL"`
+			TimeoutSeconds int    `mapstructure:"TIMEOUT_SECONDS"`
+		}
+		File struct {
+			Path string `mapstructure:"PATH"`
+		}
+	}
+	Task struct {
+		Search struct {
+			Backend string `mapstructure:"BACKEND"`
+		}
+		Workflow struct {
+			Path string `mapstructure:"PATH"`
+		}
+		Trash struct {
+			RetentionDays        int `mapstructure:"RETENTION_DAYS"`
+			PurgeIntervalMinutes int `mapstructure:"PURGE_INTERVAL_MINUTES"`
+			PurgeBatchSize       int `mapstructure:"PURGE_BATCH_SIZE"`
+		}
+		Subtasks struct {
+			MaxDepth int `mapstructure:"MAX_DEPTH"`
+		}
+		Recurrence struct {
+			IntervalMinutes int `mapstructure:"INTERVAL_MINUTES"`
+			BatchSize       int `mapstructure:"BATCH_SIZE"`
+		}
+	}
 	Server struct {
 		Env      string `mapstructure:"ENV"`
 		LogLevel string `mapstructure:"LOG_LEVEL"`
@@ -0,0 +1,60 @@
+package infras
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+)
+
+const (
+	// CacheBackendMemory keeps entries in an in-process LRU.
+	CacheBackendMemory = "memory"
+	// CacheBackendRedis keeps entries in Redis, shared between instances.
+	CacheBackendRedis = "redis"
+
+	defaultCacheTTL        = 10 * time.Second
+	defaultCacheMaxEntries = 1000
+)
+
+// Cache is a key/value store with per entry expiry. A zero ttl keeps the
+// entry until it is deleted or evicted.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error)
+	Delete(ctx context.Context, keys ...string) (err error)
+	DefaultTTL() time.Duration
+}
+
+// ProvideCache is the provider for the Cache backend selected in config.
+func ProvideCache(config *configs.Config) Cache {
+	ttl := time.Duration(config.Cache.Redis.Primary.DefaultTTLSeconds) * time.Second
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+
+	switch config.Cache.Backend {
+	case CacheBackendRedis:
+		log.Info().
+			Str("host", config.Cache.Redis.Primary.Host).
+			Str("port", config.Cache.Redis.Primary.Port).
+			Int("db", config.Cache.Redis.Primary.DB).
+			Msg("Using Redis cache")
+		return NewRedisCache(RedisConfig{
+			Address:    config.Cache.Redis.Primary.Host + ":" + config.Cache.Redis.Primary.Port,
+			Password:   config.Cache.Redis.Primary.Password,
+			DB:         config.Cache.Redis.Primary.DB,
+			RetryCount: config.Cache.Redis.Primary.RetryCount,
+			Timeout:    time.Duration(config.Cache.Redis.Primary.TimeoutSeconds) * time.Second,
+			DefaultTTL: ttl,
+		})
+	default:
+		maxEntries := config.Cache.Memory.MaxEntries
+		if maxEntries == 0 {
+			maxEntries = defaultCacheMaxEntries
+		}
+		log.Info().Int("maxEntries", maxEntries).Msg("Using in-process cache")
+		return NewMemoryCache(maxEntries, ttl)
+	}
+}
@@ -0,0 +1,100 @@
+package infras
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCache is an in-process Cache that evicts the least recently used
+// entry once it holds MaxEntries.
+type MemoryCache struct {
+	MaxEntries int
+	ttl        time.Duration
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates a MemoryCache.
+func NewMemoryCache(maxEntries int, defaultTTL time.Duration) *MemoryCache {
+	return &MemoryCache{
+		MaxEntries: maxEntries,
+		ttl:        defaultTTL,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the value of key when present and not expired.
+func (c *MemoryCache) Get(ctx context.Context, key string) (value []byte, found bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	entry := element.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Set stores value under key.
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.MaxEntries > 0 && c.order.Len() > c.MaxEntries {
+		c.remove(c.order.Back())
+	}
+	return
+}
+
+// Delete removes keys.
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return
+}
+
+// DefaultTTL returns the configured entry lifetime.
+func (c *MemoryCache) DefaultTTL() time.Duration {
+	return c.ttl
+}
+
+func (c *MemoryCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*memoryEntry).key)
+}
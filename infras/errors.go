@@ -0,0 +1,119 @@
+package infras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/rs/zerolog/log"
+	"github.com/sijms/go-ora/v2/network"
+	"github.com/tarkiman/go/shared/failure"
+)
+
+// ErrorClass is the driver independent category of a database error.
+type ErrorClass int
+
+const (
+	// ErrorClassUnknown is any error not recognised by Classify.
+	ErrorClassUnknown ErrorClass = iota
+	// ErrorClassUniqueViolation is a duplicate primary or unique key.
+	ErrorClassUniqueViolation
+	// ErrorClassForeignKeyViolation is a reference to a missing parent row.
+	ErrorClassForeignKeyViolation
+	// ErrorClassReferencedRow is a delete or update of a row still referenced by children.
+	ErrorClassReferencedRow
+	// ErrorClassNotNullViolation is a NULL written into a mandatory column.
+	ErrorClassNotNullViolation
+	// ErrorClassValueTooLarge is a value exceeding its column size.
+	ErrorClassValueTooLarge
+	// ErrorClassTimeout is an expired deadline or a cancelled statement.
+	ErrorClassTimeout
+	// ErrorClassRetryable is a deadlock or serialization failure.
+	ErrorClassRetryable
+)
+
+var (
+	oracleErrorClasses = map[int]ErrorClass{
+		1:     ErrorClassUniqueViolation,     // ORA-00001 unique constraint violated
+		2291:  ErrorClassForeignKeyViolation, // ORA-02291 parent key not found
+		2292:  ErrorClassReferencedRow,       // ORA-02292 child record found
+		1400:  ErrorClassNotNullViolation,    // ORA-01400 cannot insert NULL
+		1407:  ErrorClassNotNullViolation,    // ORA-01407 cannot update to NULL
+		12899: ErrorClassValueTooLarge,       // ORA-12899 value too large for column
+		1013:  ErrorClassTimeout,             // ORA-01013 user requested cancel
+		60:    ErrorClassRetryable,           // ORA-00060 deadlock detected
+		8176:  ErrorClassRetryable,           // ORA-08176 consistent read failure
+		8177:  ErrorClassRetryable,           // ORA-08177 cannot serialize access
+	}
+	mysqlErrorClasses = map[uint16]ErrorClass{
+		1062: ErrorClassUniqueViolation,     // ER_DUP_ENTRY
+		1452: ErrorClassForeignKeyViolation, // ER_NO_REFERENCED_ROW_2
+		1451: ErrorClassReferencedRow,       // ER_ROW_IS_REFERENCED_2
+		1048: ErrorClassNotNullViolation,    // ER_BAD_NULL_ERROR
+		1406: ErrorClassValueTooLarge,       // ER_DATA_TOO_LONG
+		3024: ErrorClassTimeout,             // ER_QUERY_TIMEOUT
+		1205: ErrorClassRetryable,           // ER_LOCK_WAIT_TIMEOUT
+		1213: ErrorClassRetryable,           // ER_LOCK_DEADLOCK
+	}
+)
+
+// Classify maps a go-ora or MySQL driver error to its ErrorClass.
+func Classify(err error) ErrorClass {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+
+	var oracleErr *network.OracleError
+	if errors.As(err, &oracleErr) {
+		return oracleErrorClasses[oracleErr.ErrCode]
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErrorClasses[mysqlErr.Number]
+	}
+	return ErrorClassUnknown
+}
+
+// IsRetryable reports whether err is a deadlock or serialization failure,
+// after which the whole transaction can safely be run again.
+func IsRetryable(err error) bool {
+	return Classify(err) == ErrorClassRetryable
+}
+
+// TranslateError turns a database error raised while running operationName
+// on entityName into a typed failure. The driver message, which may contain
+// SQL and constraint names, is logged but never returned to the client.
+// Failures are returned unchanged, so errors can be translated at every layer.
+func TranslateError(operationName string, entityName string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var f *failure.Failure
+	if errors.As(err, &f) {
+		return err
+	}
+
+	class := Classify(err)
+	log.Err(err).Str("operation", operationName).Str("entity", entityName).Int("class", int(class)).Msg("Database error")
+
+	switch class {
+	case ErrorClassUniqueViolation:
+		return failure.Conflict(operationName, entityName, "already exists")
+	case ErrorClassReferencedRow:
+		return failure.Conflict(operationName, entityName, "still referenced by other data")
+	case ErrorClassForeignKeyViolation:
+		return failure.BadRequestFromString(fmt.Sprintf("%s on %s: references data that does not exist", operationName, entityName))
+	case ErrorClassNotNullViolation:
+		return failure.BadRequestFromString(fmt.Sprintf("%s on %s: a required field is missing", operationName, entityName))
+	case ErrorClassValueTooLarge:
+		return failure.BadRequestFromString(fmt.Sprintf("%s on %s: a field exceeds its maximum length", operationName, entityName))
+	case ErrorClassTimeout:
+		return failure.GatewayTimeout(operationName)
+	case ErrorClassRetryable:
+		return failure.Retryable(operationName, entityName, err)
+	}
+	return failure.InternalError(fmt.Errorf("%s on %s failed", operationName, entityName))
+}
@@ -1,12 +1,13 @@
 package infras
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	// "github.com/golang-migrate/migrate/v4"
 	// "github.com/golang-migrate/migrate/v4/database/oracle"
-	"github.com/tarkiman/go/shared/failure"
 
 	"github.com/tarkiman/go/configs"
 	// use Oracle driver
@@ -18,24 +19,43 @@ import (
 )
 
 const (
-	maxIdleConnection = 10
-	maxOpenConnection = 10
+	maxIdleConnection   = 10
+	maxOpenConnection   = 10
+	defaultQueryTimeout = 30 * time.Second
 )
 
-// Block contains a transaction block
-type Block func(db *sqlx.Tx, c chan error)
+func init() {
+	// sqlx does not know the go-ora driver name, register its :name binds so
+	// named queries are compiled for Oracle.
+	sqlx.BindDriver("oracle", sqlx.NAMED)
+}
 
 // OracleConn wraps a pair of read/write Oracle connections.
 type OracleConn struct {
-	Read  *sqlx.DB
-	Write *sqlx.DB
+	Read           *sqlx.DB
+	Write          *sqlx.DB
+	QueryTimeout   time.Duration
+	ReadAfterWrite time.Duration
+	ReplicaLag     *ReplicaLag
 }
 
 // ProvideOracleConn is the provider for OracleConn.
 func ProvideOracleConn(config *configs.Config) *OracleConn {
+	queryTimeout := time.Duration(config.DB.Oracle.QueryTimeoutSeconds) * time.Second
+	if queryTimeout == 0 {
+		queryTimeout = defaultQueryTimeout
+	}
+	readAfterWrite := time.Duration(config.DB.Oracle.ReadAfterWriteSeconds) * time.Second
+	if readAfterWrite == 0 {
+		readAfterWrite = defaultReadAfterWrite
+	}
+
 	return &OracleConn{
-		Read:  CreateOracleReadConn(*config),
-		Write: CreateOracleWriteConn(*config),
+		Read:           CreateOracleReadConn(*config),
+		Write:          CreateOracleWriteConn(*config),
+		QueryTimeout:   queryTimeout,
+		ReadAfterWrite: readAfterWrite,
+		ReplicaLag:     new(ReplicaLag),
 	}
 }
 
@@ -48,7 +68,15 @@ func CreateOracleWriteConn(config configs.Config) *sqlx.DB {
 		config.DB.Oracle.Write.Host,
 		config.DB.Oracle.Write.Port,
 		config.DB.Oracle.Write.Name,
-		config.DB.Oracle.Write.Timezone)
+		config.DB.Oracle.Write.Timezone,
+		PoolConfig{
+			MaxOpenConnections: config.DB.Oracle.Write.MaxOpenConnections,
+			MaxIdleConnections: config.DB.Oracle.Write.MaxIdleConnections,
+			ConnMaxLifetime:    time.Duration(config.DB.Oracle.Write.ConnMaxLifetimeSeconds) * time.Second,
+			ConnMaxIdleTime:    time.Duration(config.DB.Oracle.Write.ConnMaxIdleTimeSeconds) * time.Second,
+			ConnectRetries:     config.DB.Oracle.ConnectRetries,
+			ConnectBackoff:     time.Duration(config.DB.Oracle.ConnectBackoffSeconds) * time.Second,
+		})
 
 }
 
@@ -61,12 +89,24 @@ func CreateOracleReadConn(config configs.Config) *sqlx.DB {
 		config.DB.Oracle.Read.Host,
 		config.DB.Oracle.Read.Port,
 		config.DB.Oracle.Read.Name,
-		config.DB.Oracle.Read.Timezone)
+		config.DB.Oracle.Read.Timezone,
+		PoolConfig{
+			MaxOpenConnections: config.DB.Oracle.Read.MaxOpenConnections,
+			MaxIdleConnections: config.DB.Oracle.Read.MaxIdleConnections,
+			ConnMaxLifetime:    time.Duration(config.DB.Oracle.Read.ConnMaxLifetimeSeconds) * time.Second,
+			ConnMaxIdleTime:    time.Duration(config.DB.Oracle.Read.ConnMaxIdleTimeSeconds) * time.Second,
+			ConnectRetries:     config.DB.Oracle.ConnectRetries,
+			ConnectBackoff:     time.Duration(config.DB.Oracle.ConnectBackoffSeconds) * time.Second,
+		})
 
 }
 
-// CreateDBConnection creates a database connection.
-func CreateDBConnection(name, username, password, host, port, dbName, timeZone string) *sqlx.DB {
+// CreateDBConnection creates a database connection. A database that stays
+// unreachable after the connect retries is logged rather than fatal: the
+// pool keeps trying on use and the readiness check reports it as down.
+func CreateDBConnection(name, username, password, host, port, dbName, timeZone string, pool PoolConfig) *sqlx.DB {
+	pool = pool.withDefaults()
+
 	descriptor := fmt.Sprintf(
 		"oracle://%s:%s@%s:%s/%s",
 		username,
@@ -74,10 +114,13 @@ func CreateDBConnection(name, username, password, host, port, dbName, timeZone s
 		host,
 		port,
 		dbName)
-	db, err := sqlx.Connect("oracle", descriptor)
+	db, err := connect("oracle", descriptor, pool)
+	if db == nil {
+		log.Fatal().Err(err).Str("name", name).Msg("Failed opening database")
+	}
 	if err != nil {
 		log.
-			Fatal().
+			Error().
 			Err(err).
 			Str("name", name).
 			Str("host", host).
@@ -91,10 +134,10 @@ func CreateDBConnection(name, username, password, host, port, dbName, timeZone s
 			Str("host", host).
 			Str("port", port).
 			Str("dbName", dbName).
+			Int("maxOpenConnections", pool.MaxOpenConnections).
+			Int("maxIdleConnections", pool.MaxIdleConnections).
 			Msg("Connected to database")
 	}
-	db.SetMaxIdleConns(maxIdleConnection)
-	db.SetMaxOpenConns(maxOpenConnection)
 
 	return db
 }
@@ -103,28 +146,30 @@ func CreateDBConnection(name, username, password, host, port, dbName, timeZone s
 func OpenMock(db *sql.DB) *OracleConn {
 	conn := sqlx.NewDb(db, "oracle")
 	return &OracleConn{
-		Write: conn,
-		Read:  conn,
+		Write:          conn,
+		Read:           conn,
+		QueryTimeout:   defaultQueryTimeout,
+		ReadAfterWrite: defaultReadAfterWrite,
+		ReplicaLag:     new(ReplicaLag),
 	}
 }
 
-// WithTransaction performs queries with transaction
-func (m *OracleConn) WithTransaction(block Block) (err error) {
-	e := make(chan error)
-	tx, err := m.Write.Beginx()
-	if err != nil {
-		return
-	}
-	go block(tx, e)
-	err = <-e
-	if err != nil {
-		if errTx := tx.Rollback(); errTx != nil {
-			err = failure.InternalError(errTx)
-		}
-		return
+// WithQueryTimeout derives a context bounded by the configured query timeout.
+func (m *OracleConn) WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	err = tx.Commit()
-	return
+	return context.WithTimeout(ctx, m.QueryTimeout)
+}
+
+// WithTransaction runs block within a unit of work on the write connection.
+// The transaction is rolled back when ctx is cancelled or the query timeout
+// elapses.
+func (m *OracleConn) WithTransaction(ctx context.Context, block Block) (err error) {
+	ctx, cancel := m.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return NewUnitOfWork(m.Write).Do(ctx, block)
 }
 
 // func (m *OracleConn) RunMigration(config *configs.Config) {
@@ -0,0 +1,151 @@
+package infras
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultConnectRetries  = 5
+	defaultConnectBackoff  = time.Second
+	defaultConnMaxLifetime = 30 * time.Minute
+	defaultConnMaxIdleTime = 5 * time.Minute
+)
+
+// PoolConfig holds the connection pool settings of a database role and how
+// the first connection is retried.
+type PoolConfig struct {
+	MaxOpenConnections int
+	MaxIdleConnections int
+	ConnMaxLifetime    time.Duration
+	ConnMaxIdleTime    time.Duration
+	ConnectRetries     int
+	ConnectBackoff     time.Duration
+}
+
+// withDefaults fills unset settings with the package defaults.
+func (p PoolConfig) withDefaults() PoolConfig {
+	if p.MaxOpenConnections == 0 {
+		p.MaxOpenConnections = maxOpenConnection
+	}
+	if p.MaxIdleConnections == 0 {
+		p.MaxIdleConnections = maxIdleConnection
+	}
+	if p.ConnMaxLifetime == 0 {
+		p.ConnMaxLifetime = defaultConnMaxLifetime
+	}
+	if p.ConnMaxIdleTime == 0 {
+		p.ConnMaxIdleTime = defaultConnMaxIdleTime
+	}
+	if p.ConnectRetries == 0 {
+		p.ConnectRetries = defaultConnectRetries
+	}
+	if p.ConnectBackoff == 0 {
+		p.ConnectBackoff = defaultConnectBackoff
+	}
+	return p
+}
+
+func (p PoolConfig) apply(db *sqlx.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConnections)
+	db.SetMaxIdleConns(p.MaxIdleConnections)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// connect opens a pool and pings it, retrying with exponential backoff. When
+// every attempt fails the pool is still returned, so that the service can
+// start and report the database as not ready until it becomes reachable.
+func connect(driverName, descriptor string, pool PoolConfig) (db *sqlx.DB, err error) {
+	db, err = sqlx.Open(driverName, descriptor)
+	if err != nil {
+		return
+	}
+	pool.apply(db)
+
+	backoff := pool.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err = db.Ping()
+		if err == nil || attempt > pool.ConnectRetries {
+			return
+		}
+
+		log.Warn().Err(err).Int("attempt", attempt).Dur("backoff", backoff).Msg("Database not reachable, retrying")
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// RoleStatus is the readiness of one database role.
+type RoleStatus struct {
+	Role       string    `json:"role"`
+	Up         bool      `json:"up"`
+	Error      string    `json:"error,omitempty"`
+	PingMillis int64     `json:"pingMillis"`
+	Stats      PoolStats `json:"stats"`
+}
+
+// PoolStats mirrors sql.DBStats with JSON friendly names and units.
+type PoolStats struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitMillis         int64 `json:"waitMillis"`
+	MaxIdleClosed      int64 `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64 `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64 `json:"maxLifetimeClosed"`
+}
+
+func poolStats(stats sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitMillis:         stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
+
+// Status pings every role and reports its pool statistics.
+func (m *OracleConn) Status(ctx context.Context) (statuses []RoleStatus) {
+	roles := []struct {
+		name string
+		db   *sqlx.DB
+	}{
+		{name: "read", db: m.Read},
+		{name: "write", db: m.Write},
+	}
+
+	for _, role := range roles {
+		statuses = append(statuses, roleStatus(ctx, role.name, role.db, m.QueryTimeout))
+	}
+	return
+}
+
+func roleStatus(ctx context.Context, role string, db *sqlx.DB, timeout time.Duration) (status RoleStatus) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := db.PingContext(ctx)
+	status = RoleStatus{
+		Role:       role,
+		Up:         err == nil,
+		PingMillis: time.Since(start).Milliseconds(),
+		Stats:      poolStats(db.Stats()),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return
+}
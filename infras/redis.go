@@ -0,0 +1,95 @@
+package infras
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisTimeout  = time.Second
+	defaultRedisPoolSize = 10
+)
+
+// RedisConfig holds the settings of a RedisCache.
+type RedisConfig struct {
+	Address    string
+	Password   string
+	DB         int
+	RetryCount int
+	Timeout    time.Duration
+	DefaultTTL time.Duration
+	PoolSize   int
+}
+
+// RedisCache is a Cache backed by Redis.
+type RedisCache struct {
+	client     *redis.Client
+	defaultTTL time.Duration
+}
+
+// NewRedisCache creates a RedisCache. Connections are opened on first use.
+func NewRedisCache(config RedisConfig) *RedisCache {
+	if config.Timeout == 0 {
+		config.Timeout = defaultRedisTimeout
+	}
+	if config.PoolSize == 0 {
+		config.PoolSize = defaultRedisPoolSize
+	}
+	// go-redis retries three times unless told not to with a negative count
+	retries := config.RetryCount
+	if retries == 0 {
+		retries = -1
+	}
+
+	return &RedisCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:         config.Address,
+			Password:     config.Password,
+			DB:           config.DB,
+			MaxRetries:   retries,
+			DialTimeout:  config.Timeout,
+			ReadTimeout:  config.Timeout,
+			WriteTimeout: config.Timeout,
+			PoolSize:     config.PoolSize,
+		}),
+		defaultTTL: config.DefaultTTL,
+	}
+}
+
+// Get returns the value of key when present.
+func (c *RedisCache) Get(ctx context.Context, key string) (value []byte, found bool, err error) {
+	value, err = c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Delete removes keys.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) (err error) {
+	if len(keys) == 0 {
+		return
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// DefaultTTL returns the configured entry lifetime.
+func (c *RedisCache) DefaultTTL() time.Duration {
+	return c.defaultTTL
+}
+
+// Close closes the connections to Redis.
+func (c *RedisCache) Close() (err error) {
+	return c.client.Close()
+}
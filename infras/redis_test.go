@@ -0,0 +1,172 @@
+package infras
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisCache(t *testing.T, config RedisConfig) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	config.Address = server.Addr()
+	cache := NewRedisCache(config)
+	t.Cleanup(func() { cache.Close() })
+	return cache, server
+}
+
+func TestRedisCacheGetSetDelete(t *testing.T) {
+	cache, _ := newTestRedisCache(t, RedisConfig{DefaultTTL: time.Minute})
+	ctx := context.Background()
+
+	if _, found, err := cache.Get(ctx, "missing"); err != nil || found {
+		t.Fatalf("Get(missing) = found %v, err %v; want not found", found, err)
+	}
+
+	// values are binary safe, including the bytes framing the protocol
+	value := []byte("{\"title\":\"a\r\nb\"}\x00\xff")
+	if err := cache.Set(ctx, "task:1", value, 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Set(ctx, "task:2", []byte{}, 0); err != nil {
+		t.Fatalf("Set(empty): %v", err)
+	}
+
+	got, found, err := cache.Get(ctx, "task:1")
+	if err != nil || !found || !bytes.Equal(got, value) {
+		t.Fatalf("Get(task:1) = %q, %v, %v; want %q", got, found, err, value)
+	}
+	got, found, err = cache.Get(ctx, "task:2")
+	if err != nil || !found || len(got) != 0 {
+		t.Fatalf("Get(task:2) = %q, %v, %v; want an empty value", got, found, err)
+	}
+
+	if err = cache.Delete(ctx, "task:1", "task:2", "missing"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, key := range []string{"task:1", "task:2"} {
+		if _, found, err = cache.Get(ctx, key); err != nil || found {
+			t.Fatalf("Get(%s) after Delete = found %v, err %v", key, found, err)
+		}
+	}
+	if err = cache.Delete(ctx); err != nil {
+		t.Fatalf("Delete(): %v", err)
+	}
+
+	if cache.DefaultTTL() != time.Minute {
+		t.Fatalf("DefaultTTL() = %v, want %v", cache.DefaultTTL(), time.Minute)
+	}
+}
+
+func TestRedisCacheTTL(t *testing.T) {
+	cache, server := newTestRedisCache(t, RedisConfig{})
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "expiring", []byte("1"), 1500*time.Millisecond); err != nil {
+		t.Fatalf("Set(expiring): %v", err)
+	}
+	if err := cache.Set(ctx, "lasting", []byte("1"), 0); err != nil {
+		t.Fatalf("Set(lasting): %v", err)
+	}
+
+	if ttl := server.TTL("expiring"); ttl != 1500*time.Millisecond {
+		t.Fatalf("TTL(expiring) = %v, want 1.5s", ttl)
+	}
+	if ttl := server.TTL("lasting"); ttl != 0 {
+		t.Fatalf("TTL(lasting) = %v, want none", ttl)
+	}
+
+	server.FastForward(time.Second)
+	if _, found, _ := cache.Get(ctx, "expiring"); !found {
+		t.Fatal("Get(expiring) before its TTL elapsed = not found")
+	}
+
+	server.FastForward(time.Second)
+	if _, found, _ := cache.Get(ctx, "expiring"); found {
+		t.Fatal("Get(expiring) after its TTL elapsed = found")
+	}
+	if _, found, _ := cache.Get(ctx, "lasting"); !found {
+		t.Fatal("Get(lasting) = not found")
+	}
+}
+
+func TestRedisCacheDB(t *testing.T) {
+	cache, server := newTestRedisCache(t, RedisConfig{DB: 3})
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "key", []byte("value"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := server.DB(3).Get("key"); err != nil || got != "value" {
+		t.Fatalf("DB 3 holds %q, %v; want value", got, err)
+	}
+	if server.Exists("key") {
+		t.Fatal("DB 0 holds the key")
+	}
+}
+
+func TestRedisCacheErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("password", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		server.RequireAuth("secret")
+
+		cache := NewRedisCache(RedisConfig{Address: server.Addr(), Password: "wrong"})
+		defer cache.Close()
+		if _, _, err := cache.Get(ctx, "key"); err == nil {
+			t.Fatal("Get with a wrong password succeeded")
+		}
+
+		cache = NewRedisCache(RedisConfig{Address: server.Addr(), Password: "secret"})
+		defer cache.Close()
+		if err := cache.Set(ctx, "key", []byte("value"), 0); err != nil {
+			t.Fatalf("Set with the password: %v", err)
+		}
+	})
+
+	t.Run("error reply", func(t *testing.T) {
+		cache, server := newTestRedisCache(t, RedisConfig{})
+		server.SetError("LOADING Redis is loading the dataset in memory")
+
+		if _, found, err := cache.Get(ctx, "key"); err == nil || found {
+			t.Fatalf("Get = found %v, err %v; want the error reply", found, err)
+		}
+		if err := cache.Set(ctx, "key", []byte("value"), 0); err == nil {
+			t.Fatal("Set succeeded despite the error reply")
+		}
+
+		server.SetError("")
+		if err := cache.Set(ctx, "key", []byte("value"), 0); err != nil {
+			t.Fatalf("Set once the server recovered: %v", err)
+		}
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		address := server.Addr()
+		server.Close()
+
+		cache := NewRedisCache(RedisConfig{Address: address, RetryCount: 1, Timeout: 100 * time.Millisecond})
+		defer cache.Close()
+		if _, _, err := cache.Get(ctx, "key"); err == nil {
+			t.Fatal("Get from a stopped server succeeded")
+		}
+		if err := cache.Delete(ctx, "key"); err == nil {
+			t.Fatal("Delete from a stopped server succeeded")
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		cache, _ := newTestRedisCache(t, RedisConfig{})
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		if _, _, err := cache.Get(cancelled, "key"); err == nil {
+			t.Fatal("Get with a cancelled context succeeded")
+		}
+	})
+}
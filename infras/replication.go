@@ -0,0 +1,136 @@
+package infras
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultReadAfterWrite  = 5 * time.Second
+	defaultReplicaLagCheck = 10 * time.Second
+)
+
+var replicationQueries = struct {
+	writeHeartbeat string
+	readHeartbeat  string
+}{
+	writeHeartbeat: `
+		MERGE INTO temp.replication_heartbeat h
+		USING (SELECT 1 AS id, :1 AS beat FROM dual) s
+		ON (h.id = s.id)
+		WHEN MATCHED THEN UPDATE SET h.beat = s.beat
+		WHEN NOT MATCHED THEN INSERT (id, beat) VALUES (s.id, s.beat)`,
+	readHeartbeat: `SELECT beat FROM temp.replication_heartbeat WHERE id = 1`,
+}
+
+type primaryReadsKey struct{}
+
+// WithPrimaryReads marks ctx so that reads resolved through Reader go to the
+// primary, giving the caller read-after-write consistency.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads reports whether ctx asks for reads from the primary.
+func PrimaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return primary
+}
+
+// Reader returns the connection a read should use: the primary when ctx asks
+// for read-after-write consistency, the read replica otherwise.
+func (m *OracleConn) Reader(ctx context.Context) *sqlx.DB {
+	if PrimaryReads(ctx) {
+		return m.Write
+	}
+	return m.Read
+}
+
+// ReadAfterWriteWindow is how long a caller keeps reading from the primary
+// after a write: the configured window, extended to the last measured
+// replica lag when the replica is further behind.
+func (m *OracleConn) ReadAfterWriteWindow() time.Duration {
+	window := m.ReadAfterWrite
+	if lag, _, err := m.ReplicaLag.Get(); err == nil && lag > window {
+		window = lag
+	}
+	return window
+}
+
+// ReplicaLag holds the last measured replication lag of the read replica.
+type ReplicaLag struct {
+	mu         sync.RWMutex
+	lag        time.Duration
+	measuredAt time.Time
+	err        error
+}
+
+// Get returns the last measured lag, when it was measured and the error of
+// the last measurement, if any.
+func (l *ReplicaLag) Get() (lag time.Duration, measuredAt time.Time, err error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lag, l.measuredAt, l.err
+}
+
+func (l *ReplicaLag) set(lag time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.measuredAt = time.Now()
+	l.err = err
+	if err == nil {
+		l.lag = lag
+	}
+}
+
+// MonitorReplicaLag measures the replica lag every interval until ctx is done.
+// Each round writes a heartbeat to the primary and reads the latest heartbeat
+// visible on the replica; the lag is the age of that heartbeat.
+func (m *OracleConn) MonitorReplicaLag(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultReplicaLagCheck
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		lag, err := m.measureReplicaLag(ctx)
+		m.ReplicaLag.set(lag, err)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed measuring replica lag")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *OracleConn) measureReplicaLag(ctx context.Context) (lag time.Duration, err error) {
+	ctx, cancel := m.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = m.Write.ExecContext(ctx, replicationQueries.writeHeartbeat, time.Now().UnixMilli())
+	if err != nil {
+		return
+	}
+
+	var beat int64
+	err = m.Read.GetContext(ctx, &beat, replicationQueries.readHeartbeat)
+	if err != nil {
+		return
+	}
+
+	lag = time.Since(time.UnixMilli(beat))
+	if lag < 0 {
+		lag = 0
+	}
+	return
+}
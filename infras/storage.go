@@ -0,0 +1,128 @@
+package infras
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+)
+
+const defaultStoragePath = "content"
+
+var (
+	// ErrNotStored is returned when no object is stored under a key.
+	ErrNotStored = errors.New("not stored")
+)
+
+// Storage keeps binary objects, such as uploaded files, under slash separated
+// keys.
+type Storage interface {
+	// Save stores everything read from content under key, replacing what was
+	// stored there, and returns its size. Nothing is stored when reading fails.
+	Save(ctx context.Context, key string, content io.Reader) (size int64, err error)
+	// Open fails with ErrNotStored when nothing is stored under key.
+	Open(ctx context.Context, key string) (object io.ReadSeekCloser, err error)
+	Delete(ctx context.Context, key string) (err error)
+	// DeleteAll deletes every object whose key starts with prefix/.
+	DeleteAll(ctx context.Context, prefix string) (err error)
+}
+
+// ProvideStorage is the provider for the Storage of uploaded files, kept on
+// the local filesystem under the upload path of config.
+func ProvideStorage(config *configs.Config) Storage {
+	root := config.Upload.Image.DefaultPath
+	if root == "" {
+		root = defaultStoragePath
+	}
+	log.Info().Str("root", root).Msg("Using local file storage")
+	return NewLocalStorage(root)
+}
+
+// LocalStorage is the Storage keeping objects as files below a root directory.
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage returns a LocalStorage keeping its files below root.
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+// Save writes content to a temporary file first, renamed once complete, so
+// that an object is never read half written.
+func (s *LocalStorage) Save(ctx context.Context, key string, content io.Reader) (size int64, err error) {
+	path := s.path(key)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	size, err = io.Copy(file, contextReader{ctx: ctx, reader: content})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return
+	}
+	err = os.Rename(file.Name(), path)
+	return
+}
+
+// Open opens the file of an object for reading.
+func (s *LocalStorage) Open(ctx context.Context, key string) (object io.ReadSeekCloser, err error) {
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotStored
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Delete removes the file of an object, if any.
+func (s *LocalStorage) Delete(ctx context.Context, key string) (err error) {
+	err = os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return
+}
+
+// DeleteAll removes the directory of the objects under prefix.
+func (s *LocalStorage) DeleteAll(ctx context.Context, prefix string) (err error) {
+	return os.RemoveAll(s.path(prefix))
+}
+
+// path maps key to a file below the root; keys cannot climb out of it.
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
+
+// contextReader stops reading once its context is done, such as when a
+// client gives up on an upload.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err != nil {
+		return
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,139 @@
+package infras
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+const (
+	defaultTransactionRetries = 3
+	transactionRetryBackoff   = 50 * time.Millisecond
+)
+
+// Block contains a transaction block. The context given to the block carries
+// the transaction, so a WithTransaction call made from inside the block joins
+// it through a savepoint instead of opening a new transaction.
+type Block func(ctx context.Context, tx *sqlx.Tx) (err error)
+
+// UnitOfWork runs blocks atomically on a database. A failing or panicking
+// block is rolled back, and the whole unit is retried when the database
+// reports a deadlock or a serialization failure, so blocks must not have
+// side effects outside the transaction.
+type UnitOfWork struct {
+	DB         *sqlx.DB
+	MaxRetries int
+}
+
+type transactionKey struct {
+	db *sqlx.DB
+}
+
+type transaction struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+// NewUnitOfWork creates a UnitOfWork on db with the default retry count.
+func NewUnitOfWork(db *sqlx.DB) *UnitOfWork {
+	return &UnitOfWork{
+		DB:         db,
+		MaxRetries: defaultTransactionRetries,
+	}
+}
+
+// TxFromContext returns the transaction opened on db by an enclosing unit of work.
+func TxFromContext(ctx context.Context, db *sqlx.DB) (tx *sqlx.Tx, ok bool) {
+	current, ok := ctx.Value(transactionKey{db: db}).(*transaction)
+	if !ok {
+		return nil, false
+	}
+	return current.tx, true
+}
+
+// Do runs block within a transaction, or within a savepoint of the
+// transaction already carried by ctx.
+func (u *UnitOfWork) Do(ctx context.Context, block Block) (err error) {
+	if current, ok := ctx.Value(transactionKey{db: u.DB}).(*transaction); ok {
+		return u.savepoint(ctx, current, block)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = u.run(ctx, block)
+		if err == nil || attempt >= u.MaxRetries || !IsRetryable(err) {
+			return
+		}
+
+		backoff := transactionRetryBackoff << attempt
+		log.Warn().Err(err).Int("attempt", attempt+1).Dur("backoff", backoff).Msg("Retrying transaction")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (u *UnitOfWork) run(ctx context.Context, block Block) (err error) {
+	tx, err := u.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = recovered(p)
+			rollback(tx)
+		}
+	}()
+
+	err = block(context.WithValue(ctx, transactionKey{db: u.DB}, &transaction{tx: tx}), tx)
+	if err != nil {
+		rollback(tx)
+		return
+	}
+	return tx.Commit()
+}
+
+func (u *UnitOfWork) savepoint(ctx context.Context, current *transaction, block Block) (err error) {
+	current.savepoints++
+	name := fmt.Sprintf("sp_%d", current.savepoints)
+	_, err = current.tx.ExecContext(ctx, "SAVEPOINT "+name)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = recovered(p)
+		}
+		if err != nil {
+			if _, errTx := current.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); errTx != nil {
+				logger.ErrorWithStack(errTx)
+			}
+		}
+	}()
+
+	return block(ctx, current.tx)
+}
+
+// rollback rolls tx back, logging rather than returning a rollback failure
+// so that the error which caused it is kept.
+func rollback(tx *sqlx.Tx) {
+	if errTx := tx.Rollback(); errTx != nil && !errors.Is(errTx, sql.ErrTxDone) {
+		logger.ErrorWithStack(errTx)
+	}
+}
+
+func recovered(p interface{}) error {
+	err := fmt.Errorf("panic in transaction: %v", p)
+	logger.ErrorWithStack(err)
+	return failure.InternalError(err)
+}
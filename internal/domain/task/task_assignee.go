@@ -0,0 +1,121 @@
+package task
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+)
+
+// maxAssignees is the most users a Task can be assigned to.
+const maxAssignees = 50
+
+// UserIDs are the IDs of the users assigned to a Task, in ascending order. They
+// are stored as a comma separated list next to the Task, so that its versions
+// keep who it was assigned to.
+type UserIDs []int64
+
+// NewUserIDs returns ids sorted and without repetitions, nil when empty.
+func NewUserIDs(ids ...int64) (userIDs UserIDs) {
+	for _, id := range ids {
+		if !userIDs.Contains(id) {
+			userIDs = append(userIDs, id)
+		}
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	return
+}
+
+// Contains tells whether id is one of the IDs.
+func (u UserIDs) Contains(id int64) bool {
+	for _, v := range u {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns the IDs together with ids.
+func (u UserIDs) With(ids ...int64) UserIDs {
+	return NewUserIDs(append(append([]int64{}, u...), ids...)...)
+}
+
+// Without returns the IDs except id.
+func (u UserIDs) Without(id int64) (userIDs UserIDs) {
+	for _, v := range u {
+		if v != id {
+			userIDs = append(userIDs, v)
+		}
+	}
+	return
+}
+
+// Scan reads the IDs from their comma separated list.
+func (u *UserIDs) Scan(value interface{}) (err error) {
+	var list string
+	switch v := value.(type) {
+	case nil:
+		*u = nil
+		return
+	case string:
+		list = v
+	case []byte:
+		list = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into UserIDs", value)
+	}
+
+	ids := make([]int64, 0)
+	for _, part := range strings.Split(list, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot scan %q into UserIDs: %w", list, err)
+		}
+		ids = append(ids, id)
+	}
+	*u = NewUserIDs(ids...)
+	return
+}
+
+// Value writes the IDs as a comma separated list, NULL when there are none.
+func (u UserIDs) Value() (driver.Value, error) {
+	if len(u) == 0 {
+		return nil, nil
+	}
+	parts := make([]string, 0, len(u))
+	for _, id := range u {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// TaskAssigneesRequestFormat represents the users to assign a Task to.
+type TaskAssigneesRequestFormat struct {
+	UserIDs []int64 `json:"userIds" validate:"required,min=1,max=50,dive,min=1"`
+	Version *int64  `json:"version,omitempty"`
+}
+
+// TaskAssignee is the assignment of a Task to a user.
+type TaskAssignee struct {
+	TaskID     uuid.UUID   `db:"task_id"`
+	UserID     int64       `db:"user_id"`
+	AssignedBy null.String `db:"assigned_by"`
+	AssignedAt time.Time   `db:"assigned_at"`
+}
+
+// assigneeListCondition holds for Tasks whose list of assignees holds the user
+// bound to it through assigneeListArg.
+const assigneeListCondition = "INSTR(',' || assignees || ',', ?) > 0"
+
+func assigneeListArg(id int64) string {
+	return "," + strconv.FormatInt(id, 10) + ","
+}
@@ -0,0 +1,156 @@
+package task
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/tarkiman/go/shared"
+)
+
+const (
+	// maxAttachments is the most files a Task can have attached.
+	maxAttachments = 100
+	// defaultMaxAttachmentSizeMB is the size limit of an attachment when the
+	// upload configuration sets none.
+	defaultMaxAttachmentSizeMB = 4
+	// sniffLength is how much of a file its content type is detected from.
+	sniffLength = 512
+)
+
+var (
+	// ErrAttachmentTooLarge is returned when an uploaded file exceeds the size
+	// limit.
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+)
+
+// TaskAttachment is the metadata of a file attached to a Task. The file itself
+// is kept in storage under StorageKey.
+type TaskAttachment struct {
+	ID          uuid.UUID   `db:"id"`
+	TaskID      uuid.UUID   `db:"task_id"`
+	FileName    string      `db:"file_name" validate:"required,max=255"`
+	ContentType string      `db:"content_type"`
+	Size        int64       `db:"file_size"`
+	StorageKey  string      `db:"storage_key"`
+	UploadedBy  null.String `db:"uploaded_by"`
+	CreatedAt   time.Time   `db:"created_at"`
+}
+
+// NewTaskAttachment creates the metadata of a file attached to a Task by
+// uploadedBy. Its content type and size are set once the file is read.
+func NewTaskAttachment(taskID uuid.UUID, fileName string, uploadedBy string) (attachment TaskAttachment, err error) {
+	attachment = TaskAttachment{
+		ID:         uuid.New(),
+		TaskID:     taskID,
+		FileName:   cleanFileName(fileName),
+		UploadedBy: null.NewString(uploadedBy, uploadedBy != ""),
+		CreatedAt:  time.Now(),
+	}
+	attachment.StorageKey = attachmentPrefix(taskID) + "/" + attachment.ID.String()
+	err = attachment.Validate()
+	return
+}
+
+// Validate validates the entity.
+func (a *TaskAttachment) Validate() (err error) {
+	validator := shared.GetValidator()
+	return validator.Struct(a)
+}
+
+// attachmentPrefix is the storage prefix of the files attached to a Task.
+func attachmentPrefix(taskID uuid.UUID) string {
+	return "attachments/" + taskID.String()
+}
+
+// cleanFileName keeps the base name of a client supplied file name, without
+// control characters.
+func cleanFileName(fileName string) string {
+	fileName = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, fileName)
+	fileName = filepath.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		return ""
+	}
+	return strings.TrimSpace(fileName)
+}
+
+// SniffContentType detects the content type of content from its first bytes,
+// rather than trusting the one claimed by the client. The returned reader
+// reads content from its start.
+func SniffContentType(content io.Reader) (contentType string, rewound io.Reader, err error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	head = head[:n]
+	return http.DetectContentType(head), io.MultiReader(bytes.NewReader(head), content), nil
+}
+
+// limitedReader fails with ErrAttachmentTooLarge once more than limit bytes
+// are read.
+type limitedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (r *limitedReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n, ErrAttachmentTooLarge
+	}
+	return
+}
+
+// TaskAttachmentResponse represents a TaskAttachment for JSON serializing.
+type TaskAttachmentResponse struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"taskId"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	UploadedBy  string    `json:"uploadedBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	URL         string    `json:"url"`
+}
+
+type TaskAttachmentResponseFormat struct {
+	Message    string                  `json:"message"`
+	Attachment *TaskAttachmentResponse `json:"attachment,omitempty"`
+}
+
+type TaskAttachmentListResponseFormat struct {
+	Attachments []TaskAttachmentResponse `json:"attachments"`
+}
+
+// ToResponseFormat converts a TaskAttachment into its response format.
+func (a TaskAttachment) ToResponseFormat() TaskAttachmentResponse {
+	return TaskAttachmentResponse{
+		ID:          a.ID.String(),
+		TaskID:      a.TaskID.String(),
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		UploadedBy:  a.UploadedBy.String,
+		CreatedAt:   a.CreatedAt,
+		URL:         fmt.Sprintf("/v1/tasks/%s/attachments/%s/content", a.TaskID, a.ID),
+	}
+}
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	attachmentQueries = struct {
+		selectAttachment string
+		insertAttachment string
+		deleteAttachment string
+	}{
+		selectAttachment: `
+			SELECT
+				id,
+				task_id,
+				file_name,
+				content_type,
+				file_size,
+				storage_key,
+				uploaded_by,
+				created_at
+			FROM temp.task_attachments`,
+		insertAttachment: `
+			INSERT INTO temp.task_attachments (
+				id,
+				task_id,
+				file_name,
+				content_type,
+				file_size,
+				storage_key,
+				uploaded_by,
+				created_at
+			) VALUES (
+				:id,
+				:task_id,
+				:file_name,
+				:content_type,
+				:file_size,
+				:storage_key,
+				:uploaded_by,
+				:created_at)`,
+		deleteAttachment: `
+			DELETE FROM temp.task_attachments
+			WHERE id=:id AND task_id=:task_id`,
+	}
+)
+
+// AttachmentRepository is the repository for the metadata of the files
+// attached to Tasks. Attachments are not versioned with their Task.
+type AttachmentRepository interface {
+	ResolveByTaskID(ctx context.Context, taskID uuid.UUID) (attachments []TaskAttachment, err error)
+	ResolveByID(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (attachment TaskAttachment, exist bool, err error)
+	Create(ctx context.Context, attachment TaskAttachment) (err error)
+	Delete(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (err error)
+}
+
+// AttachmentRepositoryOracle is the Oracle-backed implementation of AttachmentRepository.
+type AttachmentRepositoryOracle struct {
+	DB *infras.OracleConn
+}
+
+// ProvideAttachmentRepositoryOracle is the provider for this repository.
+func ProvideAttachmentRepositoryOracle(db *infras.OracleConn) *AttachmentRepositoryOracle {
+	s := new(AttachmentRepositoryOracle)
+	s.DB = db
+	return s
+}
+
+// ResolveByTaskID resolves the attachments of a Task, oldest first.
+func (r *AttachmentRepositoryOracle) ResolveByTaskID(ctx context.Context, taskID uuid.UUID) (attachments []TaskAttachment, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).SelectContext(ctx, &attachments,
+		attachmentQueries.selectAttachment+" WHERE task_id = :1 ORDER BY created_at, id", taskID)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveByTaskID", "TaskAttachment", err)
+	return
+}
+
+// ResolveByID resolves an attachment of a Task by its ID.
+func (r *AttachmentRepositoryOracle) ResolveByID(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (attachment TaskAttachment, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(ctx, &attachment,
+		attachmentQueries.selectAttachment+" WHERE id = :1 AND task_id = :2", id, taskID)
+	switch {
+	case err == sql.ErrNoRows:
+		return attachment, false, nil
+	case err != nil:
+		return attachment, false, infras.TranslateError("resolveByID", "TaskAttachment", err)
+	}
+	return attachment, true, err
+}
+
+// Create records a new attachment.
+func (r *AttachmentRepositoryOracle) Create(ctx context.Context, attachment TaskAttachment) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, attachmentQueries.insertAttachment, attachment)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return infras.TranslateError("create", "TaskAttachment", err)
+}
+
+// Delete deletes the record of an attachment.
+func (r *AttachmentRepositoryOracle) Delete(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, attachmentQueries.deleteAttachment, map[string]interface{}{
+		"id":      id,
+		"task_id": taskID,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("delete", "TaskAttachment", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return failure.NotFound("TaskAttachment")
+	}
+	return
+}
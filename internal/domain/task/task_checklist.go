@@ -0,0 +1,113 @@
+package task
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/tarkiman/go/shared"
+)
+
+// maxChecklistItems is the most checklist items a Task can have.
+const maxChecklistItems = 100
+
+// ChecklistItem is a lightweight step of a Task, checked off when done.
+type ChecklistItem struct {
+	ID        uuid.UUID `db:"id"`
+	TaskID    uuid.UUID `db:"task_id"`
+	Title     string    `db:"title" validate:"required,max=255"`
+	Position  int       `db:"position"`
+	DoneAt    null.Time `db:"done_at"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt null.Time `db:"updated_at"`
+}
+
+// ChecklistItemRequestFormat represents a ChecklistItem's standard formatting
+// for JSON deserializing.
+type ChecklistItemRequestFormat struct {
+	Title string `json:"title" validate:"required,max=255"`
+	Done  bool   `json:"done"`
+	// Position orders the items of a checklist; new items without one go
+	// last, updated items without one keep theirs.
+	Position int `json:"position,omitempty" validate:"min=0"`
+}
+
+// NewChecklistItem creates a new ChecklistItem of a Task from its request
+// format, at position unless the request gives one.
+func NewChecklistItem(taskID uuid.UUID, request ChecklistItemRequestFormat, position int) (item ChecklistItem, err error) {
+	if request.Position > 0 {
+		position = request.Position
+	}
+	item = ChecklistItem{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		Title:     strings.TrimSpace(request.Title),
+		Position:  position,
+		CreatedAt: time.Now(),
+	}
+	item.SetDone(request.Done)
+	err = item.Validate()
+	return
+}
+
+// Update a ChecklistItem.
+func (c *ChecklistItem) Update(request ChecklistItemRequestFormat) (err error) {
+	c.Title = strings.TrimSpace(request.Title)
+	if request.Position > 0 {
+		c.Position = request.Position
+	}
+	c.SetDone(request.Done)
+	c.UpdatedAt = null.TimeFrom(time.Now())
+	err = c.Validate()
+	return
+}
+
+// SetDone checks the item off, keeping when it was first done, or clears it.
+func (c *ChecklistItem) SetDone(done bool) {
+	switch {
+	case !done:
+		c.DoneAt = null.Time{}
+	case !c.DoneAt.Valid:
+		c.DoneAt = null.TimeFrom(time.Now())
+	}
+}
+
+// Validate validates the entity.
+func (c *ChecklistItem) Validate() (err error) {
+	validator := shared.GetValidator()
+	return validator.Struct(c)
+}
+
+// ChecklistItemResponse represents a ChecklistItem for JSON serializing.
+type ChecklistItemResponse struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Done      bool       `json:"done"`
+	DoneAt    *time.Time `json:"doneAt,omitempty"`
+	Position  int        `json:"position"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type ChecklistItemResponseFormat struct {
+	Message string                 `json:"message"`
+	Item    *ChecklistItemResponse `json:"item,omitempty"`
+}
+
+type ChecklistResponseFormat struct {
+	Items []ChecklistItemResponse `json:"items"`
+}
+
+// ToResponseFormat converts a ChecklistItem into its response format.
+func (c ChecklistItem) ToResponseFormat() ChecklistItemResponse {
+	return ChecklistItemResponse{
+		ID:        c.ID.String(),
+		Title:     c.Title,
+		Done:      c.DoneAt.Valid,
+		DoneAt:    c.DoneAt.Ptr(),
+		Position:  c.Position,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt.Ptr(),
+	}
+}
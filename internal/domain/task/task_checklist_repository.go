@@ -0,0 +1,155 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	checklistQueries = struct {
+		selectItem string
+		insertItem string
+		updateItem string
+		deleteItem string
+	}{
+		selectItem: `
+			SELECT
+				id,
+				task_id,
+				title,
+				position,
+				done_at,
+				created_at,
+				updated_at
+			FROM temp.task_checklist_items`,
+		insertItem: `
+			INSERT INTO temp.task_checklist_items (
+				id,
+				task_id,
+				title,
+				position,
+				done_at,
+				created_at
+			) VALUES (
+				:id,
+				:task_id,
+				:title,
+				:position,
+				:done_at,
+				:created_at)`,
+		updateItem: `
+			UPDATE temp.task_checklist_items SET
+				title=:title,
+				position=:position,
+				done_at=:done_at,
+				updated_at=:updated_at
+			WHERE id=:id AND task_id=:task_id`,
+		deleteItem: `
+			DELETE FROM temp.task_checklist_items
+			WHERE id=:id AND task_id=:task_id`,
+	}
+)
+
+// ChecklistRepository is the repository for the checklist items of Tasks.
+// Checklist items are not versioned with their Task.
+type ChecklistRepository interface {
+	ResolveByTaskID(ctx context.Context, taskID uuid.UUID) (items []ChecklistItem, err error)
+	ResolveByID(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (item ChecklistItem, exist bool, err error)
+	Create(ctx context.Context, item ChecklistItem) (err error)
+	Update(ctx context.Context, item ChecklistItem) (err error)
+	Delete(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (err error)
+}
+
+// ChecklistRepositoryOracle is the Oracle-backed implementation of ChecklistRepository.
+type ChecklistRepositoryOracle struct {
+	DB *infras.OracleConn
+}
+
+// ProvideChecklistRepositoryOracle is the provider for this repository.
+func ProvideChecklistRepositoryOracle(db *infras.OracleConn) *ChecklistRepositoryOracle {
+	s := new(ChecklistRepositoryOracle)
+	s.DB = db
+	return s
+}
+
+// ResolveByTaskID resolves the checklist of a Task, in order.
+func (r *ChecklistRepositoryOracle) ResolveByTaskID(ctx context.Context, taskID uuid.UUID) (items []ChecklistItem, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).SelectContext(ctx, &items,
+		checklistQueries.selectItem+" WHERE task_id = :1 ORDER BY position, created_at", taskID)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveByTaskID", "ChecklistItem", err)
+	return
+}
+
+// ResolveByID resolves a checklist item of a Task by its ID.
+func (r *ChecklistRepositoryOracle) ResolveByID(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (item ChecklistItem, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(ctx, &item,
+		checklistQueries.selectItem+" WHERE id = :1 AND task_id = :2", id, taskID)
+	switch {
+	case err == sql.ErrNoRows:
+		return item, false, nil
+	case err != nil:
+		return item, false, infras.TranslateError("resolveByID", "ChecklistItem", err)
+	}
+	return item, true, err
+}
+
+// Create creates a new checklist item.
+func (r *ChecklistRepositoryOracle) Create(ctx context.Context, item ChecklistItem) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, checklistQueries.insertItem, item)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return infras.TranslateError("create", "ChecklistItem", err)
+}
+
+// Update updates a checklist item.
+func (r *ChecklistRepositoryOracle) Update(ctx context.Context, item ChecklistItem) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, checklistQueries.updateItem, item)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("update", "ChecklistItem", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return failure.NotFound("ChecklistItem")
+	}
+	return
+}
+
+// Delete deletes a checklist item.
+func (r *ChecklistRepositoryOracle) Delete(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, checklistQueries.deleteItem, map[string]interface{}{
+		"id":      id,
+		"task_id": taskID,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("delete", "ChecklistItem", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return failure.NotFound("ChecklistItem")
+	}
+	return
+}
@@ -0,0 +1,196 @@
+package task
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/tarkiman/go/shared"
+	"github.com/tarkiman/go/shared/failure"
+)
+
+// maxMentions is the most users a comment notifies.
+const maxMentions = 20
+
+// mentionPattern matches "@username" where the @ does not follow a word
+// character, as in an email address. Usernames may hold dots and dashes, but
+// not end with one.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w(?:[\w.-]{0,62}\w)?)`)
+
+// TaskComment is a comment in the discussion of a Task.
+type TaskComment struct {
+	ID        uuid.UUID   `db:"id"`
+	TaskID    uuid.UUID   `db:"task_id"`
+	Body      string      `db:"body" validate:"required,max=4000"`
+	Author    null.String `db:"author"`
+	Mentions  UserIDs     `db:"mentions"`
+	CreatedAt time.Time   `db:"created_at"`
+	UpdatedAt null.Time   `db:"updated_at"`
+	DeletedAt null.Time   `db:"deleted_at"`
+	DeletedBy null.String `db:"deleted_by"`
+	Version   int64       `db:"version"`
+}
+
+// TaskCommentQueryData is a TaskComment row with the total row count.
+type TaskCommentQueryData struct {
+	TaskComment
+	FilterCount int `db:"count"`
+}
+
+// TaskCommentEdit keeps the body a comment had before it was edited.
+type TaskCommentEdit struct {
+	ID        uuid.UUID   `db:"id"`
+	CommentID uuid.UUID   `db:"comment_id"`
+	Body      string      `db:"body"`
+	EditedBy  null.String `db:"edited_by"`
+	EditedAt  time.Time   `db:"edited_at"`
+}
+
+// MentionedUser is a user mentioned by username in a comment.
+type MentionedUser struct {
+	ID       int64  `db:"id"`
+	Username string `db:"username"`
+}
+
+// TaskMention is the payload of the event notifying a user of a mention.
+type TaskMention struct {
+	TaskID    string `json:"taskId"`
+	CommentID string `json:"commentId"`
+	UserID    int64  `json:"userId"`
+	Username  string `json:"username"`
+	Author    string `json:"author,omitempty"`
+	Body      string `json:"body"`
+}
+
+// TaskCommentRequestFormat represents a TaskComment's standard formatting for
+// JSON deserializing.
+type TaskCommentRequestFormat struct {
+	Body string `json:"body" validate:"required,max=4000"`
+}
+
+// NewTaskComment creates a new comment on a Task by author.
+func NewTaskComment(taskID uuid.UUID, request TaskCommentRequestFormat, author string) (comment TaskComment, err error) {
+	comment = TaskComment{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		Body:      strings.TrimSpace(request.Body),
+		Author:    null.NewString(author, author != ""),
+		CreatedAt: time.Now(),
+		Version:   1,
+	}
+	err = comment.Validate()
+	return
+}
+
+// CheckAuthor verifies that actor may change the comment: only its author
+// may, when it has one.
+func (c *TaskComment) CheckAuthor(actor string) (err error) {
+	if c.Author.Valid && c.Author.String != actor {
+		return failure.Forbidden("only the author of a comment can change it")
+	}
+	return
+}
+
+// Edit changes the body of the comment, returning the edit keeping its
+// previous body.
+func (c *TaskComment) Edit(request TaskCommentRequestFormat, editedBy string) (edit TaskCommentEdit, err error) {
+	edit = TaskCommentEdit{
+		ID:        uuid.New(),
+		CommentID: c.ID,
+		Body:      c.Body,
+		EditedBy:  null.NewString(editedBy, editedBy != ""),
+		EditedAt:  time.Now(),
+	}
+	c.Body = strings.TrimSpace(request.Body)
+	c.UpdatedAt = null.TimeFrom(edit.EditedAt)
+	err = c.Validate()
+	return
+}
+
+// SoftDelete marks the comment as deleted.
+func (c *TaskComment) SoftDelete(deletedBy string) {
+	c.DeletedAt = null.TimeFrom(time.Now())
+	c.DeletedBy = null.NewString(deletedBy, deletedBy != "")
+}
+
+// Validate validates the entity.
+func (c *TaskComment) Validate() (err error) {
+	validator := shared.GetValidator()
+	return validator.Struct(c)
+}
+
+// ParseMentions returns the usernames mentioned in body, in lower case and
+// without repetitions, up to maxMentions of them.
+func ParseMentions(body string) (usernames []string) {
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.ToLower(match[1])
+		if !contains(usernames, username) {
+			usernames = append(usernames, username)
+		}
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+	return
+}
+
+// TaskCommentResponse represents a TaskComment for JSON serializing.
+type TaskCommentResponse struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"taskId"`
+	Body      string     `json:"body"`
+	Author    string     `json:"author,omitempty"`
+	Mentions  []int64    `json:"mentions"`
+	Edited    bool       `json:"edited"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	Version   int64      `json:"version"`
+}
+
+type TaskCommentResponseFormat struct {
+	Message string               `json:"message"`
+	Comment *TaskCommentResponse `json:"comment,omitempty"`
+}
+
+type TaskCommentListResponseFormat struct {
+	Comments   []TaskCommentResponse `json:"comments"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+// TaskCommentEditResponse represents a TaskCommentEdit for JSON serializing.
+type TaskCommentEditResponse struct {
+	Body     string    `json:"body"`
+	EditedBy string    `json:"editedBy,omitempty"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
+type TaskCommentEditsResponseFormat struct {
+	Comment TaskCommentResponse       `json:"comment"`
+	Edits   []TaskCommentEditResponse `json:"edits"`
+}
+
+// ToResponseFormat converts a TaskComment into its response format.
+func (c TaskComment) ToResponseFormat() TaskCommentResponse {
+	return TaskCommentResponse{
+		ID:        c.ID.String(),
+		TaskID:    c.TaskID.String(),
+		Body:      c.Body,
+		Author:    c.Author.String,
+		Mentions:  append([]int64{}, c.Mentions...),
+		Edited:    c.UpdatedAt.Valid,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt.Ptr(),
+		Version:   c.Version,
+	}
+}
+
+// ToResponseFormat converts a TaskCommentEdit into its response format.
+func (e TaskCommentEdit) ToResponseFormat() TaskCommentEditResponse {
+	return TaskCommentEditResponse{
+		Body:     e.Body,
+		EditedBy: e.EditedBy.String,
+		EditedAt: e.EditedAt,
+	}
+}
@@ -0,0 +1,293 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/internal/outbox"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	commentQueries = struct {
+		selectComment  string
+		selectComments string
+		insertComment  string
+		updateComment  string
+		deleteComment  string
+		insertEdit     string
+		selectEdits    string
+		selectUsers    string
+	}{
+		selectComment: `
+			SELECT
+				id,
+				task_id,
+				body,
+				author,
+				mentions,
+				created_at,
+				updated_at,
+				deleted_at,
+				deleted_by,
+				version
+			FROM temp.task_comments
+			WHERE id = :1 AND task_id = :2 AND deleted_at IS NULL`,
+		selectComments: `
+			SELECT
+				id,
+				task_id,
+				body,
+				author,
+				mentions,
+				created_at,
+				updated_at,
+				deleted_at,
+				deleted_by,
+				version,
+				COUNT(id) OVER() as count
+			FROM temp.task_comments
+			WHERE task_id = :1 AND deleted_at IS NULL
+			ORDER BY created_at, id
+			OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY`,
+		insertComment: `
+			INSERT INTO temp.task_comments (
+				id,
+				task_id,
+				body,
+				author,
+				mentions,
+				created_at,
+				version
+			) VALUES (
+				:id,
+				:task_id,
+				:body,
+				:author,
+				:mentions,
+				:created_at,
+				:version)`,
+		updateComment: `
+			UPDATE temp.task_comments SET
+				body=:body,
+				mentions=:mentions,
+				updated_at=:updated_at,
+				version=version + 1
+			WHERE id=:id AND version=:version AND deleted_at IS NULL`,
+		deleteComment: `
+			UPDATE temp.task_comments SET
+				deleted_at=:deleted_at,
+				deleted_by=:deleted_by,
+				version=version + 1
+			WHERE id=:id AND version=:version AND deleted_at IS NULL`,
+		insertEdit: `
+			INSERT INTO temp.task_comment_edits (
+				id,
+				comment_id,
+				body,
+				edited_by,
+				edited_at
+			) VALUES (
+				:id,
+				:comment_id,
+				:body,
+				:edited_by,
+				:edited_at)`,
+		selectEdits: `
+			SELECT
+				id,
+				comment_id,
+				body,
+				edited_by,
+				edited_at
+			FROM temp.task_comment_edits
+			WHERE comment_id = :1
+			ORDER BY edited_at DESC`,
+		// users are kept by the OAuth token store
+		selectUsers: `
+			SELECT
+				id,
+				username
+			FROM users`,
+	}
+)
+
+// CommentRepository is the repository for the comments on Tasks. Comments are
+// not versioned with their Task; their edits are kept instead.
+type CommentRepository interface {
+	ResolveByTaskID(ctx context.Context, taskID uuid.UUID, pagination Pagination) (comments []TaskCommentQueryData, err error)
+	ResolveByID(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (comment TaskComment, exist bool, err error)
+	ResolveEdits(ctx context.Context, id uuid.UUID) (edits []TaskCommentEdit, err error)
+	ResolveUsersByName(ctx context.Context, usernames []string) (users []MentionedUser, err error)
+	// Create, Update and SoftDelete record a mention event for each of the
+	// mentioned users along with the change.
+	Create(ctx context.Context, comment TaskComment, mentioned []MentionedUser) (err error)
+	// Update and SoftDelete fail with ErrVersionMismatch when the comment was
+	// changed since it was resolved.
+	Update(ctx context.Context, comment TaskComment, edit TaskCommentEdit, mentioned []MentionedUser) (err error)
+	SoftDelete(ctx context.Context, comment TaskComment) (err error)
+}
+
+// CommentRepositoryOracle is the Oracle-backed implementation of CommentRepository.
+type CommentRepositoryOracle struct {
+	DB     *infras.OracleConn
+	Outbox outbox.OutboxRepository
+}
+
+// ProvideCommentRepositoryOracle is the provider for this repository.
+func ProvideCommentRepositoryOracle(db *infras.OracleConn, outboxRepository outbox.OutboxRepository) *CommentRepositoryOracle {
+	s := new(CommentRepositoryOracle)
+	s.DB = db
+	s.Outbox = outboxRepository
+	return s
+}
+
+// ResolveByTaskID resolves a page of the comments on a Task that are not
+// deleted, oldest first.
+func (r *CommentRepositoryOracle) ResolveByTaskID(ctx context.Context, taskID uuid.UUID, pagination Pagination) (comments []TaskCommentQueryData, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	err = r.DB.Reader(ctx).SelectContext(ctx, &comments, commentQueries.selectComments, taskID, offset, pagination.PageSize)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveByTaskID", "TaskComment", err)
+	return
+}
+
+// ResolveByID resolves a comment on a Task by its ID, unless it is deleted.
+func (r *CommentRepositoryOracle) ResolveByID(ctx context.Context, taskID uuid.UUID, id uuid.UUID) (comment TaskComment, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(ctx, &comment, commentQueries.selectComment, id, taskID)
+	switch {
+	case err == sql.ErrNoRows:
+		return comment, false, nil
+	case err != nil:
+		return comment, false, infras.TranslateError("resolveByID", "TaskComment", err)
+	}
+	return comment, true, err
+}
+
+// ResolveEdits resolves the edits of a comment, most recent first.
+func (r *CommentRepositoryOracle) ResolveEdits(ctx context.Context, id uuid.UUID) (edits []TaskCommentEdit, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).SelectContext(ctx, &edits, commentQueries.selectEdits, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveEdits", "TaskCommentEdit", err)
+	return
+}
+
+// ResolveUsersByName resolves the users with the given usernames, ignoring
+// case. Unknown usernames are left out.
+func (r *CommentRepositoryOracle) ResolveUsersByName(ctx context.Context, usernames []string) (users []MentionedUser, err error) {
+	if len(usernames) == 0 {
+		return
+	}
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(usernames))
+	for _, username := range usernames {
+		args = append(args, strings.ToLower(username))
+	}
+	query := commentQueries.selectUsers + " WHERE LOWER(username) IN (" + inPlaceholders(len(usernames)) + ")"
+
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &users, db.Rebind(query), args...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveUsersByName", "User", err)
+	return
+}
+
+// Create creates a new comment.
+func (r *CommentRepositoryOracle) Create(ctx context.Context, comment TaskComment, mentioned []MentionedUser) (err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		_, err = tx.NamedExecContext(ctx, commentQueries.insertComment, comment)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		return r.txRecordMentions(ctx, tx, comment, mentioned)
+	})
+	return infras.TranslateError("create", "TaskComment", err)
+}
+
+// Update changes the body of a comment, keeping its previous body as edit.
+func (r *CommentRepositoryOracle) Update(ctx context.Context, comment TaskComment, edit TaskCommentEdit, mentioned []MentionedUser) (err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		result, err := tx.NamedExecContext(ctx, commentQueries.updateComment, comment)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		err = checkVersionUpdated(result)
+		if err != nil {
+			return
+		}
+
+		_, err = tx.NamedExecContext(ctx, commentQueries.insertEdit, edit)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		return r.txRecordMentions(ctx, tx, comment, mentioned)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return
+	}
+	return infras.TranslateError("update", "TaskComment", err)
+}
+
+// SoftDelete marks a comment as deleted. Its edits are kept.
+func (r *CommentRepositoryOracle) SoftDelete(ctx context.Context, comment TaskComment) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, commentQueries.deleteComment, comment)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("softDelete", "TaskComment", err)
+	}
+	return checkVersionUpdated(result)
+}
+
+// txRecordMentions writes a mention event for each of the mentioned users to
+// the outbox within tx, so they are notified if and only if the comment is
+// committed.
+func (r *CommentRepositoryOracle) txRecordMentions(ctx context.Context, tx *sqlx.Tx, comment TaskComment, mentioned []MentionedUser) (err error) {
+	for _, user := range mentioned {
+		event, err := outbox.NewEvent(AggregateType, comment.TaskID.String(), EventTaskMentioned, TaskMention{
+			TaskID:    comment.TaskID.String(),
+			CommentID: comment.ID.String(),
+			UserID:    user.ID,
+			Username:  user.Username,
+			Author:    comment.Author.String,
+			Body:      comment.Body,
+		})
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return err
+		}
+
+		err = r.Outbox.TxCreate(ctx, tx, event)
+		if err != nil {
+			return err
+		}
+	}
+	return
+}
@@ -0,0 +1,173 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CursorNext continues after the cursor's row.
+	CursorNext = "next"
+	// CursorPrev continues before the cursor's row.
+	CursorPrev = "prev"
+)
+
+// nullSortTime stands in for a missing created_at or completed_at, and
+// nullDueTime for a missing due_at, so that Tasks without a due date come
+// last when sorting by it in ascending order. Both match the NVL of their
+// sort expression.
+var (
+	nullSortTime = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	nullDueTime  = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// priorityRank orders the priorities in SQL, matching priorityRanks.
+const priorityRank = "CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 ELSE 0 END"
+
+// priorityRanks are the ranks of the priorities, a missing one ranking 0.
+var priorityRanks = map[string]int64{
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+// sortKeys are the sort fields with the SQL expression rows are ordered by
+// and the same value read from a Task. Expressions replace NULLs, which would
+// otherwise break the keyset comparison.
+var sortKeys = map[string]struct {
+	expression string
+	value      func(t Task) interface{}
+}{
+	"title": {
+		expression: "title",
+		value:      func(t Task) interface{} { return t.Title },
+	},
+	"status": {
+		expression: "NVL(status, ' ')",
+		value: func(t Task) interface{} {
+			if t.Status.String == "" {
+				return " "
+			}
+			return t.Status.String
+		},
+	},
+	"created_at": {
+		expression: "NVL(created_at, TIMESTAMP '1970-01-01 00:00:00')",
+		value: func(t Task) interface{} {
+			if !t.CreatedAt.Valid {
+				return nullSortTime
+			}
+			return t.CreatedAt.Time
+		},
+	},
+	"due_at": {
+		expression: "NVL(due_at, TIMESTAMP '9999-12-31 00:00:00')",
+		value: func(t Task) interface{} {
+			if !t.DueAt.Valid {
+				return nullDueTime
+			}
+			return t.DueAt.Time
+		},
+	},
+	"priority": {
+		expression: priorityRank,
+		value:      func(t Task) interface{} { return priorityRanks[t.Priority.String] },
+	},
+	"completed_at": {
+		expression: "NVL(completed_at, TIMESTAMP '1970-01-01 00:00:00')",
+		value: func(t Task) interface{} {
+			if !t.CompletedAt.Valid {
+				return nullSortTime
+			}
+			return t.CompletedAt.Time
+		},
+	},
+}
+
+// TaskCursor is the keyset position a page of Tasks continues from: the sort
+// value and ID of the row at the page boundary. It is handed to clients as a
+// signed token.
+type TaskCursor struct {
+	Field     string    `json:"f"`
+	Order     string    `json:"o"`
+	Filter    string    `json:"h"`
+	Text      string    `json:"s,omitempty"`
+	Time      time.Time `json:"t,omitempty"`
+	Number    int64     `json:"n,omitempty"`
+	ID        string    `json:"i"`
+	Direction string    `json:"d"`
+}
+
+// TaskCursors are the tokens of the pages around the current one.
+type TaskCursors struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// NewTaskCursor returns the cursor continuing from task in direction.
+func NewTaskCursor(filter TaskFilter, task Task, direction string) (cursor TaskCursor) {
+	cursor = TaskCursor{
+		Field:     filter.Sort.Field,
+		Order:     filter.Sort.Order,
+		Filter:    filter.fingerprint(),
+		ID:        task.ID.String(),
+		Direction: direction,
+	}
+	switch value := sortKeys[filter.Sort.Field].value(task).(type) {
+	case time.Time:
+		cursor.Time = value
+	case int64:
+		cursor.Number = value
+	case string:
+		cursor.Text = value
+	}
+	return
+}
+
+// Validate checks that the cursor was made for the same filter and sort.
+func (c TaskCursor) Validate(filter TaskFilter) (err error) {
+	if c.Field != filter.Sort.Field || c.Order != filter.Sort.Order {
+		return errors.New("cursor was made for another sort")
+	}
+	if c.Filter != filter.fingerprint() {
+		return errors.New("cursor was made for another filter")
+	}
+	if c.Direction != CursorNext && c.Direction != CursorPrev {
+		return errors.New("cursor is invalid")
+	}
+	return
+}
+
+// SortValue returns the sort value of the cursor's row, of the type its sort
+// key has.
+func (c TaskCursor) SortValue() interface{} {
+	switch sortKeys[c.Field].value(Task{}).(type) {
+	case time.Time:
+		return c.Time
+	case int64:
+		return c.Number
+	}
+	return c.Text
+}
+
+// fingerprint identifies the criteria of a filter, leaving out sort and
+// pagination.
+func (f TaskFilter) fingerprint() string {
+	criteria := []string{f.Keyword, f.Status, f.Priority, formatTime(f.DueAfter), formatTime(f.DueBefore),
+		strconv.FormatBool(f.Overdue), strconv.FormatInt(f.Assignee, 10), strconv.FormatInt(f.TeamID, 10),
+		strings.Join(f.Labels, ","), f.LabelMatch, f.Parent, f.Query, formatTime(f.AsOf)}
+	sum := sha256.Sum256([]byte(strings.Join(criteria, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
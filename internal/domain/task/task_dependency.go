@@ -0,0 +1,141 @@
+package task
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+)
+
+// maxDependencyEdges is the most dependencies walked for the graph or the plan
+// of Tasks.
+const maxDependencyEdges = 1000
+
+var (
+	// ErrDependencyCycle is returned when dependencies would make a Task wait,
+	// directly or not, for itself.
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+// TaskDependency tells that a Task is blocked by another one, its blocker,
+// until the blocker is completed.
+type TaskDependency struct {
+	TaskID    uuid.UUID   `db:"task_id"`
+	BlockerID uuid.UUID   `db:"blocker_id"`
+	CreatedBy null.String `db:"created_by"`
+	CreatedAt time.Time   `db:"created_at"`
+}
+
+// TaskDependencyRequestFormat represents the blocker to add to a Task.
+type TaskDependencyRequestFormat struct {
+	BlockerID string `json:"blockerId" validate:"required,uuid"`
+}
+
+// TaskDependencyEdge is a dependency met walking the graph of a Task, Depth
+// dependencies away from it.
+type TaskDependencyEdge struct {
+	TaskID    uuid.UUID `db:"task_id" json:"taskId"`
+	BlockerID uuid.UUID `db:"blocker_id" json:"blockerId"`
+	Depth     int       `db:"depth" json:"-"`
+}
+
+// TaskGraphNode is a Task within a dependency graph.
+type TaskGraphNode struct {
+	ID          uuid.UUID   `db:"id"`
+	Title       string      `db:"title"`
+	Status      null.String `db:"status"`
+	CompletedAt null.Time   `db:"completed_at"`
+}
+
+// TaskGraphNodeResponse represents a TaskGraphNode for JSON serializing. Depth
+// is how many dependencies away from the Task of a graph it is.
+type TaskGraphNodeResponse struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	Completed bool   `json:"completed"`
+	Depth     int    `json:"depth,omitempty"`
+}
+
+// ToResponseFormat converts a TaskGraphNode into its response format.
+func (n TaskGraphNode) ToResponseFormat(depth int) TaskGraphNodeResponse {
+	return TaskGraphNodeResponse{
+		ID:        n.ID.String(),
+		Title:     n.Title,
+		Status:    n.Status.String,
+		Completed: n.CompletedAt.Valid,
+		Depth:     depth,
+	}
+}
+
+type TaskDependencyResponseFormat struct {
+	Message    string              `json:"message"`
+	Dependency *TaskDependencyEdge `json:"dependency,omitempty"`
+}
+
+// TaskDependencyGraphResponseFormat is the graph of a Task: the Tasks it waits
+// for, upstream, and the Tasks waiting for it, downstream, with the
+// dependencies between them. Truncated tells that the graph was cut short.
+type TaskDependencyGraphResponseFormat struct {
+	Task       TaskGraphNodeResponse   `json:"task"`
+	Upstream   []TaskGraphNodeResponse `json:"upstream"`
+	Downstream []TaskGraphNodeResponse `json:"downstream"`
+	Edges      []TaskDependencyEdge    `json:"edges"`
+	Truncated  bool                    `json:"truncated,omitempty"`
+}
+
+// TaskPlanStep is a Task of a plan. Tasks of the same stage do not wait for
+// one another; BlockedBy are the Tasks it directly waits for.
+type TaskPlanStep struct {
+	TaskGraphNodeResponse
+	Stage     int      `json:"stage"`
+	BlockedBy []string `json:"blockedBy"`
+}
+
+type TaskPlanResponseFormat struct {
+	Tasks []TaskPlanStep `json:"tasks"`
+}
+
+// PlanStages returns the stage of every Task of the dependency graph given by
+// edges: 0 for Tasks waiting for none, one more than the latest of its
+// blockers otherwise. It fails with ErrDependencyCycle on a cycle.
+func PlanStages(edges []TaskDependencyEdge) (stages map[uuid.UUID]int, err error) {
+	blockers := make(map[uuid.UUID][]uuid.UUID)
+	for _, edge := range edges {
+		blockers[edge.TaskID] = append(blockers[edge.TaskID], edge.BlockerID)
+	}
+
+	stages = make(map[uuid.UUID]int)
+	visiting := make(map[uuid.UUID]bool)
+	var stage func(id uuid.UUID) (int, error)
+	stage = func(id uuid.UUID) (int, error) {
+		if s, ok := stages[id]; ok {
+			return s, nil
+		}
+		if visiting[id] {
+			return 0, ErrDependencyCycle
+		}
+		visiting[id] = true
+		s := 0
+		for _, blocker := range blockers[id] {
+			blockerStage, err := stage(blocker)
+			if err != nil {
+				return 0, err
+			}
+			if blockerStage+1 > s {
+				s = blockerStage + 1
+			}
+		}
+		visiting[id] = false
+		stages[id] = s
+		return s, nil
+	}
+
+	for _, edge := range edges {
+		if _, err = stage(edge.TaskID); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
@@ -0,0 +1,242 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	dependencyQueries = struct {
+		lockDependencies string
+		countCycles      string
+		insertDependency string
+		deleteDependency string
+		selectEdges      string
+		selectNodes      string
+		selectBlockers   string
+	}{
+		// dependencies are added one at a time, so that two of them cannot
+		// close a cycle together
+		lockDependencies: `LOCK TABLE temp.task_dependencies IN EXCLUSIVE MODE`,
+		// counts the dependencies upstream of the blocker (:2) that are
+		// blocked by the Task (:1), which the new dependency would close into
+		// a cycle
+		countCycles: `
+			SELECT COUNT(*)
+			FROM temp.task_dependencies
+			WHERE blocker_id = :1
+			START WITH task_id = :2
+			CONNECT BY NOCYCLE PRIOR blocker_id = task_id`,
+		insertDependency: `
+			INSERT INTO temp.task_dependencies (
+				task_id,
+				blocker_id,
+				created_by,
+				created_at
+			) VALUES (
+				:task_id,
+				:blocker_id,
+				:created_by,
+				:created_at)`,
+		deleteDependency: `
+			DELETE FROM temp.task_dependencies
+			WHERE task_id=:task_id AND blocker_id=:blocker_id`,
+		selectEdges: `
+			SELECT
+				task_id,
+				blocker_id,
+				LEVEL AS depth
+			FROM temp.task_dependencies`,
+		selectNodes: `
+			SELECT
+				id,
+				title,
+				status,
+				completed_at
+			FROM temp.tasks
+			WHERE deleted_at IS NULL`,
+		// blockers are open until completed; deleted ones block no more
+		selectBlockers: `
+			SELECT
+				id,
+				title,
+				status,
+				completed_at
+			FROM temp.tasks
+			WHERE deleted_at IS NULL AND completed_at IS NULL
+				AND id IN (SELECT blocker_id FROM temp.task_dependencies WHERE task_id = :1)
+			ORDER BY title`,
+	}
+)
+
+// DependencyRepository is the repository for the dependencies between Tasks.
+// Dependencies are not versioned with their Tasks.
+type DependencyRepository interface {
+	// Create fails with ErrDependencyCycle when the blocker waits, directly
+	// or not, for the Task.
+	Create(ctx context.Context, dependency TaskDependency) (err error)
+	Delete(ctx context.Context, taskID uuid.UUID, blockerID uuid.UUID) (err error)
+	// ResolveUpstream and ResolveDownstream walk at most limit dependencies,
+	// nearest first.
+	ResolveUpstream(ctx context.Context, ids []uuid.UUID, limit int) (edges []TaskDependencyEdge, err error)
+	ResolveDownstream(ctx context.Context, id uuid.UUID, limit int) (edges []TaskDependencyEdge, err error)
+	ResolveNodes(ctx context.Context, ids []uuid.UUID) (nodes []TaskGraphNode, err error)
+	ResolveOpenBlockers(ctx context.Context, id uuid.UUID) (blockers []TaskGraphNode, err error)
+}
+
+// DependencyRepositoryOracle is the Oracle-backed implementation of DependencyRepository.
+type DependencyRepositoryOracle struct {
+	DB *infras.OracleConn
+}
+
+// ProvideDependencyRepositoryOracle is the provider for this repository.
+func ProvideDependencyRepositoryOracle(db *infras.OracleConn) *DependencyRepositoryOracle {
+	s := new(DependencyRepositoryOracle)
+	s.DB = db
+	return s
+}
+
+// Create adds a dependency, unless it would close a cycle.
+func (r *DependencyRepositoryOracle) Create(ctx context.Context, dependency TaskDependency) (err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		_, err = tx.ExecContext(ctx, dependencyQueries.lockDependencies)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+
+		var cycles int
+		err = tx.GetContext(ctx, &cycles, dependencyQueries.countCycles, dependency.TaskID, dependency.BlockerID)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		if cycles > 0 {
+			return ErrDependencyCycle
+		}
+
+		_, err = tx.NamedExecContext(ctx, dependencyQueries.insertDependency, dependency)
+		if err != nil {
+			logger.ErrorWithStack(err)
+		}
+		return
+	})
+	if errors.Is(err, ErrDependencyCycle) {
+		return
+	}
+	return infras.TranslateError("create", "TaskDependency", err)
+}
+
+// Delete removes a dependency.
+func (r *DependencyRepositoryOracle) Delete(ctx context.Context, taskID uuid.UUID, blockerID uuid.UUID) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, dependencyQueries.deleteDependency, map[string]interface{}{
+		"task_id":    taskID,
+		"blocker_id": blockerID,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("delete", "TaskDependency", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return failure.NotFound("TaskDependency")
+	}
+	return
+}
+
+// ResolveUpstream resolves the dependencies the Tasks with the given IDs wait
+// for, directly or not.
+func (r *DependencyRepositoryOracle) ResolveUpstream(ctx context.Context, ids []uuid.UUID, limit int) (edges []TaskDependencyEdge, err error) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := dependencyQueries.selectEdges +
+		" START WITH task_id IN (" + inPlaceholders(len(ids)) + ")" +
+		" CONNECT BY NOCYCLE PRIOR blocker_id = task_id" +
+		" ORDER BY LEVEL FETCH FIRST ? ROWS ONLY"
+
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &edges, db.Rebind(query), append(idArgs(ids), limit)...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveUpstream", "TaskDependency", err)
+	return
+}
+
+// ResolveDownstream resolves the dependencies waiting for a Task, directly or not.
+func (r *DependencyRepositoryOracle) ResolveDownstream(ctx context.Context, id uuid.UUID, limit int) (edges []TaskDependencyEdge, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := dependencyQueries.selectEdges +
+		" START WITH blocker_id = ?" +
+		" CONNECT BY NOCYCLE PRIOR task_id = blocker_id" +
+		" ORDER BY LEVEL FETCH FIRST ? ROWS ONLY"
+
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &edges, db.Rebind(query), id, limit)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveDownstream", "TaskDependency", err)
+	return
+}
+
+// ResolveNodes resolves the Tasks with the given IDs as graph nodes. Deleted
+// Tasks are left out.
+func (r *DependencyRepositoryOracle) ResolveNodes(ctx context.Context, ids []uuid.UUID) (nodes []TaskGraphNode, err error) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := dependencyQueries.selectNodes + " AND id IN (" + inPlaceholders(len(ids)) + ")"
+
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &nodes, db.Rebind(query), idArgs(ids)...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveNodes", "Task", err)
+	return
+}
+
+// ResolveOpenBlockers resolves the blockers of a Task that are not completed.
+func (r *DependencyRepositoryOracle) ResolveOpenBlockers(ctx context.Context, id uuid.UUID) (blockers []TaskGraphNode, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).SelectContext(ctx, &blockers, dependencyQueries.selectBlockers, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveOpenBlockers", "Task", err)
+	return
+}
+
+// inPlaceholders returns the n "?" placeholders of an IN list.
+func inPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func idArgs(ids []uuid.UUID) []interface{} {
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	return args
+}
@@ -1,7 +1,45 @@
 package task
 
 const (
-	MessageSuccessCreatedData = "Task created successfully"
-	MessageSuccessUpdatedData = "Task updated successfully"
-	MessageSuccessDeletedData = "Task deleted successfully"
+	MessageSuccessCreatedData    = "Task created successfully"
+	MessageSuccessUpdatedData    = "Task updated successfully"
+	MessageSuccessDeletedData    = "Task deleted successfully"
+	MessageSuccessRestoredData   = "Task restored successfully"
+	MessageSuccessPurgedData     = "Task permanently deleted"
+	MessageSuccessAssignedData   = "Task assigned successfully"
+	MessageSuccessUnassignedData = "Task unassigned successfully"
+)
+
+const (
+	MessageSuccessCreatedChecklistItem = "Checklist item created successfully"
+	MessageSuccessUpdatedChecklistItem = "Checklist item updated successfully"
+	MessageSuccessDeletedChecklistItem = "Checklist item deleted successfully"
+)
+
+const (
+	MessageSuccessCreatedComment = "Comment created successfully"
+	MessageSuccessUpdatedComment = "Comment updated successfully"
+	MessageSuccessDeletedComment = "Comment deleted successfully"
+)
+
+const (
+	MessageSuccessCreatedAttachment = "Attachment uploaded successfully"
+	MessageSuccessDeletedAttachment = "Attachment deleted successfully"
+)
+
+const (
+	MessageSuccessCreatedRecurrence = "Recurrence created successfully"
+	MessageSuccessUpdatedRecurrence = "Recurrence updated successfully"
+	MessageSuccessDeletedRecurrence = "Recurrence deleted successfully"
+)
+
+const (
+	MessageSuccessAddedBlocker   = "Blocker added successfully"
+	MessageSuccessRemovedBlocker = "Blocker removed successfully"
+)
+
+const (
+	MessageSuccessCreatedLabel = "Label created successfully"
+	MessageSuccessUpdatedLabel = "Label updated successfully"
+	MessageSuccessDeletedLabel = "Label deleted successfully"
 )
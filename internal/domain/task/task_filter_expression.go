@@ -0,0 +1,447 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Limits keeping filter expressions cheap to parse and to run.
+const (
+	maxExpressionLength     = 1024
+	maxExpressionPredicates = 32
+	maxExpressionDepth      = 8
+	maxExpressionListValues = 100
+)
+
+// FilterCondition is a compiled filter expression: a SQL condition that only
+// refers to whitelisted columns and binds every value through a "?"
+// placeholder, with the arguments in placeholder order.
+type FilterCondition struct {
+	SQL  string
+	Args []interface{}
+}
+
+// ParseFilterExpression compiles a filter expression such as
+//
+//	status:pending,completed created>=2026-01-01 (createdBy:7 OR -has:description)
+//
+// Predicates are written field, operator, value without spaces; values with
+// spaces are double quoted. Juxtaposed terms are ANDed, OR binds looser than
+// AND, parentheses group and a leading "-" or NOT negates a term. Supported:
+//
+//	status:a,b             status is one of the values
+//	priority:high,urgent   priority is one of the values
+//	title:word             title contains word, ignoring case
+//	createdBy:1,2          created by one of the users
+//	assignee:1,2           assigned to one of the users
+//	team:1,2               owned by one of the teams
+//	created, updated,      date or RFC 3339 time with :, >, >=, < or <=;
+//	due, completed         a date with ":" matches the whole day
+//	has:description        description is not empty
+//	has:due                a due date is set
+//	has:assignee           assigned to a user
+//	has:team               owned by a team
+//	has:parent             a subtask of another Task
+//	is:overdue             past the due date and not completed
+func ParseFilterExpression(expression string) (condition FilterCondition, err error) {
+	if len(expression) > maxExpressionLength {
+		return condition, fmt.Errorf("must be at most %d characters", maxExpressionLength)
+	}
+
+	tokens, err := tokenizeFilterExpression(expression)
+	if err != nil {
+		return
+	}
+	if len(tokens) == 0 {
+		return condition, errors.New("must not be empty")
+	}
+
+	p := &filterParser{tokens: tokens}
+	condition, err = p.parseOr(0)
+	if err != nil {
+		return
+	}
+	if p.pos < len(p.tokens) {
+		return condition, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenWord filterTokenKind = iota
+	filterTokenOpen
+	filterTokenClose
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+// tokenizeFilterExpression splits an expression into parentheses and words.
+// Double quotes inside a word keep spaces and parentheses in it.
+func tokenizeFilterExpression(expression string) (tokens []filterToken, err error) {
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: filterTokenOpen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: filterTokenClose, text: ")"})
+			i++
+		default:
+			var word strings.Builder
+			quoted := false
+			for ; i < len(runes); i++ {
+				r := runes[i]
+				if !quoted && (unicode.IsSpace(r) || r == '(' || r == ')') {
+					break
+				}
+				if r == '"' {
+					quoted = !quoted
+				}
+				word.WriteRune(r)
+			}
+			if quoted {
+				return nil, errors.New("has an unterminated quote")
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, text: word.String()})
+		}
+	}
+	return
+}
+
+type filterParser struct {
+	tokens     []filterToken
+	pos        int
+	predicates int
+}
+
+func (p *filterParser) peek() (token filterToken, ok bool) {
+	if p.pos >= len(p.tokens) {
+		return
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseOr parses terms joined by OR.
+func (p *filterParser) parseOr(depth int) (condition FilterCondition, err error) {
+	if depth > maxExpressionDepth {
+		return condition, fmt.Errorf("must nest at most %d groups", maxExpressionDepth)
+	}
+
+	operands := make([]FilterCondition, 0, 1)
+	for {
+		operand, err := p.parseAnd(depth)
+		if err != nil {
+			return condition, err
+		}
+		operands = append(operands, operand)
+
+		token, ok := p.peek()
+		if !ok || token.kind != filterTokenWord || token.text != "OR" {
+			return joinFilterConditions(operands, " OR "), nil
+		}
+		p.pos++
+	}
+}
+
+// parseAnd parses juxtaposed terms, optionally joined by AND.
+func (p *filterParser) parseAnd(depth int) (condition FilterCondition, err error) {
+	operands := make([]FilterCondition, 0, 1)
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind == filterTokenClose || (token.kind == filterTokenWord && token.text == "OR") {
+			break
+		}
+		if token.kind == filterTokenWord && token.text == "AND" {
+			if len(operands) == 0 {
+				return condition, errors.New("AND must follow a term")
+			}
+			p.pos++
+			continue
+		}
+
+		operand, err := p.parseTerm(depth)
+		if err != nil {
+			return condition, err
+		}
+		operands = append(operands, operand)
+	}
+
+	if len(operands) == 0 {
+		return condition, errors.New("expected a term")
+	}
+	return joinFilterConditions(operands, " AND "), nil
+}
+
+// parseTerm parses an optionally negated group or predicate.
+func (p *filterParser) parseTerm(depth int) (condition FilterCondition, err error) {
+	token, _ := p.peek()
+	negated := false
+	if token.kind == filterTokenWord && token.text == "NOT" {
+		negated = true
+		p.pos++
+		token, _ = p.peek()
+	} else if token.kind == filterTokenWord && token.text == "-" {
+		negated = true
+		p.pos++
+		token, _ = p.peek()
+	} else if token.kind == filterTokenWord && strings.HasPrefix(token.text, "-") {
+		negated = true
+		token.text = token.text[1:]
+		p.tokens[p.pos] = token
+	}
+
+	switch token.kind {
+	case filterTokenOpen:
+		p.pos++
+		condition, err = p.parseOr(depth + 1)
+		if err != nil {
+			return
+		}
+		if closing, ok := p.peek(); !ok || closing.kind != filterTokenClose {
+			return condition, errors.New("has an unclosed parenthesis")
+		}
+		p.pos++
+	case filterTokenWord:
+		if token.text == "" {
+			return condition, errors.New("expected a term")
+		}
+		p.pos++
+		p.predicates++
+		if p.predicates > maxExpressionPredicates {
+			return condition, fmt.Errorf("must have at most %d predicates", maxExpressionPredicates)
+		}
+		condition, err = compileFilterPredicate(token.text)
+		if err != nil {
+			return
+		}
+	default:
+		return condition, errors.New("expected a term")
+	}
+
+	if negated {
+		condition.SQL = "NOT " + condition.SQL
+	}
+	return
+}
+
+func joinFilterConditions(operands []FilterCondition, operator string) FilterCondition {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	parts := make([]string, 0, len(operands))
+	args := make([]interface{}, 0)
+	for _, operand := range operands {
+		parts = append(parts, operand.SQL)
+		args = append(args, operand.Args...)
+	}
+	return FilterCondition{SQL: "(" + strings.Join(parts, operator) + ")", Args: args}
+}
+
+// filterOperators are the comparison operators, longest first.
+var filterOperators = []string{">=", "<=", ":", ">", "<"}
+
+// filterDateColumns maps the date fields to their columns.
+var filterDateColumns = map[string]string{
+	"created":   "created_at",
+	"updated":   "updated_at",
+	"due":       "due_at",
+	"completed": "completed_at",
+}
+
+// compileFilterPredicate compiles a single field, operator, value predicate.
+func compileFilterPredicate(predicate string) (condition FilterCondition, err error) {
+	fieldEnd := strings.IndexFunc(predicate, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if fieldEnd <= 0 {
+		return condition, fmt.Errorf("%q is not a predicate", predicate)
+	}
+	field, rest := predicate[:fieldEnd], predicate[fieldEnd:]
+
+	operator := ""
+	for _, candidate := range filterOperators {
+		if strings.HasPrefix(rest, candidate) {
+			operator = candidate
+			break
+		}
+	}
+	if operator == "" {
+		return condition, fmt.Errorf("%q has no operator", predicate)
+	}
+	value, err := unquoteFilterValue(rest[len(operator):])
+	if err != nil {
+		return
+	}
+	if value == "" {
+		return condition, fmt.Errorf("%q has no value", predicate)
+	}
+
+	if column, ok := filterDateColumns[field]; ok {
+		return compileFilterDate(column, operator, value)
+	}
+	if operator != ":" {
+		return condition, fmt.Errorf("%s only supports \":\"", field)
+	}
+
+	switch field {
+	case "status":
+		values := splitFilterList(value)
+		if len(values) > maxExpressionListValues {
+			return condition, fmt.Errorf("status must list at most %d values", maxExpressionListValues)
+		}
+		args := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			args = append(args, v)
+		}
+		return compileFilterIn("status", args), nil
+	case "priority":
+		values := splitFilterList(value)
+		if len(values) > maxExpressionListValues {
+			return condition, fmt.Errorf("priority must list at most %d values", maxExpressionListValues)
+		}
+		args := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			if !contains(Priorities, v) {
+				return condition, fmt.Errorf("priority %q is not one of %s", v, strings.Join(Priorities, ", "))
+			}
+			args = append(args, v)
+		}
+		return compileFilterIn("priority", args), nil
+	case "createdBy", "team":
+		ids, err := parseFilterIDs(field, value)
+		if err != nil {
+			return condition, err
+		}
+		args := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			args = append(args, id)
+		}
+		return compileFilterIn(filterIDColumns[field], args), nil
+	case "assignee":
+		ids, err := parseFilterIDs(field, value)
+		if err != nil {
+			return condition, err
+		}
+		// the list of assignees is what current Tasks and past versions share
+		operands := make([]FilterCondition, 0, len(ids))
+		for _, id := range ids {
+			operands = append(operands, FilterCondition{SQL: assigneeListCondition, Args: []interface{}{assigneeListArg(id)}})
+		}
+		return joinFilterConditions(operands, " OR "), nil
+	case "title":
+		return FilterCondition{
+			SQL:  `UPPER(title) LIKE UPPER(?) ESCAPE '\'`,
+			Args: []interface{}{"%" + likeEscaper.Replace(value) + "%"},
+		}, nil
+	case "has":
+		switch value {
+		case "description":
+			return FilterCondition{SQL: "(description IS NOT NULL AND LENGTH(description) > 0)"}, nil
+		case "due":
+			return FilterCondition{SQL: "due_at IS NOT NULL"}, nil
+		case "assignee":
+			return FilterCondition{SQL: "assignees IS NOT NULL"}, nil
+		case "team":
+			return FilterCondition{SQL: "team_id IS NOT NULL"}, nil
+		case "parent":
+			return FilterCondition{SQL: "parent_id IS NOT NULL"}, nil
+		}
+		return condition, fmt.Errorf("has %q is not supported", value)
+	case "is":
+		if value != "overdue" {
+			return condition, fmt.Errorf("is %q is not supported", value)
+		}
+		return FilterCondition{SQL: overdueCondition, Args: []interface{}{time.Now()}}, nil
+	}
+	return condition, fmt.Errorf("%q is not a filter field", field)
+}
+
+// filterIDColumns maps the fields listing user or team IDs to their columns.
+var filterIDColumns = map[string]string{
+	"createdBy": "created_by",
+	"team":      "team_id",
+}
+
+// parseFilterIDs parses the list of IDs given to field.
+func parseFilterIDs(field string, value string) (ids []int64, err error) {
+	values := splitFilterList(value)
+	if len(values) > maxExpressionListValues {
+		return nil, fmt.Errorf("%s must list at most %d values", field, maxExpressionListValues)
+	}
+	for _, v := range values {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q is not a number", field, v)
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+func compileFilterIn(column string, args []interface{}) FilterCondition {
+	if len(args) == 1 {
+		return FilterCondition{SQL: column + " = ?", Args: args}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+	return FilterCondition{SQL: column + " IN (" + placeholders + ")", Args: args}
+}
+
+// compileFilterDate compares a date column. A date without a time stands for
+// the whole day, so ":" matches the day, ">" starts after it and "<=" ends
+// with it.
+func compileFilterDate(column string, operator string, value string) (condition FilterCondition, err error) {
+	if at, parseErr := time.Parse(time.RFC3339, value); parseErr == nil {
+		if operator == ":" {
+			operator = "="
+		}
+		return FilterCondition{SQL: column + " " + operator + " ?", Args: []interface{}{at}}, nil
+	}
+
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return condition, fmt.Errorf("%q is not a date or an RFC 3339 time", value)
+	}
+	nextDay := day.AddDate(0, 0, 1)
+	switch operator {
+	case ":":
+		return FilterCondition{SQL: "(" + column + " >= ? AND " + column + " < ?)", Args: []interface{}{day, nextDay}}, nil
+	case ">":
+		return FilterCondition{SQL: column + " >= ?", Args: []interface{}{nextDay}}, nil
+	case "<=":
+		return FilterCondition{SQL: column + " < ?", Args: []interface{}{nextDay}}, nil
+	default:
+		return FilterCondition{SQL: column + " " + operator + " ?", Args: []interface{}{day}}, nil
+	}
+}
+
+func splitFilterList(value string) (values []string) {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return
+}
+
+// unquoteFilterValue removes the double quotes around a value or parts of it.
+func unquoteFilterValue(value string) (string, error) {
+	if !strings.Contains(value, `"`) {
+		return value, nil
+	}
+	if strings.Count(value, `"`)%2 != 0 {
+		return "", errors.New("has an unterminated quote")
+	}
+	return strings.ReplaceAll(value, `"`, ""), nil
+}
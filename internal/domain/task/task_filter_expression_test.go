@@ -0,0 +1,78 @@
+package task
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// filterSQLToken matches the next token of a compiled filter condition.
+var filterSQLToken = regexp.MustCompile(`^(?:\s+|'[^']*'|[A-Za-z_]+|[0-9]+|>=|<=|\|\||[=<>(),?])`)
+
+// filterSQLVocabulary is every word, literal and operator a compiled filter
+// condition may consist of.
+var filterSQLVocabulary = map[string]bool{
+	// columns
+	"status": true, "priority": true, "title": true, "description": true,
+	"created_by": true, "team_id": true, "assignees": true, "parent_id": true,
+	"created_at": true, "updated_at": true, "due_at": true, "completed_at": true,
+	// keywords and functions
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true, "NULL": true,
+	"LIKE": true, "ESCAPE": true, "UPPER": true, "LENGTH": true, "INSTR": true,
+	// literals
+	`'\'`: true, `','`: true, "0": true,
+	// operators and punctuation
+	"=": true, ">=": true, "<=": true, ">": true, "<": true, "||": true,
+	"(": true, ")": true, ",": true, "?": true,
+}
+
+func FuzzParseFilterExpression(f *testing.F) {
+	for _, seed := range []string{
+		`status:pending,completed created>=2026-01-01 (createdBy:7 OR -has:description)`,
+		`title:"drop table" OR title:x'--`,
+		`title:%_\ AND NOT priority:high,urgent`,
+		`due<=2026-03-01T10:00:00Z is:overdue -has:parent`,
+		`status:"a' OR '1'='1" team:1,2 assignee:3`,
+		`completed:2026-02-30 ((status:a) OR (status:b))`,
+		`status:pending;DELETE FROM tasks`,
+		`updated>"2026-01-01" has:due has:assignee has:team`,
+		`-(status:x) NOT - status:y`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, expression string) {
+		condition, err := ParseFilterExpression(expression)
+		if err != nil {
+			return
+		}
+
+		placeholders := 0
+		for rest := condition.SQL; rest != ""; {
+			token := filterSQLToken.FindString(rest)
+			if token == "" {
+				t.Fatalf("%q compiled to %q, with unexpected text at %q", expression, condition.SQL, rest)
+			}
+			rest = rest[len(token):]
+			if strings.TrimSpace(token) == "" {
+				continue
+			}
+			if !filterSQLVocabulary[token] {
+				t.Fatalf("%q compiled to %q, holding %q", expression, condition.SQL, token)
+			}
+			if token == "?" {
+				placeholders++
+			}
+		}
+
+		if placeholders != len(condition.Args) {
+			t.Fatalf("%q compiled to %q with %d placeholders and %d arguments", expression, condition.SQL, placeholders, len(condition.Args))
+		}
+
+		// every predicate has its field and operator written together, which
+		// the compiled condition never does
+		if strings.Contains(condition.SQL, strings.TrimSpace(expression)) {
+			t.Fatalf("%q compiled to %q, holding the expression", expression, condition.SQL)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/tarkiman/go/shared"
+)
+
+const (
+	// HistoryActionCreated is recorded when a Task is created.
+	HistoryActionCreated = "created"
+	// HistoryActionUpdated is recorded when a Task is updated.
+	HistoryActionUpdated = "updated"
+	// HistoryActionDeleted is recorded when a Task is soft deleted.
+	HistoryActionDeleted = "deleted"
+	// HistoryActionRestored is recorded when a Task is restored from the trash.
+	HistoryActionRestored = "restored"
+	// HistoryActionReassigned is recorded when users are assigned to or
+	// unassigned from a Task.
+	HistoryActionReassigned = "reassigned"
+)
+
+// TaskHistory is one recorded change of a Task.
+type TaskHistory struct {
+	ID        uuid.UUID   `db:"id"`
+	TaskID    uuid.UUID   `db:"task_id"`
+	Action    string      `db:"action"`
+	Changes   string      `db:"changes"`
+	Actor     null.String `db:"actor"`
+	RequestID null.String `db:"request_id"`
+	CreatedAt time.Time   `db:"created_at"`
+}
+
+// TaskHistoryQueryData is a TaskHistory row with the total row count.
+type TaskHistoryQueryData struct {
+	TaskHistory
+	FilterCount int `db:"count"`
+}
+
+// FieldChange is the old and new value of a changed field.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// auditedFields are the Task fields whose changes are recorded, in the order
+// they are reported.
+var auditedFields = []struct {
+	name  string
+	value func(t Task) interface{}
+}{
+	{"title", func(t Task) interface{} { return t.Title }},
+	{"description", func(t Task) interface{} { return t.Description }},
+	{"status", func(t Task) interface{} { return t.Status }},
+	{"dueAt", func(t Task) interface{} { return t.DueAt }},
+	{"priority", func(t Task) interface{} { return t.Priority }},
+	{"completedAt", func(t Task) interface{} { return t.CompletedAt }},
+	{"teamId", func(t Task) interface{} { return t.TeamID }},
+	{"assignees", func(t Task) interface{} { return t.Assignees }},
+	{"labels", func(t Task) interface{} { return t.Labels.IDs() }},
+	{"parentId", func(t Task) interface{} { return t.ParentID }},
+	{"deletedAt", func(t Task) interface{} { return t.DeletedAt }},
+	{"deletedBy", func(t Task) interface{} { return t.DeletedBy }},
+}
+
+// DiffTask returns the audited fields that differ between previous and
+// current. A nil previous reports every set field of current as new.
+func DiffTask(previous *Task, current Task) (changes []FieldChange) {
+	changes = make([]FieldChange, 0)
+	for _, field := range auditedFields {
+		newValue := field.value(current)
+		if previous == nil {
+			if !isZeroValue(newValue) {
+				changes = append(changes, FieldChange{Field: field.name, New: newValue})
+			}
+			continue
+		}
+
+		oldValue := field.value(*previous)
+		if !equalValues(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: field.name, Old: oldValue, New: newValue})
+		}
+	}
+	return
+}
+
+// NewTaskHistory records a change of a Task made within ctx.
+func NewTaskHistory(ctx context.Context, action string, previous *Task, current Task) (history TaskHistory, err error) {
+	changes, err := json.Marshal(DiffTask(previous, current))
+	if err != nil {
+		return
+	}
+
+	actor := shared.ActorFromContext(ctx)
+	requestID := shared.RequestIDFromContext(ctx)
+	history = TaskHistory{
+		ID:        uuid.New(),
+		TaskID:    current.ID,
+		Action:    action,
+		Changes:   string(changes),
+		Actor:     null.NewString(actor, actor != ""),
+		RequestID: null.NewString(requestID, requestID != ""),
+		CreatedAt: time.Now(),
+	}
+	return
+}
+
+// equalValues compares two field values by their JSON form, which treats
+// null values and timestamps consistently.
+func equalValues(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+func isZeroValue(value interface{}) bool {
+	encoded, err := json.Marshal(value)
+	return err == nil && (string(encoded) == "null" || string(encoded) == `""`)
+}
+
+// TaskHistoryResponse represents a TaskHistory for JSON serializing.
+type TaskHistoryResponse struct {
+	ID        string        `json:"id"`
+	Action    string        `json:"action"`
+	Changes   []FieldChange `json:"changes"`
+	Actor     string        `json:"actor"`
+	RequestID string        `json:"requestId"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+type TaskHistoryResponseFormat struct {
+	History    []TaskHistoryResponse `json:"history"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+// ToResponseFormat converts a TaskHistory into its response format.
+func (h TaskHistory) ToResponseFormat() (response TaskHistoryResponse, err error) {
+	response = TaskHistoryResponse{
+		ID:        h.ID.String(),
+		Action:    h.Action,
+		Changes:   make([]FieldChange, 0),
+		Actor:     h.Actor.String,
+		RequestID: h.RequestID.String,
+		CreatedAt: h.CreatedAt,
+	}
+	err = json.Unmarshal([]byte(h.Changes), &response.Changes)
+	return
+}
@@ -0,0 +1,177 @@
+package task
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/tarkiman/go/shared"
+)
+
+const (
+	// LabelMatchAny keeps Tasks carrying any of the filtered labels.
+	LabelMatchAny = "any"
+	// LabelMatchAll keeps Tasks carrying all of the filtered labels.
+	LabelMatchAll = "all"
+)
+
+// Label categorizes Tasks, e.g. by the area of work they belong to.
+type Label struct {
+	ID          uuid.UUID   `db:"id"`
+	Name        string      `db:"name" validate:"required,max=64"`
+	Color       string      `db:"color" validate:"required,hexcolor"`
+	Description null.String `db:"description"`
+	CreatedAt   time.Time   `db:"created_at"`
+	UpdatedAt   null.Time   `db:"updated_at"`
+}
+
+// LabelRequestFormat represents a Label's standard formatting for JSON deserializing.
+type LabelRequestFormat struct {
+	Name        string `json:"name" validate:"required,max=64"`
+	Color       string `json:"color" validate:"required,hexcolor"`
+	Description string `json:"description,omitempty" validate:"max=512"`
+}
+
+// NewLabel creates a new Label from its request format.
+func NewLabel(request LabelRequestFormat) (label Label, err error) {
+	label = Label{
+		ID:          uuid.New(),
+		Name:        strings.TrimSpace(request.Name),
+		Color:       strings.ToLower(request.Color),
+		Description: null.NewString(request.Description, request.Description != ""),
+		CreatedAt:   time.Now(),
+	}
+	err = label.Validate()
+	return
+}
+
+// Update a Label.
+func (l *Label) Update(request LabelRequestFormat) (err error) {
+	l.Name = strings.TrimSpace(request.Name)
+	l.Color = strings.ToLower(request.Color)
+	l.Description = null.NewString(request.Description, request.Description != "")
+	l.UpdatedAt = null.TimeFrom(time.Now())
+	err = l.Validate()
+	return
+}
+
+// Validate validates the entity.
+func (l *Label) Validate() (err error) {
+	validator := shared.GetValidator()
+	return validator.Struct(l)
+}
+
+// LabelResponse represents a Label for JSON serializing.
+type LabelResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Color       string     `json:"color"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+type LabelResponseFormat struct {
+	Message string         `json:"message"`
+	Label   *LabelResponse `json:"label,omitempty"`
+}
+
+type LabelListResponseFormat struct {
+	Labels []LabelResponse `json:"labels"`
+}
+
+// ToResponseFormat converts a Label into its response format.
+func (l Label) ToResponseFormat() LabelResponse {
+	return LabelResponse{
+		ID:          l.ID.String(),
+		Name:        l.Name,
+		Color:       l.Color,
+		Description: l.Description.String,
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt.Ptr(),
+	}
+}
+
+// TaskLabel is a Label as carried by a Task.
+type TaskLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// TaskLabels are the labels of a Task, by name. They are read as a JSON array,
+// which versions of a Task keep as they were.
+type TaskLabels []TaskLabel
+
+// NewTaskLabels returns the labels of a Task carrying labels.
+func NewTaskLabels(labels []Label) (taskLabels TaskLabels) {
+	for _, label := range labels {
+		taskLabels = append(taskLabels, TaskLabel{ID: label.ID.String(), Name: label.Name, Color: label.Color})
+	}
+	return
+}
+
+// IDs returns the IDs of the labels.
+func (t TaskLabels) IDs() (ids []string) {
+	for _, label := range t {
+		ids = append(ids, label.ID)
+	}
+	return
+}
+
+// Contains tells whether the label with id is one of the labels.
+func (t TaskLabels) Contains(id string) bool {
+	for _, label := range t {
+		if label.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan reads the labels from their JSON array.
+func (t *TaskLabels) Scan(value interface{}) (err error) {
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return
+	case string:
+		return t.unmarshal([]byte(v))
+	case []byte:
+		return t.unmarshal(v)
+	}
+	return fmt.Errorf("cannot scan %T into TaskLabels", value)
+}
+
+func (t *TaskLabels) unmarshal(encoded []byte) (err error) {
+	var labels TaskLabels
+	if err = json.Unmarshal(encoded, &labels); err != nil {
+		return fmt.Errorf("cannot scan %q into TaskLabels: %w", encoded, err)
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+	*t = labels
+	return
+}
+
+// Value writes the labels as a JSON array, NULL when there are none.
+func (t TaskLabels) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(t)
+	return string(encoded), err
+}
+
+// labelListCondition holds for Tasks whose JSON array of labels holds the
+// label bound to it through labelListArg.
+const labelListCondition = "INSTR(labels, ?) > 0"
+
+func labelListArg(id string) string {
+	return `"id":"` + id + `"`
+}
@@ -0,0 +1,220 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	labelQueries = struct {
+		selectLabel     string
+		insertLabel     string
+		updateLabel     string
+		deleteLabel     string
+		selectTaskIDs   string
+		insertTaskLabel string
+		deleteTaskLabel string
+	}{
+		selectLabel: `
+			SELECT
+				id,
+				name,
+				color,
+				description,
+				created_at,
+				updated_at
+			FROM temp.labels`,
+		insertLabel: `
+			INSERT INTO temp.labels (
+				id,
+				name,
+				color,
+				description,
+				created_at
+			) VALUES (
+				:id,
+				:name,
+				:color,
+				:description,
+				:created_at)`,
+		updateLabel: `
+			UPDATE temp.labels SET
+				name=:name,
+				color=:color,
+				description=:description,
+				updated_at=:updated_at
+			WHERE id=:id`,
+		deleteLabel: `
+			DELETE FROM temp.labels
+			WHERE id=:id`,
+		selectTaskIDs: `
+			SELECT task_id
+			FROM temp.task_labels
+			WHERE label_id = :1`,
+		insertTaskLabel: `
+			INSERT INTO temp.task_labels (
+				task_id,
+				label_id
+			) VALUES (
+				:task_id,
+				:label_id)`,
+		deleteTaskLabel: `
+			DELETE FROM temp.task_labels
+			WHERE task_id=:task_id AND label_id=:label_id`,
+	}
+)
+
+// taskLabelsColumn selects the labels of the current Tasks as a JSON array of
+// TaskLabel, in the order of their names. Versions of a Task keep the array in
+// their labels column instead.
+const taskLabelsColumn = `
+				(SELECT JSON_ARRAYAGG(
+						JSON_OBJECT('id' VALUE l.id, 'name' VALUE l.name, 'color' VALUE l.color)
+						ORDER BY l.name RETURNING VARCHAR2(4000))
+					FROM temp.task_labels tl JOIN temp.labels l ON l.id = tl.label_id
+					WHERE tl.task_id = tasks.id) AS labels`
+
+// LabelRepository is the repository for Label data.
+type LabelRepository interface {
+	Create(ctx context.Context, label Label) (err error)
+	ResolveAll(ctx context.Context) (labels []Label, err error)
+	ResolveByID(ctx context.Context, id uuid.UUID) (label Label, exist bool, err error)
+	ResolveByIDs(ctx context.Context, ids []string) (labels []Label, err error)
+	// Update and Delete return the IDs of the Tasks carrying the label, whose
+	// labels they change.
+	Update(ctx context.Context, label Label) (taskIDs []uuid.UUID, err error)
+	Delete(ctx context.Context, id uuid.UUID) (taskIDs []uuid.UUID, err error)
+}
+
+// LabelRepositoryOracle is the Oracle-backed implementation of LabelRepository.
+type LabelRepositoryOracle struct {
+	DB *infras.OracleConn
+}
+
+// ProvideLabelRepositoryOracle is the provider for this repository.
+func ProvideLabelRepositoryOracle(db *infras.OracleConn) *LabelRepositoryOracle {
+	s := new(LabelRepositoryOracle)
+	s.DB = db
+	return s
+}
+
+// Create creates a new Label.
+func (r *LabelRepositoryOracle) Create(ctx context.Context, label Label) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, labelQueries.insertLabel, label)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return infras.TranslateError("create", "Label", err)
+}
+
+// ResolveAll resolves all Labels by name.
+func (r *LabelRepositoryOracle) ResolveAll(ctx context.Context) (labels []Label, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).SelectContext(ctx, &labels, labelQueries.selectLabel+" ORDER BY UPPER(name)")
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveAll", "Label", err)
+	return
+}
+
+// ResolveByID resolves a Label by its ID.
+func (r *LabelRepositoryOracle) ResolveByID(ctx context.Context, id uuid.UUID) (label Label, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(ctx, &label, labelQueries.selectLabel+" WHERE id = :1", id)
+	switch {
+	case err == sql.ErrNoRows:
+		return label, false, nil
+	case err != nil:
+		return label, false, infras.TranslateError("resolveByID", "Label", err)
+	}
+	return label, true, err
+}
+
+// ResolveByIDs resolves the Labels with the given IDs by name. IDs of no Label
+// are left out.
+func (r *LabelRepositoryOracle) ResolveByIDs(ctx context.Context, ids []string) (labels []Label, err error) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := labelQueries.selectLabel +
+		" WHERE id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")" +
+		" ORDER BY name"
+
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &labels, db.Rebind(query), args...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveByIDs", "Label", err)
+	return
+}
+
+// Update updates a Label.
+func (r *LabelRepositoryOracle) Update(ctx context.Context, label Label) (taskIDs []uuid.UUID, err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		result, err := tx.NamedExecContext(ctx, labelQueries.updateLabel, label)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return failure.NotFound("Label")
+		}
+
+		taskIDs, err = r.txResolveTaskIDs(ctx, tx, label.ID)
+		return err
+	})
+	return taskIDs, infras.TranslateError("update", "Label", err)
+}
+
+// Delete deletes a Label, taking it off the Tasks carrying it.
+func (r *LabelRepositoryOracle) Delete(ctx context.Context, id uuid.UUID) (taskIDs []uuid.UUID, err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		taskIDs, err = r.txResolveTaskIDs(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.NamedExecContext(ctx, labelQueries.deleteLabel, map[string]interface{}{"id": id})
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return err
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return failure.NotFound("Label")
+		}
+		return nil
+	})
+	return taskIDs, infras.TranslateError("delete", "Label", err)
+}
+
+// txResolveTaskIDs resolves the IDs of the Tasks carrying a Label within tx.
+func (r *LabelRepositoryOracle) txResolveTaskIDs(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (taskIDs []uuid.UUID, err error) {
+	err = tx.SelectContext(ctx, &taskIDs, labelQueries.selectTaskIDs, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
@@ -0,0 +1,123 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/shared/failure"
+)
+
+// LabelService is the service interface for Label entities.
+type LabelService interface {
+	Create(ctx context.Context, requestFormat LabelRequestFormat) (response LabelResponseFormat, err error)
+	ResolveAll(ctx context.Context) (response LabelListResponseFormat, err error)
+	ResolveByID(ctx context.Context, id uuid.UUID) (label Label, err error)
+	Update(ctx context.Context, id uuid.UUID, requestFormat LabelRequestFormat) (response LabelResponseFormat, err error)
+	Delete(ctx context.Context, id uuid.UUID) (response LabelResponseFormat, err error)
+}
+
+// TaskInvalidator drops what is cached of Tasks changed by a write made
+// around the TaskRepository.
+type TaskInvalidator interface {
+	Invalidate(ctx context.Context, ids ...uuid.UUID)
+}
+
+// LabelServiceImpl is the service implementation for Label entities.
+type LabelServiceImpl struct {
+	LabelRepository LabelRepository
+	Tasks           TaskInvalidator
+}
+
+// ProvideLabelServiceImpl is the provider for this service.
+func ProvideLabelServiceImpl(labelRepository LabelRepository, tasks TaskInvalidator) *LabelServiceImpl {
+	s := new(LabelServiceImpl)
+	s.LabelRepository = labelRepository
+	s.Tasks = tasks
+	return s
+}
+
+// Create creates a new Label.
+func (s *LabelServiceImpl) Create(ctx context.Context, requestFormat LabelRequestFormat) (response LabelResponseFormat, err error) {
+	label, err := NewLabel(requestFormat)
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	err = s.LabelRepository.Create(ctx, label)
+	if err != nil {
+		log.Err(err).Msg("[Create] error LabelRepository.Create")
+		return
+	}
+
+	formatted := label.ToResponseFormat()
+	response = LabelResponseFormat{Message: MessageSuccessCreatedLabel, Label: &formatted}
+	return
+}
+
+// ResolveAll resolves all Labels by name.
+func (s *LabelServiceImpl) ResolveAll(ctx context.Context) (response LabelListResponseFormat, err error) {
+	labels, err := s.LabelRepository.ResolveAll(ctx)
+	if err != nil {
+		log.Err(err).Msg("[ResolveAll] error LabelRepository.ResolveAll")
+		return
+	}
+
+	response.Labels = make([]LabelResponse, 0, len(labels))
+	for _, label := range labels {
+		response.Labels = append(response.Labels, label.ToResponseFormat())
+	}
+	return
+}
+
+// ResolveByID resolves a Label by its ID.
+func (s *LabelServiceImpl) ResolveByID(ctx context.Context, id uuid.UUID) (label Label, err error) {
+	label, exist, err := s.LabelRepository.ResolveByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[ResolveByID] error LabelRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		return label, failure.NotFound(fmt.Sprintf("LabelID %s", id.String()))
+	}
+	return
+}
+
+// Update updates a Label, which the Tasks carrying it show from then on.
+func (s *LabelServiceImpl) Update(ctx context.Context, id uuid.UUID, requestFormat LabelRequestFormat) (response LabelResponseFormat, err error) {
+	label, err := s.ResolveByID(ctx, id)
+	if err != nil {
+		return
+	}
+
+	err = label.Update(requestFormat)
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	taskIDs, err := s.LabelRepository.Update(ctx, label)
+	if err != nil {
+		log.Err(err).Msg("[Update] error LabelRepository.Update")
+		return
+	}
+	s.Tasks.Invalidate(ctx, taskIDs...)
+
+	formatted := label.ToResponseFormat()
+	response = LabelResponseFormat{Message: MessageSuccessUpdatedLabel, Label: &formatted}
+	return
+}
+
+// Delete deletes a Label, taking it off the Tasks carrying it. Past versions
+// of those Tasks keep it.
+func (s *LabelServiceImpl) Delete(ctx context.Context, id uuid.UUID) (response LabelResponseFormat, err error) {
+	taskIDs, err := s.LabelRepository.Delete(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[Delete] error LabelRepository.Delete")
+		return
+	}
+	s.Tasks.Invalidate(ctx, taskIDs...)
+
+	response.Message = MessageSuccessDeletedLabel
+	return
+}
@@ -1,7 +1,9 @@
 package task
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,28 +16,99 @@ const (
 	EmptyFilterError string = "empty filter is not allowed"
 )
 
+const (
+	// AggregateType identifies Task events in the outbox.
+	AggregateType = "Task"
+	// EventTaskCreated is recorded when a Task is created.
+	EventTaskCreated = "TaskCreated"
+	// EventTaskUpdated is recorded when a Task is updated.
+	EventTaskUpdated = "TaskUpdated"
+	// EventTaskDeleted is recorded when a Task is soft deleted.
+	EventTaskDeleted = "TaskDeleted"
+	// EventTaskRestored is recorded when a soft deleted Task is restored.
+	EventTaskRestored = "TaskRestored"
+	// EventTaskPurged is recorded when a Task is permanently deleted.
+	EventTaskPurged = "TaskPurged"
+	// EventTaskReassigned is recorded when users are assigned to or unassigned
+	// from a Task.
+	EventTaskReassigned = "TaskReassigned"
+	// EventTaskMentioned is recorded for every user mentioned in a comment on
+	// a Task, for them to be notified.
+	EventTaskMentioned = "TaskMentioned"
+)
+
+// Priorities of a Task, from lowest to highest.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities are the priorities of a Task, from lowest to highest.
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+var (
+	// ErrVersionMismatch is returned by the repository when a Task changed
+	// since the version the caller based its change on.
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
 // Task is a sample parent entity model.
 type Task struct {
 	ID          uuid.UUID   `db:"ID"`
 	Title       string      `db:"TITLE" validate:"required"`
 	Description null.String `db:"DESCRIPTION"`
 	Status      null.String `db:"STATUS"`
+	DueAt       null.Time   `db:"due_at"`
+	Priority    null.String `db:"priority"`
+	CompletedAt null.Time   `db:"completed_at"`
+	TeamID      null.Int    `db:"team_id"`
+	Assignees   UserIDs     `db:"assignees"`
+	Labels      TaskLabels  `db:"labels"`
+	ParentID    null.String `db:"parent_id"`
 	CreatedAt   null.Time   `db:"created_at"`
 	CreatedBy   null.Int    `db:"created_by"`
 	UpdatedAt   null.Time   `db:"updated_at"`
 	UpdatedBy   null.Int    `db:"updated_by"`
 	DeletedAt   null.Time   `db:"deleted_at"`
 	DeletedBy   null.String `db:"deleted_by"`
+	Version     int64       `db:"version"`
+	// Progress is computed from the subtasks and checklist of the Task when
+	// it is resolved, it is not stored.
+	Progress *TaskProgress `db:"-"`
+}
+
+// TaskVersion is a past or current state of a Task and the time from which
+// it held.
+type TaskVersion struct {
+	Task
+	ValidFrom time.Time `db:"valid_from"`
+	ValidTo   null.Time `db:"valid_to"`
 }
 
 // TaskRequestFormat represents a Task's standard formatting for JSON deserializing.
 type TaskRequestFormat struct {
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	Status      string `json:"status" validate:"oneof=pending completed"`
-	ID          string `json:"-"`
-	CreatedBy   int64  `json:"-"`
-	UpdatedBy   int64  `json:"-"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	DueAt       *time.Time `json:"dueAt,omitempty"`
+	Priority    string     `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	TeamID      *int64     `json:"teamId,omitempty"`
+	// Assignees are the users a new Task is assigned to; the assignees of an
+	// existing Task are changed through its assignees.
+	Assignees []int64 `json:"assignees,omitempty" validate:"max=50,dive,min=1"`
+	// Labels are the IDs of the labels of the Task. When updating, leaving
+	// them out keeps the current labels and an empty list removes them.
+	Labels []string `json:"labels,omitempty" validate:"max=20,dive,uuid"`
+	// ParentID makes the Task a subtask of another one; leaving it out makes
+	// it a top-level Task.
+	ParentID  string `json:"parentId,omitempty" validate:"omitempty,uuid"`
+	Version   *int64 `json:"version,omitempty"`
+	ID        string `json:"-"`
+	CreatedBy int64  `json:"-"`
+	UpdatedBy int64  `json:"-"`
 }
 
 // TaskRequestFormat creates a new Task from its request format.
@@ -47,8 +120,14 @@ func (t Task) CreateRequestFormat(request TaskRequestFormat) (task Task, err err
 		Title:       request.Title,
 		Description: null.StringFrom(request.Description),
 		Status:      null.StringFrom(request.Status),
+		DueAt:       null.TimeFromPtr(request.DueAt),
+		Priority:    null.NewString(request.Priority, request.Priority != ""),
+		TeamID:      null.IntFromPtr(request.TeamID),
+		Assignees:   NewUserIDs(request.Assignees...),
+		ParentID:    null.NewString(strings.ToLower(request.ParentID), request.ParentID != ""),
 		CreatedAt:   null.TimeFrom(time.Now()),
 		CreatedBy:   null.IntFrom(createdBy), //to do get from token
+		Version:     1,
 	}
 	return
 }
@@ -57,7 +136,13 @@ func (t Task) CreateRequestFormat(request TaskRequestFormat) (task Task, err err
 func (u *Task) UpdateRequestFormat(request TaskRequestFormat) (err error) {
 	u.Title = request.Title
 	u.Description = null.StringFrom(request.Description)
-	u.Status = null.StringFrom(request.Status)
+	if request.Status != "" {
+		u.Status = null.StringFrom(request.Status)
+	}
+	u.DueAt = null.TimeFromPtr(request.DueAt)
+	u.Priority = null.NewString(request.Priority, request.Priority != "")
+	u.TeamID = null.IntFromPtr(request.TeamID)
+	u.ParentID = null.NewString(strings.ToLower(request.ParentID), request.ParentID != "")
 	u.UpdatedAt = null.TimeFrom(time.Now())
 	u.UpdatedBy = null.IntFrom(request.UpdatedBy)
 	err = u.Validate()
@@ -66,10 +151,20 @@ func (u *Task) UpdateRequestFormat(request TaskRequestFormat) (err error) {
 
 // TaskResponseFormat represents a Task's standard formatting for JSON serializing.
 type TaskResponse struct {
-	ID         string `json:"id"`
-	Title      string `json:"title"`
-	Desciption string `json:"description"`
-	Status     string `json:"status"`
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Desciption  string        `json:"description"`
+	Status      string        `json:"status"`
+	DueAt       *time.Time    `json:"dueAt,omitempty"`
+	Priority    string        `json:"priority,omitempty"`
+	CompletedAt *time.Time    `json:"completedAt,omitempty"`
+	Overdue     bool          `json:"overdue"`
+	TeamID      *int64        `json:"teamId,omitempty"`
+	Assignees   []int64       `json:"assignees"`
+	Labels      []TaskLabel   `json:"labels"`
+	ParentID    *string       `json:"parentId,omitempty"`
+	Progress    *TaskProgress `json:"progress,omitempty"`
+	Version     int64         `json:"version"`
 }
 
 type TaskResponseFormat struct {
@@ -78,14 +173,8 @@ type TaskResponseFormat struct {
 }
 
 func (t Task) ToJSONResponseFormat(message string) (response TaskResponseFormat) {
-	task := TaskResponse{
-		ID:         t.ID.String(),
-		Title:      t.Title,
-		Desciption: t.Description.String,
-		Status:     t.Status.String,
-	}
 	response.Message = message
-	response.Task = task
+	response.Task = t.ToResponseFormat()
 	return
 }
 
@@ -101,6 +190,75 @@ func (u *Task) SoftDelete(deletedBy string) (err error) {
 	return
 }
 
+// SetCompletion keeps CompletedAt in line with whether the Task is done. A
+// Task becoming done is completed at completedAt, or now when not given; a
+// Task no longer done has its completion cleared.
+func (u *Task) SetCompletion(done bool, completedAt *time.Time) (err error) {
+	if !done {
+		if completedAt != nil {
+			return failure.InvalidFields(map[string]string{"completedAt": "can only be set on a done Task"})
+		}
+		u.CompletedAt = null.Time{}
+		return
+	}
+
+	switch {
+	case completedAt != nil:
+		if completedAt.After(time.Now()) {
+			return failure.InvalidFields(map[string]string{"completedAt": "must not be in the future"})
+		}
+		u.CompletedAt = null.TimeFrom(*completedAt)
+	case !u.CompletedAt.Valid:
+		u.CompletedAt = null.TimeFrom(time.Now())
+	}
+	return
+}
+
+// Overdue tells whether the Task is past its due date at now without having
+// been completed.
+func (t Task) Overdue(now time.Time) bool {
+	return t.DueAt.Valid && !t.CompletedAt.Valid && t.DueAt.Time.Before(now)
+}
+
+// Restore takes a Task out of the trash by clearing "deletedAt" and "deletedBy".
+func (u *Task) Restore() (err error) {
+	if !u.DeletedAt.Valid {
+		return failure.Conflict("restore", "Task", "not marked as deleted")
+	}
+
+	u.DeletedAt = null.Time{}
+	u.DeletedBy = null.String{}
+
+	return
+}
+
+// Precondition is the version a client expects a Task to have before it
+// changes the Task.
+type Precondition struct {
+	Version int64
+	// Any is set by "If-Match: *", which matches whatever the current version is.
+	Any bool
+	// IfMatch tells whether the version came from an If-Match header, in which
+	// case a mismatch is a failed precondition rather than a conflict.
+	IfMatch bool
+}
+
+// CheckVersion verifies the Task still has the version the client expects.
+func (u *Task) CheckVersion(operationName string, precondition Precondition) (err error) {
+	if precondition.Any || u.Version == precondition.Version {
+		return
+	}
+	return precondition.Mismatch(operationName)
+}
+
+// Mismatch returns the failure reported when the precondition does not hold.
+func (p Precondition) Mismatch(operationName string) error {
+	if p.IfMatch {
+		return failure.PreconditionFailed(operationName, "Task", ErrVersionMismatch.Error())
+	}
+	return failure.Conflict(operationName, "Task", ErrVersionMismatch.Error())
+}
+
 // Validate validates the entity.
 func (u *Task) Validate() (err error) {
 	validator := shared.GetValidator()
@@ -108,13 +266,39 @@ func (u *Task) Validate() (err error) {
 }
 
 type TaskFilter struct {
-	Keyword    string     `json:"keyword"`
-	Sort       TaskSort   `json:"sort"`
-	Pagination Pagination `json:"pagination"`
+	Keyword   string     `json:"keyword"`
+	Status    string     `json:"status"`
+	Priority  string     `json:"priority,omitempty"`
+	DueAfter  *time.Time `json:"dueAfter,omitempty"`
+	DueBefore *time.Time `json:"dueBefore,omitempty"`
+	// Overdue keeps only Tasks past their due date and not completed.
+	Overdue bool `json:"overdue,omitempty"`
+	// Assignee keeps only Tasks assigned to this user.
+	Assignee int64 `json:"assignee,omitempty"`
+	TeamID   int64 `json:"team,omitempty"`
+	// Labels keeps only Tasks carrying any or, with LabelMatch all, all of
+	// these labels.
+	Labels     []string `json:"labels,omitempty"`
+	LabelMatch string   `json:"labelMatch,omitempty"`
+	// Parent keeps only the subtasks of this Task, or with ParentNone only
+	// top-level Tasks.
+	Parent string `json:"parent,omitempty"`
+	Query  string `json:"q,omitempty"`
+	// Condition is Query compiled by ParseFilterExpression.
+	Condition  *FilterCondition `json:"-"`
+	AsOf       *time.Time       `json:"asOf,omitempty"`
+	Sort       TaskSort         `json:"sort"`
+	Pagination Pagination       `json:"pagination"`
+	// Cursor is a signed TaskCursor token; when given, Pagination.Page is ignored.
+	Cursor string `json:"cursor,omitempty"`
+	// WithCount asks for the total number of matching Tasks.
+	WithCount bool `json:"withCount"`
+	// After is Cursor once verified.
+	After *TaskCursor `json:"-"`
 }
 
 type TaskSort struct {
-	Field string `json:"field" validate:"oneof=title status created_at"`
+	Field string `json:"field" validate:"oneof=title status created_at due_at priority completed_at"`
 	Order string `json:"order" validate:"oneof=ASC DESC"`
 }
 
@@ -153,8 +337,53 @@ func (s *TaskSort) Validate() (err error) {
 
 type TaskFilterResponseFormat struct {
 	Tasks      []TaskResponse `json:"tasks"`
-	Pagination Pagination     `json:"pagination"`
+	Keyword    string         `json:"keyword,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Priority   string         `json:"priority,omitempty"`
+	DueAfter   *time.Time     `json:"dueAfter,omitempty"`
+	DueBefore  *time.Time     `json:"dueBefore,omitempty"`
+	Overdue    bool           `json:"overdue,omitempty"`
+	Assignee   int64          `json:"assignee,omitempty"`
+	TeamID     int64          `json:"team,omitempty"`
+	Labels     []string       `json:"labels,omitempty"`
+	LabelMatch string         `json:"labelMatch,omitempty"`
+	Parent     string         `json:"parent,omitempty"`
+	Query      string         `json:"q,omitempty"`
+	AsOf       *time.Time     `json:"asOf,omitempty"`
+	Pagination PageInfo       `json:"pagination"`
 	Sort       TaskSort       `json:"sort"`
+	Cursors    TaskCursors    `json:"cursors"`
+	Links      TaskCursors    `json:"links"`
+}
+
+// PageInfo describes a page of a list. Page is only set for offset
+// pagination, Count and TotalPage only when the total was asked for.
+type PageInfo struct {
+	Page      int  `json:"page,omitempty"`
+	PageSize  int  `json:"pageSize"`
+	Count     *int `json:"count,omitempty"`
+	TotalPage *int `json:"totalPage,omitempty"`
+}
+
+// TaskTrashResponse represents a soft deleted Task with who deleted it and when.
+type TaskTrashResponse struct {
+	TaskResponse
+	DeletedAt time.Time `json:"deletedAt"`
+	DeletedBy string    `json:"deletedBy"`
+}
+
+type TaskTrashResponseFormat struct {
+	Tasks      []TaskTrashResponse `json:"tasks"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+// ToTrashResponseFormat converts a soft deleted Task into its trash listing format.
+func (t Task) ToTrashResponseFormat() TaskTrashResponse {
+	return TaskTrashResponse{
+		TaskResponse: t.ToResponseFormat(),
+		DeletedAt:    t.DeletedAt.Time,
+		DeletedBy:    t.DeletedBy.String,
+	}
 }
 
 type TaskFilterQueryData struct {
@@ -163,17 +392,48 @@ type TaskFilterQueryData struct {
 }
 
 func (t *TaskFilterResponseFormat) SetSortAndPagination(filter TaskFilter) {
+	t.Keyword = filter.Keyword
+	t.Status = filter.Status
+	t.Priority = filter.Priority
+	t.DueAfter = filter.DueAfter
+	t.DueBefore = filter.DueBefore
+	t.Overdue = filter.Overdue
+	t.Assignee = filter.Assignee
+	t.TeamID = filter.TeamID
+	t.Labels = filter.Labels
+	t.LabelMatch = filter.LabelMatch
+	t.Parent = filter.Parent
+	t.Query = filter.Query
+	t.AsOf = filter.AsOf
 	t.Sort = filter.Sort
-	t.Pagination = filter.Pagination
-	t.Pagination.TotalPage = int(math.Ceil(float64(t.Pagination.Count) / float64(t.Pagination.PageSize)))
+	t.Pagination = PageInfo{PageSize: filter.Pagination.PageSize}
+	if filter.Cursor == "" {
+		t.Pagination.Page = filter.Pagination.Page
+	}
+	if filter.WithCount {
+		count := filter.Pagination.Count
+		totalPage := int(math.Ceil(float64(count) / float64(filter.Pagination.PageSize)))
+		t.Pagination.Count = &count
+		t.Pagination.TotalPage = &totalPage
+	}
 }
 
 func (t Task) ToResponseFormat() TaskResponse {
 	resp := TaskResponse{
-		ID:         t.ID.String(),
-		Title:      t.Title,
-		Desciption: t.Description.String,
-		Status:     t.Status.String,
+		ID:          t.ID.String(),
+		Title:       t.Title,
+		Desciption:  t.Description.String,
+		Status:      t.Status.String,
+		DueAt:       t.DueAt.Ptr(),
+		Priority:    t.Priority.String,
+		CompletedAt: t.CompletedAt.Ptr(),
+		Overdue:     t.Overdue(time.Now()),
+		TeamID:      t.TeamID.Ptr(),
+		Assignees:   append([]int64{}, t.Assignees...),
+		Labels:      append([]TaskLabel{}, t.Labels...),
+		ParentID:    t.ParentID.Ptr(),
+		Progress:    t.Progress,
+		Version:     t.Version,
 	}
 	return resp
 }
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+)
+
+const (
+	defaultPurgeBatchSize = 100
+	defaultPurgeInterval  = time.Hour
+)
+
+// TrashPurger periodically purges Tasks whose trash retention has elapsed.
+type TrashPurger struct {
+	Config      *configs.Config
+	TaskService TaskService
+}
+
+// ProvideTrashPurger is the provider for TrashPurger.
+func ProvideTrashPurger(config *configs.Config, taskService TaskService) *TrashPurger {
+	return &TrashPurger{
+		Config:      config,
+		TaskService: taskService,
+	}
+}
+
+// Run purges the trash every purge interval until ctx is cancelled. It does
+// nothing when no retention is configured.
+func (p *TrashPurger) Run(ctx context.Context) {
+	retention := p.Config.Task.Trash.RetentionDays
+	if retention <= 0 {
+		log.Info().Msg("Task trash purge is disabled.")
+		return
+	}
+	interval := time.Duration(p.Config.Task.Trash.PurgeIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+	log.Info().Int("retentionDays", retention).Dur("interval", interval).Msg("Starting task trash purge.")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.TaskService.PurgeTrash(ctx)
+		if err != nil {
+			log.Err(err).Int("purged", purged).Msg("Failed purging task trash")
+		} else if purged > 0 {
+			log.Info().Int("purged", purged).Msg("Purged task trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
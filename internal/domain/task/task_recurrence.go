@@ -0,0 +1,308 @@
+package task
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"github.com/tarkiman/go/shared"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/rrule"
+)
+
+// Generations of a TaskRecurrence, telling when its next occurrence becomes a
+// Task.
+const (
+	// GenerationSchedule makes each occurrence a Task once it is due, less
+	// the lead time of the recurrence.
+	GenerationSchedule = "schedule"
+	// GenerationCompletion makes the next occurrence a Task when the Task of
+	// the previous one is completed.
+	GenerationCompletion = "completion"
+)
+
+const (
+	// exDateLayout is the layout of the dates excluded from a recurrence.
+	exDateLayout = "2006-01-02"
+	// maxUpcomingOccurrences is how many upcoming occurrences a recurrence
+	// is shown with.
+	maxUpcomingOccurrences = 5
+)
+
+var (
+	// ErrOccurrenceMaterialized is returned by the repository when the Task of
+	// an occurrence was created already.
+	ErrOccurrenceMaterialized = errors.New("occurrence already materialized")
+)
+
+// TaskRecurrence is a template of Tasks repeating on an RFC 5545 RRULE
+// schedule in a timezone, from StartsAt. Each occurrence becomes a Task due at
+// that occurrence, except on the dates of ExDates.
+type TaskRecurrence struct {
+	ID          uuid.UUID `db:"id"`
+	Template    string    `db:"template"`
+	RRule       string    `db:"rrule"`
+	Timezone    string    `db:"timezone"`
+	StartsAt    time.Time `db:"starts_at"`
+	ExDates     ExDates   `db:"exdates"`
+	Generation  string    `db:"generation"`
+	LeadMinutes int       `db:"lead_minutes"`
+	// NextOccurrenceAt is the next occurrence to become a Task, null once the
+	// recurrence has ended.
+	NextOccurrenceAt null.Time   `db:"next_occurrence_at"`
+	LastTaskID       null.String `db:"last_task_id"`
+	CreatedBy        null.String `db:"created_by"`
+	CreatedAt        time.Time   `db:"created_at"`
+	UpdatedAt        null.Time   `db:"updated_at"`
+	Version          int64       `db:"version"`
+}
+
+// TaskRecurrenceQueryData is a TaskRecurrence row with the total row count.
+type TaskRecurrenceQueryData struct {
+	TaskRecurrence
+	FilterCount int `db:"count"`
+}
+
+// TaskOccurrence records that an occurrence of a recurrence became a Task, so
+// that it never does twice.
+type TaskOccurrence struct {
+	RecurrenceID uuid.UUID   `db:"recurrence_id"`
+	OccurrenceAt time.Time   `db:"occurrence_at"`
+	TaskID       null.String `db:"task_id"`
+	CreatedAt    time.Time   `db:"created_at"`
+}
+
+// ExDates are the dates excluded from a recurrence, in ascending order. They
+// are stored as a comma separated list.
+type ExDates []string
+
+// Contains tells whether the date of t is excluded.
+func (e ExDates) Contains(t time.Time) bool {
+	return contains(e, t.Format(exDateLayout))
+}
+
+// Scan reads the dates from their comma separated list.
+func (e *ExDates) Scan(value interface{}) (err error) {
+	switch v := value.(type) {
+	case nil:
+		*e = nil
+	case string:
+		*e = newExDates(strings.Split(v, ","))
+	case []byte:
+		*e = newExDates(strings.Split(string(v), ","))
+	default:
+		return fmt.Errorf("cannot scan %T into ExDates", value)
+	}
+	return
+}
+
+// Value writes the dates as a comma separated list, NULL when there are none.
+func (e ExDates) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+	return strings.Join(e, ","), nil
+}
+
+func newExDates(dates []string) (exDates ExDates) {
+	for _, date := range dates {
+		if date = strings.TrimSpace(date); date != "" && !contains(exDates, date) {
+			exDates = append(exDates, date)
+		}
+	}
+	sort.Strings(exDates)
+	return
+}
+
+// TaskRecurrenceRequestFormat represents a TaskRecurrence's standard
+// formatting for JSON deserializing.
+type TaskRecurrenceRequestFormat struct {
+	// Template is the Task each occurrence becomes, due at the occurrence.
+	Template TaskRequestFormat `json:"template"`
+	RRule    string            `json:"rrule" validate:"required,max=512"`
+	Timezone string            `json:"timezone" validate:"required,timezone"`
+	// StartsAt is the first possible occurrence; occurrences fall at its time
+	// of day in the timezone.
+	StartsAt    time.Time `json:"startsAt" validate:"required"`
+	ExDates     []string  `json:"exdates,omitempty" validate:"max=366,dive,datetime=2006-01-02"`
+	Generation  string    `json:"generation,omitempty" validate:"omitempty,oneof=schedule completion"`
+	LeadMinutes int       `json:"leadMinutes,omitempty" validate:"min=0,max=525600"`
+}
+
+// NewTaskRecurrence creates a new TaskRecurrence from its request format.
+func NewTaskRecurrence(request TaskRecurrenceRequestFormat, createdBy string) (recurrence TaskRecurrence, err error) {
+	recurrence = TaskRecurrence{
+		ID:        uuid.New(),
+		CreatedBy: null.NewString(createdBy, createdBy != ""),
+		CreatedAt: time.Now(),
+		Version:   1,
+	}
+	err = recurrence.set(request)
+	return
+}
+
+// Update a TaskRecurrence.
+func (r *TaskRecurrence) Update(request TaskRecurrenceRequestFormat) (err error) {
+	err = r.set(request)
+	r.UpdatedAt = null.TimeFrom(time.Now())
+	return
+}
+
+func (r *TaskRecurrence) set(request TaskRecurrenceRequestFormat) (err error) {
+	err = shared.GetValidator().Struct(request)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+	if _, err = rrule.Parse(request.RRule); err != nil {
+		return failure.InvalidFields(map[string]string{"rrule": err.Error()})
+	}
+	if request.Template.CompletedAt != nil {
+		return failure.InvalidFields(map[string]string{"template.completedAt": "occurrences cannot be completed already"})
+	}
+
+	template := request.Template
+	template.DueAt, template.Version = nil, nil
+	encoded, err := json.Marshal(template)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+
+	r.Template = string(encoded)
+	r.RRule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(request.RRule)), "RRULE:")
+	r.Timezone = request.Timezone
+	r.StartsAt = request.StartsAt.UTC()
+	r.ExDates = newExDates(request.ExDates)
+	r.Generation = request.Generation
+	if r.Generation == "" {
+		r.Generation = GenerationSchedule
+	}
+	r.LeadMinutes = request.LeadMinutes
+	return
+}
+
+// TaskTemplate returns the request format of the Tasks of the recurrence.
+func (r TaskRecurrence) TaskTemplate() (template TaskRequestFormat, err error) {
+	err = json.Unmarshal([]byte(r.Template), &template)
+	return
+}
+
+// OccurrenceRequest returns the request format of the Task of the occurrence
+// at occurrenceAt.
+func (r TaskRecurrence) OccurrenceRequest(occurrenceAt time.Time) (request TaskRequestFormat, err error) {
+	request, err = r.TaskTemplate()
+	request.DueAt = &occurrenceAt
+	return
+}
+
+// OccurrenceAfter returns the first occurrence strictly after after that is
+// not excluded. It returns false once the recurrence has ended.
+func (r TaskRecurrence) OccurrenceAfter(after time.Time) (next time.Time, ok bool, err error) {
+	rule, err := rrule.Parse(r.RRule)
+	if err != nil {
+		return
+	}
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return
+	}
+
+	start := r.StartsAt.In(location)
+	// each excluded date skips one occurrence at most
+	for i := 0; i <= len(r.ExDates); i++ {
+		next, ok = rule.Next(start, after)
+		if !ok || !r.ExDates.Contains(next) {
+			return
+		}
+		after = next
+	}
+	return time.Time{}, false, nil
+}
+
+// FirstOccurrence returns the first occurrence that is not before now, nor
+// before the start of the recurrence.
+func (r TaskRecurrence) FirstOccurrence(now time.Time) (first time.Time, ok bool, err error) {
+	after := now.Add(-time.Nanosecond)
+	if r.StartsAt.After(now) {
+		after = r.StartsAt.Add(-time.Nanosecond)
+	}
+	return r.OccurrenceAfter(after)
+}
+
+// Upcoming returns at most n occurrences from the next one.
+func (r TaskRecurrence) Upcoming(n int) (upcoming []time.Time) {
+	if !r.NextOccurrenceAt.Valid {
+		return
+	}
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return
+	}
+
+	next := r.NextOccurrenceAt.Time.In(location)
+	for len(upcoming) < n {
+		upcoming = append(upcoming, next)
+		var ok bool
+		next, ok, err = r.OccurrenceAfter(next)
+		if err != nil || !ok {
+			return
+		}
+	}
+	return
+}
+
+// TaskRecurrenceResponse represents a TaskRecurrence for JSON serializing.
+type TaskRecurrenceResponse struct {
+	ID               string            `json:"id"`
+	Template         TaskRequestFormat `json:"template"`
+	RRule            string            `json:"rrule"`
+	Timezone         string            `json:"timezone"`
+	StartsAt         time.Time         `json:"startsAt"`
+	ExDates          []string          `json:"exdates"`
+	Generation       string            `json:"generation"`
+	LeadMinutes      int               `json:"leadMinutes"`
+	NextOccurrenceAt *time.Time        `json:"nextOccurrenceAt,omitempty"`
+	Upcoming         []time.Time       `json:"upcoming"`
+	LastTaskID       string            `json:"lastTaskId,omitempty"`
+	CreatedBy        string            `json:"createdBy,omitempty"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        *time.Time        `json:"updatedAt,omitempty"`
+	Version          int64             `json:"version"`
+}
+
+type TaskRecurrenceResponseFormat struct {
+	Message    string                  `json:"message"`
+	Recurrence *TaskRecurrenceResponse `json:"recurrence,omitempty"`
+}
+
+type TaskRecurrenceListResponseFormat struct {
+	Recurrences []TaskRecurrenceResponse `json:"recurrences"`
+	Pagination  Pagination               `json:"pagination"`
+}
+
+// ToResponseFormat converts a TaskRecurrence into its response format.
+func (r TaskRecurrence) ToResponseFormat() TaskRecurrenceResponse {
+	template, _ := r.TaskTemplate()
+	return TaskRecurrenceResponse{
+		ID:               r.ID.String(),
+		Template:         template,
+		RRule:            r.RRule,
+		Timezone:         r.Timezone,
+		StartsAt:         r.StartsAt,
+		ExDates:          append([]string{}, r.ExDates...),
+		Generation:       r.Generation,
+		LeadMinutes:      r.LeadMinutes,
+		NextOccurrenceAt: r.NextOccurrenceAt.Ptr(),
+		Upcoming:         append([]time.Time{}, r.Upcoming(maxUpcomingOccurrences)...),
+		LastTaskID:       r.LastTaskID.String,
+		CreatedBy:        r.CreatedBy.String,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt.Ptr(),
+		Version:          r.Version,
+	}
+}
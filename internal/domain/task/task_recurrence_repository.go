@@ -0,0 +1,303 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	recurrenceQueries = struct {
+		selectRecurrence  string
+		selectRecurrences string
+		selectDue         string
+		insertRecurrence  string
+		updateRecurrence  string
+		deleteRecurrence  string
+		insertOccurrence  string
+	}{
+		selectRecurrence: `
+			SELECT
+				id,
+				template,
+				rrule,
+				timezone,
+				starts_at,
+				exdates,
+				generation,
+				lead_minutes,
+				next_occurrence_at,
+				last_task_id,
+				created_by,
+				created_at,
+				updated_at,
+				version
+			FROM temp.task_recurrences`,
+		selectRecurrences: `
+			SELECT
+				id,
+				template,
+				rrule,
+				timezone,
+				starts_at,
+				exdates,
+				generation,
+				lead_minutes,
+				next_occurrence_at,
+				last_task_id,
+				created_by,
+				created_at,
+				updated_at,
+				version,
+				COUNT(id) OVER() as count
+			FROM temp.task_recurrences
+			ORDER BY created_at DESC, id
+			OFFSET :1 ROWS FETCH NEXT :2 ROWS ONLY`,
+		// an occurrence is due once it is no further ahead than the lead time
+		selectDue: `
+			SELECT
+				id,
+				template,
+				rrule,
+				timezone,
+				starts_at,
+				exdates,
+				generation,
+				lead_minutes,
+				next_occurrence_at,
+				last_task_id,
+				created_by,
+				created_at,
+				updated_at,
+				version
+			FROM temp.task_recurrences
+			WHERE generation = 'schedule' AND next_occurrence_at IS NOT NULL
+				AND next_occurrence_at <= :1 + NUMTODSINTERVAL(lead_minutes, 'MINUTE')
+			ORDER BY next_occurrence_at
+			FETCH FIRST :2 ROWS ONLY`,
+		insertRecurrence: `
+			INSERT INTO temp.task_recurrences (
+				id,
+				template,
+				rrule,
+				timezone,
+				starts_at,
+				exdates,
+				generation,
+				lead_minutes,
+				next_occurrence_at,
+				last_task_id,
+				created_by,
+				created_at,
+				version
+			) VALUES (
+				:id,
+				:template,
+				:rrule,
+				:timezone,
+				:starts_at,
+				:exdates,
+				:generation,
+				:lead_minutes,
+				:next_occurrence_at,
+				:last_task_id,
+				:created_by,
+				:created_at,
+				:version)`,
+		updateRecurrence: `
+			UPDATE temp.task_recurrences SET
+				template=:template,
+				rrule=:rrule,
+				timezone=:timezone,
+				starts_at=:starts_at,
+				exdates=:exdates,
+				generation=:generation,
+				lead_minutes=:lead_minutes,
+				next_occurrence_at=:next_occurrence_at,
+				last_task_id=:last_task_id,
+				updated_at=:updated_at,
+				version=version + 1
+			WHERE id=:id AND version=:version`,
+		deleteRecurrence: `
+			DELETE FROM temp.task_recurrences
+			WHERE id=:id`,
+		insertOccurrence: `
+			INSERT INTO temp.task_occurrences (
+				recurrence_id,
+				occurrence_at,
+				task_id,
+				created_at
+			) VALUES (
+				:recurrence_id,
+				:occurrence_at,
+				:task_id,
+				:created_at)`,
+	}
+)
+
+// RecurrenceRepository is the repository for the recurrences of Tasks.
+type RecurrenceRepository interface {
+	ResolveAll(ctx context.Context, pagination Pagination) (recurrences []TaskRecurrenceQueryData, err error)
+	ResolveByID(ctx context.Context, id uuid.UUID) (recurrence TaskRecurrence, exist bool, err error)
+	// ResolveByLastTaskID resolves the recurrence whose latest occurrence
+	// became the Task with the given ID.
+	ResolveByLastTaskID(ctx context.Context, taskID uuid.UUID) (recurrence TaskRecurrence, exist bool, err error)
+	// ResolveDue resolves at most limit recurrences generated on schedule
+	// whose next occurrence is due at now.
+	ResolveDue(ctx context.Context, now time.Time, limit int) (recurrences []TaskRecurrence, err error)
+	Create(ctx context.Context, recurrence TaskRecurrence) (err error)
+	// Update and Materialize fail with ErrVersionMismatch when the recurrence
+	// was changed since it was resolved.
+	Update(ctx context.Context, recurrence TaskRecurrence) (err error)
+	Delete(ctx context.Context, id uuid.UUID) (err error)
+	// Materialize creates task for occurrence and saves recurrence, advanced
+	// past it, at once. It fails with ErrOccurrenceMaterialized when the
+	// occurrence became a Task already.
+	Materialize(ctx context.Context, recurrence TaskRecurrence, occurrence TaskOccurrence, task Task) (err error)
+}
+
+// RecurrenceRepositoryOracle is the Oracle-backed implementation of RecurrenceRepository.
+type RecurrenceRepositoryOracle struct {
+	DB *infras.OracleConn
+	// Tasks creates the Tasks of occurrences within the same transaction.
+	Tasks *TaskRepositoryOracle
+}
+
+// ProvideRecurrenceRepositoryOracle is the provider for this repository.
+func ProvideRecurrenceRepositoryOracle(db *infras.OracleConn, tasks *TaskRepositoryOracle) *RecurrenceRepositoryOracle {
+	s := new(RecurrenceRepositoryOracle)
+	s.DB = db
+	s.Tasks = tasks
+	return s
+}
+
+// ResolveAll resolves a page of the recurrences, most recent first.
+func (r *RecurrenceRepositoryOracle) ResolveAll(ctx context.Context, pagination Pagination) (recurrences []TaskRecurrenceQueryData, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	err = r.DB.Reader(ctx).SelectContext(ctx, &recurrences, recurrenceQueries.selectRecurrences, offset, pagination.PageSize)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveAll", "TaskRecurrence", err)
+	return
+}
+
+// ResolveByID resolves a recurrence by its ID.
+func (r *RecurrenceRepositoryOracle) ResolveByID(ctx context.Context, id uuid.UUID) (recurrence TaskRecurrence, exist bool, err error) {
+	return r.resolve(ctx, "resolveByID", recurrenceQueries.selectRecurrence+" WHERE id = :1", id)
+}
+
+// ResolveByLastTaskID resolves a recurrence by the Task of its latest occurrence.
+func (r *RecurrenceRepositoryOracle) ResolveByLastTaskID(ctx context.Context, taskID uuid.UUID) (recurrence TaskRecurrence, exist bool, err error) {
+	return r.resolve(ctx, "resolveByLastTaskID", recurrenceQueries.selectRecurrence+" WHERE last_task_id = :1", taskID)
+}
+
+func (r *RecurrenceRepositoryOracle) resolve(ctx context.Context, operationName string, query string, id uuid.UUID) (recurrence TaskRecurrence, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(ctx, &recurrence, query, id)
+	switch {
+	case err == sql.ErrNoRows:
+		return recurrence, false, nil
+	case err != nil:
+		return recurrence, false, infras.TranslateError(operationName, "TaskRecurrence", err)
+	}
+	return recurrence, true, err
+}
+
+// ResolveDue resolves the recurrences whose next occurrence is due, soonest
+// first. They are read from the primary, as they are about to be advanced.
+func (r *RecurrenceRepositoryOracle) ResolveDue(ctx context.Context, now time.Time, limit int) (recurrences []TaskRecurrence, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Write.SelectContext(ctx, &recurrences, recurrenceQueries.selectDue, now, limit)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveDue", "TaskRecurrence", err)
+	return
+}
+
+// Create creates a new recurrence.
+func (r *RecurrenceRepositoryOracle) Create(ctx context.Context, recurrence TaskRecurrence) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, recurrenceQueries.insertRecurrence, recurrence)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return infras.TranslateError("create", "TaskRecurrence", err)
+}
+
+// Update updates a recurrence.
+func (r *RecurrenceRepositoryOracle) Update(ctx context.Context, recurrence TaskRecurrence) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, recurrenceQueries.updateRecurrence, recurrence)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("update", "TaskRecurrence", err)
+	}
+	return checkVersionUpdated(result)
+}
+
+// Delete deletes a recurrence. The Tasks of its occurrences are kept.
+func (r *RecurrenceRepositoryOracle) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := r.DB.Write.NamedExecContext(ctx, recurrenceQueries.deleteRecurrence, map[string]interface{}{"id": id})
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return infras.TranslateError("delete", "TaskRecurrence", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return failure.NotFound("TaskRecurrence")
+	}
+	return
+}
+
+// Materialize records the occurrence first: its primary key makes concurrent
+// schedulers, or a retried completion, create its Task once only.
+func (r *RecurrenceRepositoryOracle) Materialize(ctx context.Context, recurrence TaskRecurrence, occurrence TaskOccurrence, task Task) (err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		_, err = tx.NamedExecContext(ctx, recurrenceQueries.insertOccurrence, occurrence)
+		if infras.Classify(err) == infras.ErrorClassUniqueViolation {
+			return ErrOccurrenceMaterialized
+		}
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+
+		err = r.Tasks.txCreate(ctx, tx, task)
+		if err != nil {
+			return
+		}
+
+		result, err := tx.NamedExecContext(ctx, recurrenceQueries.updateRecurrence, recurrence)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+		return checkVersionUpdated(result)
+	})
+	if errors.Is(err, ErrOccurrenceMaterialized) || errors.Is(err, ErrVersionMismatch) {
+		return
+	}
+	return infras.TranslateError("materialize", "TaskRecurrence", err)
+}
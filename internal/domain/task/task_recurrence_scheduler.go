@@ -0,0 +1,55 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+)
+
+const (
+	defaultRecurrenceBatchSize = 100
+	defaultRecurrenceInterval  = 5 * time.Minute
+)
+
+// RecurrenceScheduler periodically makes the due occurrences of recurring
+// Tasks generated on schedule Tasks.
+type RecurrenceScheduler struct {
+	Config      *configs.Config
+	TaskService TaskService
+}
+
+// ProvideRecurrenceScheduler is the provider for RecurrenceScheduler.
+func ProvideRecurrenceScheduler(config *configs.Config, taskService TaskService) *RecurrenceScheduler {
+	return &RecurrenceScheduler{
+		Config:      config,
+		TaskService: taskService,
+	}
+}
+
+// Run materializes the due occurrences every interval until ctx is cancelled.
+func (s *RecurrenceScheduler) Run(ctx context.Context) {
+	interval := time.Duration(s.Config.Task.Recurrence.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = defaultRecurrenceInterval
+	}
+	log.Info().Dur("interval", interval).Msg("Starting task recurrence scheduler.")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		materialized, err := s.TaskService.MaterializeRecurrences(ctx)
+		if err != nil {
+			log.Err(err).Int("materialized", materialized).Msg("Failed materializing task recurrences")
+		} else if materialized > 0 {
+			log.Info().Int("materialized", materialized).Msg("Materialized task recurrences")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package task
 
 import (
+	"context"
 	"database/sql"
-	"strconv"
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/guregu/null"
 	"github.com/jmoiron/sqlx"
-	"github.com/rs/zerolog/log"
 	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/internal/outbox"
+	"github.com/tarkiman/go/shared"
 	"github.com/tarkiman/go/shared/failure"
 	"github.com/tarkiman/go/shared/logger"
 )
@@ -19,8 +23,23 @@ var (
 		selectDataWithFilter string
 		insertData           string
 		updateData           string
+		selectDataAsOf       string
+		insertVersion        string
+		closeVersion         string
+		selectTrash          string
+		selectExpiredTrash   string
 		deleteData           string
 		softDeleteData       string
+		restoreData          string
+		insertHistory        string
+		selectHistory        string
+		updateAssignees      string
+		insertAssignee       string
+		deleteAssignee       string
+		selectUserIDs        string
+		countTeams           string
+		selectDepth          string
+		selectSubtree        string
 	}{
 		selectData: `
 			SELECT 
@@ -28,74 +47,309 @@ var (
 				title, 
 				description, 
 				status,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
 				created_at, 
 				created_by, 
 				updated_at, 
 				updated_by, 
 				deleted_at, 
-				deleted_by
-			FROM temp.tasks;`,
+				deleted_by,
+				version,` + taskLabelsColumn + `
+			FROM temp.tasks`,
 		selectDataWithFilter: `
 			SELECT
 				id, 
 				title, 
 				description, 
 				status,
-				COUNT(id) OVER() as count
-			FROM temp.tasks;`,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				created_at,
+				version`,
 		insertData: `
 			INSERT INTO temp.tasks (
 			    id,
 				title, 
 				description,
 				status,
-				created_by
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				created_by,
+				version
 			) VALUES (
 			    :id,
 				:title, 
 				:description,
 				:status,
-				:created_by);`,
+				:due_at,
+				:priority,
+				:completed_at,
+				:team_id,
+				:parent_id,
+				:assignees,
+				:created_by,
+				:version);`,
 		updateData: `
 				UPDATE temp.tasks SET
 					title=:title, 
 					description=:description, 
 					status=:status,
-					updated_by=:updated_by
-				WHERE id=:id;`,
-		deleteData: `DELETE FROM temp.tasks WHERE id = ?;`,
+					due_at=:due_at,
+					priority=:priority,
+					completed_at=:completed_at,
+					team_id=:team_id,
+					parent_id=:parent_id,
+					updated_by=:updated_by,
+					version=version + 1
+				WHERE id=:id AND version=:version;`,
+		selectDataAsOf: `
+			SELECT
+				id,
+				title,
+				description,
+				status,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				labels,
+				created_at,
+				created_by,
+				updated_at,
+				updated_by,
+				deleted_at,
+				deleted_by,
+				version
+			FROM temp.tasks_versions
+			WHERE id = :1 AND deleted_at IS NULL
+				AND valid_from <= :2 AND (valid_to IS NULL OR valid_to > :3)`,
+		insertVersion: `
+			INSERT INTO temp.tasks_versions (
+				id,
+				version,
+				title,
+				description,
+				status,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				labels,
+				created_at,
+				created_by,
+				updated_at,
+				updated_by,
+				deleted_at,
+				deleted_by,
+				valid_from
+			) VALUES (
+				:id,
+				:version,
+				:title,
+				:description,
+				:status,
+				:due_at,
+				:priority,
+				:completed_at,
+				:team_id,
+				:parent_id,
+				:assignees,
+				:labels,
+				:created_at,
+				:created_by,
+				:updated_at,
+				:updated_by,
+				:deleted_at,
+				:deleted_by,
+				:valid_from)`,
+		closeVersion: `
+			UPDATE temp.tasks_versions SET
+				valid_to=:valid_to
+			WHERE id=:id AND valid_to IS NULL`,
+		selectTrash: `
+			SELECT
+				id,
+				title,
+				description,
+				status,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				created_at,
+				created_by,
+				updated_at,
+				updated_by,
+				deleted_at,
+				deleted_by,
+				version,` + taskLabelsColumn + `,
+				COUNT(id) OVER() as count
+			FROM temp.tasks
+			WHERE deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC
+			OFFSET :1 ROWS FETCH NEXT :2 ROWS ONLY`,
+		selectExpiredTrash: `
+			SELECT
+				id,
+				version
+			FROM temp.tasks
+			WHERE deleted_at IS NOT NULL AND deleted_at < :1
+			ORDER BY deleted_at
+			FETCH FIRST :2 ROWS ONLY`,
+		deleteData: `
+				DELETE FROM temp.tasks
+				WHERE id=:id AND version=:version AND deleted_at IS NOT NULL`,
 		softDeleteData: `
 				UPDATE temp.tasks SET
 					deleted_at=:deleted_at, 
-					deleted_by=:deleted_by
-				WHERE id=:id;`,
+					deleted_by=:deleted_by,
+					version=version + 1
+				WHERE id=:id AND version=:version;`,
+		restoreData: `
+				UPDATE temp.tasks SET
+					deleted_at=NULL,
+					deleted_by=NULL,
+					version=version + 1
+				WHERE id=:id AND version=:version AND deleted_at IS NOT NULL`,
+		insertHistory: `
+			INSERT INTO temp.task_history (
+				id,
+				task_id,
+				action,
+				changes,
+				actor,
+				request_id,
+				created_at
+			) VALUES (
+				:id,
+				:task_id,
+				:action,
+				:changes,
+				:actor,
+				:request_id,
+				:created_at)`,
+		selectHistory: `
+			SELECT
+				id,
+				task_id,
+				action,
+				changes,
+				actor,
+				request_id,
+				created_at,
+				COUNT(id) OVER() as count
+			FROM temp.task_history
+			WHERE task_id = :1
+			ORDER BY seq DESC
+			OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY`,
+		updateAssignees: `
+				UPDATE temp.tasks SET
+					assignees=:assignees,
+					version=version + 1
+				WHERE id=:id AND version=:version AND deleted_at IS NULL`,
+		insertAssignee: `
+			INSERT INTO temp.task_assignees (
+				task_id,
+				user_id,
+				assigned_by,
+				assigned_at
+			) VALUES (
+				:task_id,
+				:user_id,
+				:assigned_by,
+				:assigned_at)`,
+		deleteAssignee: `
+			DELETE FROM temp.task_assignees
+			WHERE task_id=:task_id AND user_id=:user_id`,
+		// users are kept by the OAuth token store
+		selectUserIDs: `
+			SELECT id
+			FROM users`,
+		countTeams: `
+			SELECT COUNT(id)
+			FROM temp.teams
+			WHERE id = :1`,
+		selectDepth: `
+			SELECT COUNT(id) - 1
+			FROM temp.tasks
+			START WITH id = :1
+			CONNECT BY NOCYCLE id = PRIOR parent_id`,
+		selectSubtree: `
+			SELECT
+				id,
+				LEVEL - 1 AS depth
+			FROM temp.tasks
+			WHERE id <> :1
+			START WITH id = :2
+			CONNECT BY NOCYCLE PRIOR id = parent_id AND deleted_at IS NULL`,
 	}
 )
 
 // TaskRepository is the repository for Task data.
 type TaskRepository interface {
-	ResolveByID(id uuid.UUID) (task Task, exist bool, err error)
-	Create(task Task) (err error)
-	ResolveByFilter(filter TaskFilter) (tasks []TaskFilterQueryData, err error)
-	Update(task Task) (err error)
-	SoftDelete(task Task) (err error)
+	ResolveByID(ctx context.Context, id uuid.UUID) (task Task, exist bool, err error)
+	Create(ctx context.Context, task Task) (err error)
+	ResolveByFilter(ctx context.Context, filter TaskFilter) (tasks []TaskFilterQueryData, err error)
+	CountByFilter(ctx context.Context, filter TaskFilter) (count int, err error)
+	Update(ctx context.Context, task Task) (err error)
+	// SoftDelete, Restore and HardDelete cascade to the subtasks of the Task
+	// and return them as cascaded to.
+	SoftDelete(ctx context.Context, task Task) (cascaded []Task, err error)
+	ResolveTrashedByID(ctx context.Context, id uuid.UUID) (task Task, exist bool, err error)
+	ResolveTrash(ctx context.Context, pagination Pagination) (tasks []TaskFilterQueryData, err error)
+	ResolveExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) (tasks []Task, err error)
+	ResolveByIDAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (task Task, exist bool, err error)
+	Restore(ctx context.Context, task Task) (cascaded []Task, err error)
+	HardDelete(ctx context.Context, task Task) (cascaded []Task, err error)
+	ResolveHistory(ctx context.Context, id uuid.UUID, pagination Pagination) (history []TaskHistoryQueryData, err error)
+	Reassign(ctx context.Context, task Task) (err error)
+	ResolveMissingUsers(ctx context.Context, ids []int64) (missing []int64, err error)
+	TeamExists(ctx context.Context, id int64) (exists bool, err error)
+	ResolveDepth(ctx context.Context, id uuid.UUID) (depth int, err error)
+	ResolveSubtree(ctx context.Context, id uuid.UUID) (subtree []TaskNode, err error)
+	ResolveProgress(ctx context.Context, ids []uuid.UUID) (progress map[uuid.UUID]*TaskProgress, err error)
 }
 
 // TaskRepositoryOracle is the MySQL-backed implementation of TaskRepository.
 type TaskRepositoryOracle struct {
-	DB *infras.OracleConn
+	DB     *infras.OracleConn
+	Outbox outbox.OutboxRepository
 }
 
 // ProvideTaskRepositoryOracle is the provider for this repository.
-func ProvideTaskRepositoryOracle(db *infras.OracleConn) *TaskRepositoryOracle {
+func ProvideTaskRepositoryOracle(db *infras.OracleConn, outboxRepository outbox.OutboxRepository) *TaskRepositoryOracle {
 	s := new(TaskRepositoryOracle)
 	s.DB = db
+	s.Outbox = outboxRepository
 	return s
 }
 
 // ResolveByID resolves a Task by its ID
-func (r *TaskRepositoryOracle) ResolveByID(id uuid.UUID) (task Task, exist bool, err error) {
-	err = r.DB.Read.Get(
+func (r *TaskRepositoryOracle) ResolveByID(ctx context.Context, id uuid.UUID) (task Task, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(
+		ctx,
 		&task,
 		queries.selectData+" WHERE id = ? AND deleted_at IS NULL ",
 		id)
@@ -103,74 +357,217 @@ func (r *TaskRepositoryOracle) ResolveByID(id uuid.UUID) (task Task, exist bool,
 	case err == sql.ErrNoRows:
 		return task, false, nil
 	case err != nil:
-		return task, false, err
+		return task, false, infras.TranslateError("resolveByID", "Task", err)
 	}
 	return task, true, err
 }
 
-func (r *TaskRepositoryOracle) ResolveByFilter(filter TaskFilter) (tasks []TaskFilterQueryData, err error) {
-	clauses, args, err := filterClause(filter)
-	if err != nil {
-		return
+// ResolveByFilter resolves a page of Tasks matching filter. With a cursor the
+// page continues from the cursor's row, otherwise it starts at the page
+// offset. One row more than the page size is resolved, telling whether
+// another page follows. Rows before a cursor are resolved in reverse order.
+func (r *TaskRepositoryOracle) ResolveByFilter(ctx context.Context, filter TaskFilter) (tasks []TaskFilterQueryData, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	source, conditions, args := filterSource(filter)
+	labels := taskLabelsColumn
+	if filter.AsOf != nil {
+		labels = "labels"
 	}
-	query := queries.selectDataWithFilter
-	// checks if there's another args besides sort and pagination
-	if len(args) > 0 {
-		query += " WHERE deleted_at IS NULL AND " + clauses
-	} else {
-		query += " WHERE deleted_at IS NULL " + clauses
+	sortExpression := sortKeys[filter.Sort.Field].expression
+	order := filter.Sort.Order
+	offset := (filter.Pagination.Page - 1) * filter.Pagination.PageSize
+
+	if filter.After != nil {
+		comparison := ">"
+		if (order == "DESC") != (filter.After.Direction == CursorPrev) {
+			comparison = "<"
+		}
+		conditions = append(conditions, "("+sortExpression+" "+comparison+" ? OR ("+sortExpression+" = ? AND id "+comparison+" ?))")
+		args = append(args, filter.After.SortValue(), filter.After.SortValue(), filter.After.ID)
+		if filter.After.Direction == CursorPrev {
+			order = reverseOrder(order)
+		}
+		offset = 0
 	}
 
-	query, args, err = sqlx.In(query, args...)
+	query := queries.selectDataWithFilter + ", " + labels + source +
+		" WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + sortExpression + " " + order + ", id " + order +
+		" OFFSET ? ROWS FETCH NEXT ? ROWS ONLY"
+	args = append(args, offset, filter.Pagination.PageSize+1)
+
+	// callers that just wrote are routed to the primary, see infras.Reader
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &tasks, db.Rebind(query), args...)
 	if err != nil {
 		logger.ErrorWithStack(err)
-		return
 	}
+	err = infras.TranslateError("resolveByFilter", "Task", err)
+	return
+}
+
+// CountByFilter counts the Tasks matching filter, regardless of pagination.
+func (r *TaskRepositoryOracle) CountByFilter(ctx context.Context, filter TaskFilter) (count int, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
 
-	query = "SELECT id, title, description, status FROM anonymous.tasks"
+	source, conditions, args := filterSource(filter)
+	query := "SELECT COUNT(id)" + source + " WHERE " + strings.Join(conditions, " AND ")
 
-	err = r.DB.Read.Select(&tasks, query, args...)
+	db := r.DB.Reader(ctx)
+	err = db.GetContext(ctx, &count, db.Rebind(query), args...)
 	if err != nil {
-		log.Err(err)
-		// logger.ErrorWithStack(err)
+		logger.ErrorWithStack(err)
 	}
-	// use write DB in case the data hasn't been replicated to read DB
-	if len(tasks) == 0 {
-		log.Warn().Interface("Filter", filter).Msg("Task Filter not found with Read DB, trying using Write DB")
-		err = r.DB.Write.Select(&tasks, query, args...)
-		if err != nil {
-			logger.ErrorWithStack(err)
-		}
+	err = infras.TranslateError("countByFilter", "Task", err)
+	return
+}
+
+// filterSource returns the FROM clause and the conditions of filter.
+func filterSource(filter TaskFilter) (source string, conditions []string, args []interface{}) {
+	source = " FROM temp.tasks"
+	conditions = []string{"deleted_at IS NULL"}
+	if filter.AsOf != nil {
+		// answer from the versions that were current at that moment
+		source = " FROM temp.tasks_versions"
+		conditions = append(conditions, "valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)")
+		args = append(args, *filter.AsOf, *filter.AsOf)
 	}
+
+	filterConditions, filterArgs := filterClause(filter)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
 	return
 }
 
-func filterClause(filter TaskFilter) (string, []interface{}, error) {
-	args := make([]interface{}, 0)
-	clause := make([]string, 0)
+func reverseOrder(order string) string {
+	if order == "DESC" {
+		return "ASC"
+	}
+	return "DESC"
+}
 
+// overdueCondition holds for Tasks past the due date bound to it that are not
+// completed.
+const overdueCondition = "(due_at < ? AND completed_at IS NULL)"
+
+// likeEscaper escapes the LIKE wildcards of a keyword, so that it is matched
+// literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterClause returns the conditions of filter with their arguments. The
+// sort field is validated against a fixed list before it gets here.
+func filterClause(filter TaskFilter) (conditions []string, args []interface{}) {
 	if len(filter.Keyword) > 0 {
-		clause = append(clause, "title LIKE ?")
-		args = append(args, "%"+filter.Keyword+"%")
+		keyword := "%" + likeEscaper.Replace(strings.ToUpper(filter.Keyword)) + "%"
+		conditions = append(conditions, `(UPPER(title) LIKE ? ESCAPE '\' OR UPPER(description) LIKE ? ESCAPE '\')`)
+		args = append(args, keyword, keyword)
+	}
+	if len(filter.Status) > 0 {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if len(filter.Priority) > 0 {
+		conditions = append(conditions, "priority = ?")
+		args = append(args, filter.Priority)
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "due_at >= ?")
+		args = append(args, *filter.DueAfter)
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_at < ?")
+		args = append(args, *filter.DueBefore)
+	}
+	if filter.Overdue {
+		conditions = append(conditions, overdueCondition)
+		args = append(args, time.Now())
+	}
+	if filter.Assignee != 0 {
+		if filter.AsOf != nil {
+			// past versions only have the list of their assignees
+			conditions = append(conditions, assigneeListCondition)
+			args = append(args, assigneeListArg(filter.Assignee))
+		} else {
+			conditions = append(conditions, "id IN (SELECT task_id FROM temp.task_assignees WHERE user_id = ?)")
+			args = append(args, filter.Assignee)
+		}
 	}
+	if filter.TeamID != 0 {
+		conditions = append(conditions, "team_id = ?")
+		args = append(args, filter.TeamID)
+	}
+	switch filter.Parent {
+	case "":
+	case ParentNone:
+		conditions = append(conditions, "parent_id IS NULL")
+	default:
+		conditions = append(conditions, "parent_id = ?")
+		args = append(args, filter.Parent)
+	}
+	if len(filter.Labels) > 0 {
+		condition, labelArgs := labelsCondition(filter)
+		conditions = append(conditions, condition)
+		args = append(args, labelArgs...)
+	}
+	if filter.Condition != nil {
+		conditions = append(conditions, filter.Condition.SQL)
+		args = append(args, filter.Condition.Args...)
+	}
+	return
+}
 
-	// if len(clause) == 0 {
-	// 	err := errors.New(EmptyFilterError)
-	// 	return "", args, err
-	// }
-	whereClause := strings.Join(clause, " AND ")
+// labelsCondition returns the condition holding for Tasks carrying any or all
+// of the labels of filter. Current Tasks are looked up through their labels,
+// past versions by their array of labels.
+func labelsCondition(filter TaskFilter) (condition string, args []interface{}) {
+	if filter.AsOf != nil {
+		operator := " OR "
+		if filter.LabelMatch == LabelMatchAll {
+			operator = " AND "
+		}
+		parts := make([]string, 0, len(filter.Labels))
+		for _, id := range filter.Labels {
+			parts = append(parts, labelListCondition)
+			args = append(args, labelListArg(id))
+		}
+		return "(" + strings.Join(parts, operator) + ")", args
+	}
+
+	for _, id := range filter.Labels {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Labels)), ", ")
+	condition = "id IN (SELECT task_id FROM temp.task_labels WHERE label_id IN (" + placeholders + ")"
+	if filter.LabelMatch == LabelMatchAll {
+		// the filtered labels are distinct, so a Task carrying them all has
+		// as many of them
+		condition += " GROUP BY task_id HAVING COUNT(label_id) = ?"
+		args = append(args, len(filter.Labels))
+	}
+	return condition + ")", args
+}
 
-	whereClause += " ORDER BY " + filter.Sort.Field + " " + filter.Sort.Order
+// ResolveByIDAsOf resolves a Task as it was at asOf.
+func (r *TaskRepositoryOracle) ResolveByIDAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (task Task, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
 
-	limit := strconv.Itoa(filter.Pagination.PageSize)
-	offset := strconv.Itoa((filter.Pagination.Page - 1) * filter.Pagination.PageSize)
-	whereClause += " LIMIT " + limit + " OFFSET " + offset
-	return whereClause, args, nil
+	err = r.DB.Reader(ctx).GetContext(ctx, &task, queries.selectDataAsOf, id, asOf, asOf)
+	switch {
+	case err == sql.ErrNoRows:
+		return task, false, nil
+	case err != nil:
+		return task, false, infras.TranslateError("resolveByIDAsOf", "Task", err)
+	}
+	return task, true, err
 }
 
 // Create creates a new Task.
-func (r *TaskRepositoryOracle) Create(task Task) (err error) {
-	_, exists, err := r.ResolveByID(task.ID)
+func (r *TaskRepositoryOracle) Create(ctx context.Context, task Task) (err error) {
+	_, exists, err := r.ResolveByID(ctx, task.ID)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
@@ -182,36 +579,53 @@ func (r *TaskRepositoryOracle) Create(task Task) (err error) {
 		return
 	}
 
-	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
-		if err := r.txCreate(tx, task); err != nil {
-			e <- err
-			return
-		}
-
-		e <- nil
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		return r.txCreate(ctx, tx, task)
 	})
+	return infras.TranslateError("create", "Task", err)
 }
 
 // txCreate creates a Task transactionally given the *sqlx.Tx param.
-func (r *TaskRepositoryOracle) txCreate(tx *sqlx.Tx, task Task) (err error) {
-	stmt, err := tx.PrepareNamed(queries.insertData)
+func (r *TaskRepositoryOracle) txCreate(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	stmt, err := tx.PrepareNamedContext(ctx, queries.insertData)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(task)
+	_, err = stmt.ExecContext(ctx, task)
 	if err != nil {
 		logger.ErrorWithStack(err)
+		return
 	}
 
-	return
+	err = r.txSyncAssignees(ctx, tx, nil, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txSyncLabels(ctx, tx, nil, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txRecordHistory(ctx, tx, HistoryActionCreated, nil, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txRecordVersion(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	return r.txRecordEvent(ctx, tx, EventTaskCreated, task)
 }
 
 // Update updates a Task.
-func (r *TaskRepositoryOracle) Update(task Task) (err error) {
-	_, exists, err := r.ResolveByID(task.ID)
+func (r *TaskRepositoryOracle) Update(ctx context.Context, task Task) (err error) {
+	_, exists, err := r.ResolveByID(ctx, task.ID)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
@@ -223,37 +637,62 @@ func (r *TaskRepositoryOracle) Update(task Task) (err error) {
 		return
 	}
 
-	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
-
-		if err := r.txUpdate(tx, task); err != nil {
-			e <- err
-			return
-		}
-
-		e <- nil
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		return r.txUpdate(ctx, tx, task)
 	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return
+	}
+	return infras.TranslateError("update", "Task", err)
 }
 
 // txUpdate updates a Task transactionally, given the *sqlx.Tx param.
-func (r *TaskRepositoryOracle) txUpdate(tx *sqlx.Tx, task Task) (err error) {
-	stmt, err := tx.PrepareNamed(queries.updateData)
+func (r *TaskRepositoryOracle) txUpdate(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	previous, err := r.txResolveForUpdate(ctx, tx, task.ID)
+	if err != nil {
+		return
+	}
+
+	stmt, err := tx.PrepareNamedContext(ctx, queries.updateData)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(task)
+	result, err := stmt.ExecContext(ctx, task)
 	if err != nil {
 		logger.ErrorWithStack(err)
+		return
 	}
 
-	return
+	err = checkVersionUpdated(result)
+	if err != nil {
+		return
+	}
+
+	err = r.txSyncLabels(ctx, tx, previous.Labels, task)
+	if err != nil {
+		return
+	}
+
+	task.Version++
+	err = r.txRecordHistory(ctx, tx, HistoryActionUpdated, &previous, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txRecordVersion(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	return r.txRecordEvent(ctx, tx, EventTaskUpdated, task)
 }
 
-// Delete a Task.
-func (r *TaskRepositoryOracle) SoftDelete(task Task) (err error) {
-	_, exists, err := r.ResolveByID(task.ID)
+// Delete a Task, along with its subtasks.
+func (r *TaskRepositoryOracle) SoftDelete(ctx context.Context, task Task) (cascaded []Task, err error) {
+	_, exists, err := r.ResolveByID(ctx, task.ID)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
@@ -265,38 +704,597 @@ func (r *TaskRepositoryOracle) SoftDelete(task Task) (err error) {
 		return
 	}
 
-	return r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		cascaded, err = r.txSoftDelete(ctx, tx, task)
+		return
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return nil, err
+	}
+	return cascaded, infras.TranslateError("softDelete", "Task", err)
+}
+
+// txSoftDelete soft deletes a Task and the subtasks below it that are not
+// deleted yet transactionally, given the *sqlx.Tx param. The subtasks are
+// deleted at the same time and by the same user as the Task.
+func (r *TaskRepositoryOracle) txSoftDelete(ctx context.Context, tx *sqlx.Tx, task Task) (cascaded []Task, err error) {
+	descendants, err := r.txResolveDescendants(ctx, tx, task.ID, descendantsLive, "ASC")
+	if err != nil {
+		return
+	}
+
+	err = r.txSoftDeleteOne(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	for _, descendant := range descendants {
+		descendant.DeletedAt = task.DeletedAt
+		descendant.DeletedBy = task.DeletedBy
+		err = r.txSoftDeleteOne(ctx, tx, descendant)
+		if err != nil {
+			return nil, err
+		}
+		descendant.Version++
+		cascaded = append(cascaded, descendant)
+	}
+	return
+}
+
+// txSoftDeleteOne soft deletes a single Task within tx.
+func (r *TaskRepositoryOracle) txSoftDeleteOne(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	previous, err := r.txResolveForUpdate(ctx, tx, task.ID)
+	if err != nil {
+		return
+	}
+
+	stmt, err := tx.PrepareNamedContext(ctx, queries.softDeleteData)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, task)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = checkVersionUpdated(result)
+	if err != nil {
+		return
+	}
+
+	task.Version++
+	err = r.txRecordHistory(ctx, tx, HistoryActionDeleted, &previous, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txRecordVersion(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	return r.txRecordEvent(ctx, tx, EventTaskDeleted, task)
+}
+
+// Reassign changes the users a Task is assigned to, to its Assignees.
+func (r *TaskRepositoryOracle) Reassign(ctx context.Context, task Task) (err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		return r.txReassign(ctx, tx, task)
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return
+	}
+	return infras.TranslateError("reassign", "Task", err)
+}
+
+// txReassign reassigns a Task transactionally, given the *sqlx.Tx param.
+func (r *TaskRepositoryOracle) txReassign(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	previous, err := r.txResolveForUpdate(ctx, tx, task.ID)
+	if err != nil {
+		return
+	}
+
+	result, err := tx.NamedExecContext(ctx, queries.updateAssignees, task)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = checkVersionUpdated(result)
+	if err != nil {
+		return
+	}
+
+	err = r.txSyncAssignees(ctx, tx, previous.Assignees, task)
+	if err != nil {
+		return
+	}
+
+	task.Version++
+	err = r.txRecordHistory(ctx, tx, HistoryActionReassigned, &previous, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txRecordVersion(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	return r.txRecordEvent(ctx, tx, EventTaskReassigned, task)
+}
+
+// txSyncAssignees brings the assignments of a Task from previous to its
+// Assignees within tx, recording who made the new ones.
+func (r *TaskRepositoryOracle) txSyncAssignees(ctx context.Context, tx *sqlx.Tx, previous UserIDs, task Task) (err error) {
+	actor := shared.ActorFromContext(ctx)
+	now := time.Now()
+	for _, userID := range task.Assignees {
+		if previous.Contains(userID) {
+			continue
+		}
+		_, err = tx.NamedExecContext(ctx, queries.insertAssignee, TaskAssignee{
+			TaskID:     task.ID,
+			UserID:     userID,
+			AssignedBy: null.NewString(actor, actor != ""),
+			AssignedAt: now,
+		})
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+	for _, userID := range previous {
+		if task.Assignees.Contains(userID) {
+			continue
+		}
+		_, err = tx.NamedExecContext(ctx, queries.deleteAssignee, TaskAssignee{TaskID: task.ID, UserID: userID})
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+	return
+}
 
-		if err := r.txSoftDelete(tx, task); err != nil {
-			e <- err
+// txSyncLabels brings the labels of a Task from previous to its Labels within tx.
+func (r *TaskRepositoryOracle) txSyncLabels(ctx context.Context, tx *sqlx.Tx, previous TaskLabels, task Task) (err error) {
+	for _, label := range task.Labels {
+		if previous.Contains(label.ID) {
+			continue
+		}
+		_, err = tx.NamedExecContext(ctx, labelQueries.insertTaskLabel, map[string]interface{}{
+			"task_id":  task.ID,
+			"label_id": label.ID,
+		})
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+	for _, label := range previous {
+		if task.Labels.Contains(label.ID) {
+			continue
+		}
+		_, err = tx.NamedExecContext(ctx, labelQueries.deleteTaskLabel, map[string]interface{}{
+			"task_id":  task.ID,
+			"label_id": label.ID,
+		})
+		if err != nil {
+			logger.ErrorWithStack(err)
 			return
 		}
+	}
+	return
+}
+
+// ResolveMissingUsers returns the IDs of ids that belong to no user.
+func (r *TaskRepositoryOracle) ResolveMissingUsers(ctx context.Context, ids []int64) (missing []int64, err error) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := queries.selectUserIDs + " WHERE id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+
+	var found []int64
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &found, db.Rebind(query), args...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return nil, infras.TranslateError("resolveUsers", "User", err)
+	}
+	for _, id := range ids {
+		if !UserIDs(found).Contains(id) {
+			missing = append(missing, id)
+		}
+	}
+	return
+}
+
+// TeamExists tells whether there is a team with id.
+func (r *TaskRepositoryOracle) TeamExists(ctx context.Context, id int64) (exists bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err = r.DB.Reader(ctx).GetContext(ctx, &count, queries.countTeams, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveTeam", "Team", err)
+	return count > 0, err
+}
+
+// ResolveDepth resolves how many levels of parents a Task has, 0 for top-level
+// Tasks.
+func (r *TaskRepositoryOracle) ResolveDepth(ctx context.Context, id uuid.UUID) (depth int, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(ctx, &depth, queries.selectDepth, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveDepth", "Task", err)
+	return
+}
+
+// ResolveSubtree resolves the Tasks that are not deleted below a Task, at any
+// depth.
+func (r *TaskRepositoryOracle) ResolveSubtree(ctx context.Context, id uuid.UUID) (subtree []TaskNode, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).SelectContext(ctx, &subtree, queries.selectSubtree, id, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveSubtree", "Task", err)
+	return
+}
+
+// ResolveProgress resolves the progress of the Tasks with the given IDs. Tasks
+// without subtasks or checklist items are left out.
+func (r *TaskRepositoryOracle) ResolveProgress(ctx context.Context, ids []uuid.UUID) (progress map[uuid.UUID]*TaskProgress, err error) {
+	progress = make(map[uuid.UUID]*TaskProgress)
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	args := make([]interface{}, 0, 2*len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, args...)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query := `
+		SELECT parent_id AS task_id, '` + progressKindSubtasks + `' AS kind, COUNT(id) AS total, COUNT(completed_at) AS done
+		FROM temp.tasks
+		WHERE deleted_at IS NULL AND parent_id IN (` + placeholders + `)
+		GROUP BY parent_id
+		UNION ALL
+		SELECT task_id, '` + progressKindChecklist + `', COUNT(id), COUNT(done_at)
+		FROM temp.task_checklist_items
+		WHERE task_id IN (` + placeholders + `)
+		GROUP BY task_id`
+
+	var rows []progressQueryData
+	db := r.DB.Reader(ctx)
+	err = db.SelectContext(ctx, &rows, db.Rebind(query), args...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return nil, infras.TranslateError("resolveProgress", "Task", err)
+	}
+
+	counts := make(map[uuid.UUID]map[string]ProgressCount)
+	for _, row := range rows {
+		if counts[row.TaskID] == nil {
+			counts[row.TaskID] = make(map[string]ProgressCount)
+		}
+		counts[row.TaskID][row.Kind] = ProgressCount{Total: row.Total, Done: row.Done}
+	}
+	for id, count := range counts {
+		progress[id] = NewTaskProgress(count[progressKindSubtasks], count[progressKindChecklist])
+	}
+	return
+}
+
+// txResolveDescendants resolves the Tasks below a Task within tx, walking
+// down the subtasks that hold condition, by level in order.
+func (r *TaskRepositoryOracle) txResolveDescendants(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, condition string, order string) (descendants []Task, err error) {
+	query := queries.selectData +
+		" WHERE id <> :1 START WITH id = :2" +
+		" CONNECT BY NOCYCLE PRIOR id = parent_id AND " + condition +
+		" ORDER BY LEVEL " + order
+	err = tx.SelectContext(ctx, &descendants, query, id, id)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// txResolveForUpdate resolves the current state of a Task and locks its row
+// until tx ends. A Task removed meanwhile is reported as ErrVersionMismatch.
+func (r *TaskRepositoryOracle) txResolveForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (task Task, err error) {
+	err = tx.GetContext(ctx, &task, queries.selectData+" WHERE id = :1 FOR UPDATE", id)
+	if err == sql.ErrNoRows {
+		return task, ErrVersionMismatch
+	}
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// txRecordHistory writes the field level changes between previous and task to
+// the audit history within tx.
+func (r *TaskRepositoryOracle) txRecordHistory(ctx context.Context, tx *sqlx.Tx, action string, previous *Task, task Task) (err error) {
+	history, err := NewTaskHistory(ctx, action, previous, task)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	_, err = tx.NamedExecContext(ctx, queries.insertHistory, history)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// ResolveHistory resolves a page of the recorded changes of a Task, most
+// recent first.
+func (r *TaskRepositoryOracle) ResolveHistory(ctx context.Context, id uuid.UUID, pagination Pagination) (history []TaskHistoryQueryData, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	err = r.DB.Reader(ctx).SelectContext(ctx, &history, queries.selectHistory, id, offset, pagination.PageSize)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveHistory", "TaskHistory", err)
+	return
+}
+
+// txRecordVersion closes the current version of a Task and opens a new one
+// holding task, so that past states can be resolved with an as-of time.
+func (r *TaskRepositoryOracle) txRecordVersion(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	now := time.Now()
+	err = r.txCloseVersion(ctx, tx, task.ID, now)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.NamedExecContext(ctx, queries.insertVersion, TaskVersion{Task: task, ValidFrom: now})
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
 
-		e <- nil
+// txCloseVersion ends the current version of a Task at validTo.
+func (r *TaskRepositoryOracle) txCloseVersion(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, validTo time.Time) (err error) {
+	_, err = tx.NamedExecContext(ctx, queries.closeVersion, map[string]interface{}{
+		"id":       id,
+		"valid_to": validTo,
 	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
 }
 
-// txSoftDelete updates a Task transactionally, given the *sqlx.Tx param.
-func (r *TaskRepositoryOracle) txSoftDelete(tx *sqlx.Tx, task Task) (err error) {
-	stmt, err := tx.PrepareNamed(queries.softDeleteData)
+// txRecordEvent writes a Task event to the outbox within tx, so the event is
+// stored if and only if the change it describes is committed.
+func (r *TaskRepositoryOracle) txRecordEvent(ctx context.Context, tx *sqlx.Tx, eventType string, task Task) (err error) {
+	event, err := outbox.NewEvent(AggregateType, task.ID.String(), eventType, task.ToResponseFormat())
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(task)
+	return r.Outbox.TxCreate(ctx, tx, event)
+}
+
+// checkVersionUpdated reports ErrVersionMismatch when a versioned UPDATE
+// matched no row, meaning another request changed the Task first.
+func checkVersionUpdated(result sql.Result) (err error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected == 0 {
+		return ErrVersionMismatch
+	}
+	return
+}
+
+// ResolveTrashedByID resolves a soft deleted Task by its ID.
+func (r *TaskRepositoryOracle) ResolveTrashedByID(ctx context.Context, id uuid.UUID) (task Task, exist bool, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Reader(ctx).GetContext(
+		ctx,
+		&task,
+		queries.selectData+" WHERE id = :1 AND deleted_at IS NOT NULL",
+		id)
+	switch {
+	case err == sql.ErrNoRows:
+		return task, false, nil
+	case err != nil:
+		return task, false, infras.TranslateError("resolveTrashedByID", "Task", err)
+	}
+	return task, true, err
+}
+
+// ResolveTrash resolves a page of soft deleted Tasks, most recently deleted first.
+func (r *TaskRepositoryOracle) ResolveTrash(ctx context.Context, pagination Pagination) (tasks []TaskFilterQueryData, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	err = r.DB.Reader(ctx).SelectContext(ctx, &tasks, queries.selectTrash, offset, pagination.PageSize)
 	if err != nil {
 		logger.ErrorWithStack(err)
 	}
+	err = infras.TranslateError("resolveTrash", "Task", err)
+	return
+}
+
+// ResolveExpiredTrash resolves the ID and version of up to limit Tasks soft
+// deleted before deletedBefore, oldest first.
+func (r *TaskRepositoryOracle) ResolveExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) (tasks []Task, err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = r.DB.Write.SelectContext(ctx, &tasks, queries.selectExpiredTrash, deletedBefore, limit)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("resolveExpiredTrash", "Task", err)
+	return
+}
+
+// Restore takes a soft deleted Task out of the trash, along with the subtasks
+// deleted with it.
+func (r *TaskRepositoryOracle) Restore(ctx context.Context, task Task) (cascaded []Task, err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		cascaded, err = r.txRestore(ctx, tx, task)
+		return
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return nil, err
+	}
+	return cascaded, infras.TranslateError("restore", "Task", err)
+}
 
+// txRestore restores a Task and the subtasks deleted along with it
+// transactionally, given the *sqlx.Tx param. Subtasks deleted on their own
+// before stay in the trash.
+func (r *TaskRepositoryOracle) txRestore(ctx context.Context, tx *sqlx.Tx, task Task) (cascaded []Task, err error) {
+	// resolved first, as they are told by the deletion time of the Task
+	descendants, err := r.txResolveDescendants(ctx, tx, task.ID, descendantsDeletedWithParent, "ASC")
+	if err != nil {
+		return
+	}
+
+	err = r.txRestoreOne(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	for _, descendant := range descendants {
+		descendant.DeletedAt = null.Time{}
+		descendant.DeletedBy = null.String{}
+		err = r.txRestoreOne(ctx, tx, descendant)
+		if err != nil {
+			return nil, err
+		}
+		descendant.Version++
+		cascaded = append(cascaded, descendant)
+	}
 	return
 }
 
-func (r *TaskRepositoryOracle) txDelete(tx *sqlx.Tx, id string) (err error) {
-	_, err = tx.Exec(queries.deleteData, id)
+// txRestoreOne restores a single Task within tx.
+func (r *TaskRepositoryOracle) txRestoreOne(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	previous, err := r.txResolveForUpdate(ctx, tx, task.ID)
+	if err != nil {
+		return
+	}
+
+	result, err := tx.NamedExecContext(ctx, queries.restoreData, task)
 	if err != nil {
 		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = checkVersionUpdated(result)
+	if err != nil {
+		return
+	}
+
+	task.Version++
+	err = r.txRecordHistory(ctx, tx, HistoryActionRestored, &previous, task)
+	if err != nil {
+		return
+	}
+
+	err = r.txRecordVersion(ctx, tx, task)
+	if err != nil {
+		return
+	}
+
+	return r.txRecordEvent(ctx, tx, EventTaskRestored, task)
+}
+
+// HardDelete permanently deletes a soft deleted Task, along with its subtasks.
+func (r *TaskRepositoryOracle) HardDelete(ctx context.Context, task Task) (cascaded []Task, err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		cascaded, err = r.txDelete(ctx, tx, task)
+		return
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return nil, err
+	}
+	return cascaded, infras.TranslateError("hardDelete", "Task", err)
+}
+
+// txDelete permanently deletes a Task and the deleted subtasks below it
+// transactionally, given the *sqlx.Tx param. Subtasks go first, deepest
+// first, as a Task cannot outlive its parent.
+func (r *TaskRepositoryOracle) txDelete(ctx context.Context, tx *sqlx.Tx, task Task) (cascaded []Task, err error) {
+	descendants, err := r.txResolveDescendants(ctx, tx, task.ID, descendantsDeleted, "DESC")
+	if err != nil {
+		return
+	}
+
+	for _, descendant := range descendants {
+		err = r.txDeleteOne(ctx, tx, descendant)
+		if err != nil {
+			return nil, err
+		}
+		cascaded = append(cascaded, descendant)
+	}
+
+	err = r.txDeleteOne(ctx, tx, task)
+	if err != nil {
+		return nil, err
 	}
 	return
 }
+
+// txDeleteOne permanently deletes a single Task within tx.
+func (r *TaskRepositoryOracle) txDeleteOne(ctx context.Context, tx *sqlx.Tx, task Task) (err error) {
+	result, err := tx.NamedExecContext(ctx, queries.deleteData, task)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	err = checkVersionUpdated(result)
+	if err != nil {
+		return
+	}
+
+	err = r.txCloseVersion(ctx, tx, task.ID, time.Now())
+	if err != nil {
+		return
+	}
+
+	return r.txRecordEvent(ctx, tx, EventTaskPurged, task)
+}
@@ -0,0 +1,275 @@
+package task
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/infras"
+)
+
+const (
+	cacheKeyPrefix           = "task:"
+	cacheKeyFilterGeneration = "task:filter:generation"
+)
+
+// TaskRepositoryCache is a read-through caching decorator of a TaskRepository.
+// Lookups by ID and filter queries are served from the cache; writes go to the
+// decorated repository and then invalidate the cached entries. Cache failures
+// are logged and fall back to the decorated repository. Callers reading from
+// the primary after a write bypass the cache, whose entries may have been read
+// from a lagging replica.
+type TaskRepositoryCache struct {
+	TaskRepository TaskRepository
+	Cache          infras.Cache
+}
+
+// ProvideTaskRepositoryCache is the provider for this repository.
+func ProvideTaskRepositoryCache(repository *TaskRepositoryOracle, cache infras.Cache) *TaskRepositoryCache {
+	return &TaskRepositoryCache{
+		TaskRepository: repository,
+		Cache:          cache,
+	}
+}
+
+// ResolveByID resolves a Task by its ID. Missing Tasks are not cached.
+func (r *TaskRepositoryCache) ResolveByID(ctx context.Context, id uuid.UUID) (task Task, exist bool, err error) {
+	if infras.PrimaryReads(ctx) {
+		return r.TaskRepository.ResolveByID(ctx, id)
+	}
+
+	key := cacheKeyPrefix + id.String()
+	if r.get(ctx, key, &task) {
+		return task, true, nil
+	}
+
+	task, exist, err = r.TaskRepository.ResolveByID(ctx, id)
+	if err != nil || !exist {
+		return
+	}
+	r.set(ctx, key, task)
+	return
+}
+
+// ResolveByFilter resolves Tasks matching filter. Cached results are keyed on
+// the filter and the current filter generation, which every write bumps.
+func (r *TaskRepositoryCache) ResolveByFilter(ctx context.Context, filter TaskFilter) (tasks []TaskFilterQueryData, err error) {
+	if infras.PrimaryReads(ctx) {
+		return r.TaskRepository.ResolveByFilter(ctx, filter)
+	}
+
+	key, err := r.filterKey(ctx, "filter", filter)
+	if err == nil && r.get(ctx, key, &tasks) {
+		return tasks, nil
+	}
+
+	tasks, err = r.TaskRepository.ResolveByFilter(ctx, filter)
+	if err != nil || key == "" {
+		return
+	}
+	r.set(ctx, key, tasks)
+	return
+}
+
+// CountByFilter counts the Tasks matching filter, cached like ResolveByFilter.
+func (r *TaskRepositoryCache) CountByFilter(ctx context.Context, filter TaskFilter) (count int, err error) {
+	if infras.PrimaryReads(ctx) {
+		return r.TaskRepository.CountByFilter(ctx, filter)
+	}
+
+	key, err := r.filterKey(ctx, "count", filter)
+	if err == nil && r.get(ctx, key, &count) {
+		return count, nil
+	}
+
+	count, err = r.TaskRepository.CountByFilter(ctx, filter)
+	if err != nil || key == "" {
+		return
+	}
+	r.set(ctx, key, count)
+	return
+}
+
+// Create creates a new Task.
+func (r *TaskRepositoryCache) Create(ctx context.Context, task Task) (err error) {
+	err = r.TaskRepository.Create(ctx, task)
+	if err != nil {
+		return
+	}
+	r.invalidate(ctx, task.ID)
+	return
+}
+
+// Update updates a Task.
+func (r *TaskRepositoryCache) Update(ctx context.Context, task Task) (err error) {
+	err = r.TaskRepository.Update(ctx, task)
+	r.invalidate(ctx, task.ID)
+	return
+}
+
+// SoftDelete marks a Task and its subtasks as deleted.
+func (r *TaskRepositoryCache) SoftDelete(ctx context.Context, task Task) (cascaded []Task, err error) {
+	cascaded, err = r.TaskRepository.SoftDelete(ctx, task)
+	r.Invalidate(ctx, append(taskIDs(cascaded), task.ID)...)
+	return
+}
+
+// filterKey returns the cache key of a kind of filter result under the
+// current generation.
+func (r *TaskRepositoryCache) filterKey(ctx context.Context, kind string, filter TaskFilter) (key string, err error) {
+	generation, found, err := r.Cache.Get(ctx, cacheKeyFilterGeneration)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed reading task filter generation from cache")
+		return
+	}
+	if !found {
+		generation = []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+		if err = r.Cache.Set(ctx, cacheKeyFilterGeneration, generation, 0); err != nil {
+			log.Warn().Err(err).Msg("Failed writing task filter generation to cache")
+			return
+		}
+	}
+
+	encoded, err := json.Marshal(filter)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(encoded)
+	return cacheKeyPrefix + kind + ":" + string(generation) + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// invalidate drops the cached Task and starts a new filter generation, so
+// filter results cached before the write are no longer used.
+func (r *TaskRepositoryCache) invalidate(ctx context.Context, id uuid.UUID) {
+	err := r.Cache.Delete(ctx, cacheKeyPrefix+id.String(), cacheKeyFilterGeneration)
+	if err != nil {
+		log.Warn().Err(err).Str("id", id.String()).Msg("Failed invalidating task cache")
+	}
+}
+
+// Invalidate drops the cached Tasks changed by a write made around the
+// TaskRepository, such as to a label they carry, and starts a new filter
+// generation.
+func (r *TaskRepositoryCache) Invalidate(ctx context.Context, ids ...uuid.UUID) {
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, cacheKeyPrefix+id.String())
+	}
+	keys = append(keys, cacheKeyFilterGeneration)
+	if err := r.Cache.Delete(ctx, keys...); err != nil {
+		log.Warn().Err(err).Int("tasks", len(ids)).Msg("Failed invalidating task cache")
+	}
+}
+
+func (r *TaskRepositoryCache) get(ctx context.Context, key string, value interface{}) (found bool) {
+	cached, found, err := r.Cache.Get(ctx, key)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed reading from cache")
+		return false
+	}
+	if !found {
+		return
+	}
+	if err = json.Unmarshal(cached, value); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed decoding cached value")
+		return false
+	}
+	return
+}
+
+func (r *TaskRepositoryCache) set(ctx context.Context, key string, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err = r.Cache.Set(ctx, key, encoded, r.Cache.DefaultTTL()); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed writing to cache")
+	}
+}
+
+// ResolveTrashedByID resolves a soft deleted Task by its ID. The trash is not
+// cached.
+func (r *TaskRepositoryCache) ResolveTrashedByID(ctx context.Context, id uuid.UUID) (task Task, exist bool, err error) {
+	return r.TaskRepository.ResolveTrashedByID(ctx, id)
+}
+
+// ResolveTrash resolves a page of soft deleted Tasks.
+func (r *TaskRepositoryCache) ResolveTrash(ctx context.Context, pagination Pagination) (tasks []TaskFilterQueryData, err error) {
+	return r.TaskRepository.ResolveTrash(ctx, pagination)
+}
+
+// ResolveExpiredTrash resolves Tasks soft deleted before deletedBefore.
+func (r *TaskRepositoryCache) ResolveExpiredTrash(ctx context.Context, deletedBefore time.Time, limit int) (tasks []Task, err error) {
+	return r.TaskRepository.ResolveExpiredTrash(ctx, deletedBefore, limit)
+}
+
+// ResolveHistory resolves a page of the recorded changes of a Task.
+func (r *TaskRepositoryCache) ResolveHistory(ctx context.Context, id uuid.UUID, pagination Pagination) (history []TaskHistoryQueryData, err error) {
+	return r.TaskRepository.ResolveHistory(ctx, id, pagination)
+}
+
+// ResolveByIDAsOf resolves a Task as it was at asOf. Past states are not cached.
+func (r *TaskRepositoryCache) ResolveByIDAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (task Task, exist bool, err error) {
+	return r.TaskRepository.ResolveByIDAsOf(ctx, id, asOf)
+}
+
+// Restore takes a soft deleted Task and its subtasks out of the trash.
+func (r *TaskRepositoryCache) Restore(ctx context.Context, task Task) (cascaded []Task, err error) {
+	cascaded, err = r.TaskRepository.Restore(ctx, task)
+	r.Invalidate(ctx, append(taskIDs(cascaded), task.ID)...)
+	return
+}
+
+// HardDelete permanently deletes a soft deleted Task and its subtasks.
+func (r *TaskRepositoryCache) HardDelete(ctx context.Context, task Task) (cascaded []Task, err error) {
+	cascaded, err = r.TaskRepository.HardDelete(ctx, task)
+	r.Invalidate(ctx, append(taskIDs(cascaded), task.ID)...)
+	return
+}
+
+// Reassign changes the users a Task is assigned to.
+func (r *TaskRepositoryCache) Reassign(ctx context.Context, task Task) (err error) {
+	err = r.TaskRepository.Reassign(ctx, task)
+	r.invalidate(ctx, task.ID)
+	return
+}
+
+// ResolveMissingUsers returns the IDs of ids that belong to no user. Users are
+// not cached.
+func (r *TaskRepositoryCache) ResolveMissingUsers(ctx context.Context, ids []int64) (missing []int64, err error) {
+	return r.TaskRepository.ResolveMissingUsers(ctx, ids)
+}
+
+// TeamExists tells whether there is a team with id. Teams are not cached.
+func (r *TaskRepositoryCache) TeamExists(ctx context.Context, id int64) (exists bool, err error) {
+	return r.TaskRepository.TeamExists(ctx, id)
+}
+
+// ResolveDepth resolves how many levels of parents a Task has. The hierarchy
+// is not cached.
+func (r *TaskRepositoryCache) ResolveDepth(ctx context.Context, id uuid.UUID) (depth int, err error) {
+	return r.TaskRepository.ResolveDepth(ctx, id)
+}
+
+// ResolveSubtree resolves the Tasks below a Task.
+func (r *TaskRepositoryCache) ResolveSubtree(ctx context.Context, id uuid.UUID) (subtree []TaskNode, err error) {
+	return r.TaskRepository.ResolveSubtree(ctx, id)
+}
+
+// ResolveProgress resolves the progress of Tasks. Progress changes with the
+// subtasks and checklist items of a Task, so it is not cached.
+func (r *TaskRepositoryCache) ResolveProgress(ctx context.Context, ids []uuid.UUID) (progress map[uuid.UUID]*TaskProgress, err error) {
+	return r.TaskRepository.ResolveProgress(ctx, ids)
+}
+
+func taskIDs(tasks []Task) (ids []uuid.UUID) {
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return
+}
@@ -0,0 +1,369 @@
+package task
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/logger"
+	"github.com/tarkiman/go/shared/search"
+)
+
+const (
+	// SearchBackendAuto uses Oracle Text when its index exists, the in-memory
+	// index otherwise.
+	SearchBackendAuto = "auto"
+	// SearchBackendOracle searches with the Oracle Text index of migration 000006.
+	SearchBackendOracle = "oracle"
+	// SearchBackendMemory searches an in-memory index of this instance, kept
+	// current by the writes made through it. Meant for a single instance.
+	SearchBackendMemory = "memory"
+
+	// words in the title count for twice as much as in the description
+	titleWeight       = 2
+	descriptionWeight = 1
+
+	// descriptionFragmentWidth is the length of highlighted description fragments.
+	descriptionFragmentWidth = 160
+	searchLoadBatchSize      = 500
+)
+
+var (
+	searchQueries = struct {
+		selectIndexStatus string
+		selectOracleText  string
+		selectBatch       string
+	}{
+		selectIndexStatus: `
+			SELECT COUNT(*)
+			FROM all_indexes
+			WHERE owner = 'TEMP' AND index_name = 'TASKS_SEARCH_IDX'
+				AND ityp_name = 'CONTEXT' AND domidx_opstatus = 'VALID'`,
+		selectOracleText: `
+			SELECT
+				id,
+				title,
+				description,
+				status,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				created_at,
+				version,` + taskLabelsColumn + `,
+				SCORE(1) AS score,
+				COUNT(id) OVER() AS count
+			FROM temp.tasks
+			WHERE deleted_at IS NULL AND CONTAINS(title, :1, 1) > 0
+			ORDER BY SCORE(1) DESC, id
+			OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY`,
+		selectBatch: `
+			SELECT
+				id,
+				title,
+				description,
+				status,
+				due_at,
+				priority,
+				completed_at,
+				team_id,
+				parent_id,
+				assignees,
+				created_at,
+				version,` + taskLabelsColumn + `
+			FROM temp.tasks
+			WHERE deleted_at IS NULL AND id > :1
+			ORDER BY id
+			FETCH FIRST :2 ROWS ONLY`,
+	}
+)
+
+// TaskSearchFilter is a full-text search for Tasks.
+type TaskSearchFilter struct {
+	Query string `json:"q"`
+	// Parsed is Query parsed by search.ParseQuery.
+	Parsed     search.Query `json:"-"`
+	Highlight  bool         `json:"highlight"`
+	Pagination Pagination   `json:"pagination"`
+}
+
+// TaskSearchQueryData is a Task found by a search, with its relevance.
+type TaskSearchQueryData struct {
+	Task
+	Score       float64 `db:"score"`
+	FilterCount int     `db:"count"`
+}
+
+// TaskHighlights are the title and description of a Task found by a search,
+// as HTML with the matched words marked.
+type TaskHighlights struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TaskSearchResponse is a Task found by a search. Scores only compare Tasks
+// of the same search.
+type TaskSearchResponse struct {
+	TaskResponse
+	Score      float64         `json:"score"`
+	Highlights *TaskHighlights `json:"highlights,omitempty"`
+}
+
+type TaskSearchResponseFormat struct {
+	Tasks      []TaskSearchResponse `json:"tasks"`
+	Query      string               `json:"q"`
+	Pagination Pagination           `json:"pagination"`
+}
+
+// ToSearchResponseFormat converts a found Task into its search format, with
+// its highlights when asked for.
+func (t TaskSearchQueryData) ToSearchResponseFormat(filter TaskSearchFilter) TaskSearchResponse {
+	response := TaskSearchResponse{
+		TaskResponse: t.ToResponseFormat(),
+		Score:        t.Score,
+	}
+	if filter.Highlight {
+		response.Highlights = &TaskHighlights{
+			Title:       search.Highlight(t.Title, filter.Parsed, 0),
+			Description: search.Highlight(t.Description.String, filter.Parsed, descriptionFragmentWidth),
+		}
+	}
+	return response
+}
+
+// TaskSearcher finds Tasks by the words of their title and description,
+// most relevant first.
+type TaskSearcher interface {
+	Search(ctx context.Context, query search.Query, pagination Pagination) (tasks []TaskSearchQueryData, err error)
+	// Index and Remove tell the searcher of a committed change to a Task.
+	Index(task Task)
+	Remove(id uuid.UUID)
+}
+
+// ProvideTaskSearcher is the provider for the TaskSearcher backend selected in
+// config.
+func ProvideTaskSearcher(config *configs.Config, db *infras.OracleConn) TaskSearcher {
+	backend := config.Task.Search.Backend
+	if backend == "" || backend == SearchBackendAuto {
+		backend = SearchBackendMemory
+		available, err := oracleTextAvailable(db)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed checking for the Oracle Text task index")
+		}
+		if available {
+			backend = SearchBackendOracle
+		}
+	}
+
+	if backend == SearchBackendOracle {
+		log.Info().Msg("Searching tasks with Oracle Text")
+		return &TaskSearcherOracle{DB: db}
+	}
+	log.Info().Msg("Searching tasks with an in-process index")
+	return NewTaskSearcherMemory(db)
+}
+
+func oracleTextAvailable(db *infras.OracleConn) (available bool, err error) {
+	ctx, cancel := db.WithQueryTimeout(context.Background())
+	defer cancel()
+
+	var count int
+	err = db.Write.GetContext(ctx, &count, searchQueries.selectIndexStatus)
+	return count > 0, err
+}
+
+// TaskSearcherOracle searches Tasks with Oracle Text. The index is kept
+// current by Oracle on commit.
+type TaskSearcherOracle struct {
+	DB *infras.OracleConn
+}
+
+// Search resolves a page of the Tasks matching query.
+func (s *TaskSearcherOracle) Search(ctx context.Context, query search.Query, pagination Pagination) (tasks []TaskSearchQueryData, err error) {
+	ctx, cancel := s.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	err = s.DB.Reader(ctx).SelectContext(ctx, &tasks, searchQueries.selectOracleText,
+		oracleTextQuery(query), offset, pagination.PageSize)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("search", "Task", err)
+	return
+}
+
+// Index does nothing, Oracle Text indexes Tasks itself.
+func (s *TaskSearcherOracle) Index(task Task) {}
+
+// Remove does nothing, Oracle Text indexes Tasks itself.
+func (s *TaskSearcherOracle) Remove(id uuid.UUID) {}
+
+// oracleTextQuery renders query in the Oracle Text query language. Every term
+// matches the word itself, its stem or, for prefix terms, longer words, and
+// counts double within the title. Terms only hold letters and digits, so
+// braces are enough to escape reserved words.
+func oracleTextQuery(query search.Query) string {
+	terms := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		alternatives := []string{"{" + term.Surface + "}"}
+		if term.Stem != term.Surface {
+			alternatives = append(alternatives, "{"+term.Stem+"}")
+		}
+		// the index keeps prefixes from two letters on
+		if term.Prefix && len([]rune(term.Surface)) >= 2 {
+			alternatives = append(alternatives, term.Surface+"%")
+		}
+		words := "(" + strings.Join(alternatives, " | ") + ")"
+		terms = append(terms, "(("+words+" WITHIN title)*2 ACCUM "+words+")")
+	}
+	return strings.Join(terms, " & ")
+}
+
+// TaskSearcherMemory searches Tasks with an in-memory index. The index is
+// loaded from the database on the first search and then kept current by
+// Index and Remove, so it misses changes made through other instances.
+type TaskSearcherMemory struct {
+	DB *infras.OracleConn
+
+	index *search.Index
+	// mu guards tasks and loaded, and is held while loading so that changes
+	// are only applied after the load.
+	mu     sync.RWMutex
+	tasks  map[uuid.UUID]Task
+	loaded bool
+}
+
+// NewTaskSearcherMemory returns a TaskSearcherMemory that loads from db.
+func NewTaskSearcherMemory(db *infras.OracleConn) *TaskSearcherMemory {
+	return &TaskSearcherMemory{
+		DB:    db,
+		index: search.NewIndex(),
+		tasks: make(map[uuid.UUID]Task),
+	}
+}
+
+// Search resolves a page of the Tasks matching query.
+func (s *TaskSearcherMemory) Search(ctx context.Context, query search.Query, pagination Pagination) (tasks []TaskSearchQueryData, err error) {
+	err = s.load(ctx)
+	if err != nil {
+		return
+	}
+
+	hits := s.index.Search(query)
+	count := len(hits)
+	offset := (pagination.Page - 1) * pagination.PageSize
+	if offset >= len(hits) {
+		return
+	}
+	hits = hits[offset:]
+	if len(hits) > pagination.PageSize {
+		hits = hits[:pagination.PageSize]
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, hit := range hits {
+		task, ok := s.tasks[uuid.MustParse(hit.ID)]
+		if !ok {
+			// removed since the search
+			continue
+		}
+		tasks = append(tasks, TaskSearchQueryData{
+			Task:        task,
+			Score:       hit.Score,
+			FilterCount: count,
+		})
+	}
+	return
+}
+
+// Index adds or replaces task in the index, unless a later version of it is
+// indexed already.
+func (s *TaskSearcherMemory) Index(task Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		return
+	}
+	if indexed, ok := s.tasks[task.ID]; ok && indexed.Version > task.Version {
+		return
+	}
+	s.add(task)
+}
+
+// Remove drops a Task from the index.
+func (s *TaskSearcherMemory) Remove(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		return
+	}
+	delete(s.tasks, id)
+	s.index.Remove(id.String())
+}
+
+func (s *TaskSearcherMemory) add(task Task) {
+	s.tasks[task.ID] = task
+	s.index.Add(task.ID.String(),
+		search.Field{Text: task.Title, Weight: titleWeight},
+		search.Field{Text: task.Description.String, Weight: descriptionWeight})
+}
+
+// load indexes all Tasks unless they were loaded already. Changes made
+// before the load are part of what it reads; changes made during it wait
+// for it to finish.
+func (s *TaskSearcherMemory) load(ctx context.Context) (err error) {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if loaded {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return
+	}
+
+	start := time.Now()
+	// an empty string is NULL to Oracle
+	after := uuid.Nil.String()
+	for {
+		var batch []Task
+		batch, err = s.resolveBatch(ctx, after)
+		if err != nil {
+			return
+		}
+		for _, task := range batch {
+			s.add(task)
+		}
+		if len(batch) < searchLoadBatchSize {
+			break
+		}
+		after = batch[len(batch)-1].ID.String()
+	}
+	s.loaded = true
+	log.Info().Int("tasks", s.index.Len()).Dur("took", time.Since(start)).Msg("Loaded task search index")
+	return
+}
+
+func (s *TaskSearcherMemory) resolveBatch(ctx context.Context, after string) (tasks []Task, err error) {
+	ctx, cancel := s.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	err = s.DB.Write.SelectContext(ctx, &tasks, searchQueries.selectBatch, after, searchLoadBatchSize)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	err = infras.TranslateError("loadSearchIndex", "Task", err)
+	return
+}
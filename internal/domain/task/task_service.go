@@ -1,85 +1,302 @@
 package task
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/guregu/null"
 	"github.com/rs/zerolog/log"
 	"github.com/tarkiman/go/configs"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared"
+	"github.com/tarkiman/go/shared/cursor"
 	"github.com/tarkiman/go/shared/failure"
 )
 
 // TaskService is the service interface for Task entities.
 type TaskService interface {
-	Create(requestFormat TaskRequestFormat) (response TaskResponseFormat, err error)
-	ResolveByFilter(filter TaskFilter) (task TaskFilterResponseFormat, err error)
-	ResolveByID(id uuid.UUID) (task Task, err error)
-	Update(id uuid.UUID, requestFormat TaskRequestFormat) (response TaskResponseFormat, err error)
-	SoftDelete(id uuid.UUID, deletedBy string) (response TaskResponseFormat, err error)
+	Create(ctx context.Context, requestFormat TaskRequestFormat) (response TaskResponseFormat, err error)
+	ResolveByFilter(ctx context.Context, filter TaskFilter) (task TaskFilterResponseFormat, err error)
+	Search(ctx context.Context, filter TaskSearchFilter) (response TaskSearchResponseFormat, err error)
+	ResolveByID(ctx context.Context, id uuid.UUID) (task Task, err error)
+	ResolveByIDAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (task Task, err error)
+	Update(ctx context.Context, id uuid.UUID, requestFormat TaskRequestFormat, precondition Precondition) (response TaskResponseFormat, err error)
+	SoftDelete(ctx context.Context, id uuid.UUID, deletedBy string, precondition Precondition) (response TaskResponseFormat, err error)
+	ResolveTrash(ctx context.Context, pagination Pagination) (response TaskTrashResponseFormat, err error)
+	Restore(ctx context.Context, id uuid.UUID, precondition Precondition) (response TaskResponseFormat, err error)
+	HardDelete(ctx context.Context, id uuid.UUID, precondition Precondition) (response TaskResponseFormat, err error)
+	PurgeTrash(ctx context.Context) (purged int, err error)
+	ResolveHistory(ctx context.Context, id uuid.UUID, pagination Pagination) (response TaskHistoryResponseFormat, err error)
+	Assign(ctx context.Context, id uuid.UUID, userIDs []int64, precondition Precondition) (response TaskResponseFormat, err error)
+	Unassign(ctx context.Context, id uuid.UUID, userID int64, precondition Precondition) (response TaskResponseFormat, err error)
+	ResolveChecklist(ctx context.Context, id uuid.UUID) (response ChecklistResponseFormat, err error)
+	AddChecklistItem(ctx context.Context, id uuid.UUID, requestFormat ChecklistItemRequestFormat) (response ChecklistItemResponseFormat, err error)
+	UpdateChecklistItem(ctx context.Context, id uuid.UUID, itemID uuid.UUID, requestFormat ChecklistItemRequestFormat) (response ChecklistItemResponseFormat, err error)
+	DeleteChecklistItem(ctx context.Context, id uuid.UUID, itemID uuid.UUID) (response ChecklistItemResponseFormat, err error)
+	ResolveComments(ctx context.Context, id uuid.UUID, pagination Pagination) (response TaskCommentListResponseFormat, err error)
+	ResolveCommentEdits(ctx context.Context, id uuid.UUID, commentID uuid.UUID) (response TaskCommentEditsResponseFormat, err error)
+	AddComment(ctx context.Context, id uuid.UUID, requestFormat TaskCommentRequestFormat) (response TaskCommentResponseFormat, err error)
+	UpdateComment(ctx context.Context, id uuid.UUID, commentID uuid.UUID, requestFormat TaskCommentRequestFormat) (response TaskCommentResponseFormat, err error)
+	DeleteComment(ctx context.Context, id uuid.UUID, commentID uuid.UUID) (response TaskCommentResponseFormat, err error)
+	ResolveAttachments(ctx context.Context, id uuid.UUID) (response TaskAttachmentListResponseFormat, err error)
+	AddAttachment(ctx context.Context, id uuid.UUID, fileName string, content io.Reader) (response TaskAttachmentResponseFormat, err error)
+	OpenAttachment(ctx context.Context, id uuid.UUID, attachmentID uuid.UUID) (attachment TaskAttachment, content io.ReadSeekCloser, err error)
+	DeleteAttachment(ctx context.Context, id uuid.UUID, attachmentID uuid.UUID) (response TaskAttachmentResponseFormat, err error)
+	AddBlocker(ctx context.Context, id uuid.UUID, blockerID uuid.UUID) (response TaskDependencyResponseFormat, err error)
+	RemoveBlocker(ctx context.Context, id uuid.UUID, blockerID uuid.UUID) (response TaskDependencyResponseFormat, err error)
+	ResolveDependencies(ctx context.Context, id uuid.UUID) (response TaskDependencyGraphResponseFormat, err error)
+	Plan(ctx context.Context, ids []uuid.UUID) (response TaskPlanResponseFormat, err error)
+	ResolveRecurrences(ctx context.Context, pagination Pagination) (response TaskRecurrenceListResponseFormat, err error)
+	ResolveRecurrence(ctx context.Context, id uuid.UUID) (response TaskRecurrenceResponseFormat, err error)
+	CreateRecurrence(ctx context.Context, requestFormat TaskRecurrenceRequestFormat) (response TaskRecurrenceResponseFormat, err error)
+	UpdateRecurrence(ctx context.Context, id uuid.UUID, requestFormat TaskRecurrenceRequestFormat) (response TaskRecurrenceResponseFormat, err error)
+	DeleteRecurrence(ctx context.Context, id uuid.UUID) (response TaskRecurrenceResponseFormat, err error)
+	MaterializeRecurrences(ctx context.Context) (materialized int, err error)
 }
 
 // TaskServiceImpl is the service implementation for Task entities.
 type TaskServiceImpl struct {
 	Config         *configs.Config
 	TaskRepository TaskRepository
+	Cursors        *cursor.Signer
+	Searcher       TaskSearcher
+	Workflow       *Workflow
+	Labels         LabelRepository
+	Checklists     ChecklistRepository
+	Dependencies   DependencyRepository
+	Comments       CommentRepository
+	Attachments    AttachmentRepository
+	Storage        infras.Storage
+	Recurrences    RecurrenceRepository
+	Invalidator    TaskInvalidator
 }
 
 // ProvideTaskServiceImpl is the provider for this service.
 func ProvideTaskServiceImpl(
 	config *configs.Config,
-	taskRepository TaskRepository) *TaskServiceImpl {
+	taskRepository TaskRepository,
+	cursors *cursor.Signer,
+	searcher TaskSearcher,
+	workflow *Workflow,
+	labels LabelRepository,
+	checklists ChecklistRepository,
+	dependencies DependencyRepository,
+	comments CommentRepository,
+	attachments AttachmentRepository,
+	storage infras.Storage,
+	recurrences RecurrenceRepository,
+	invalidator TaskInvalidator) *TaskServiceImpl {
 	s := new(TaskServiceImpl)
 	s.Config = config
 	s.TaskRepository = taskRepository
+	s.Cursors = cursors.For(cursor.PurposeTaskCursor)
+	s.Searcher = searcher
+	s.Workflow = workflow
+	s.Labels = labels
+	s.Checklists = checklists
+	s.Dependencies = dependencies
+	s.Comments = comments
+	s.Attachments = attachments
+	s.Storage = storage
+	s.Recurrences = recurrences
+	s.Invalidator = invalidator
 
 	return s
 }
 
 // Create creates a new Task.
-func (s *TaskServiceImpl) Create(requestFormat TaskRequestFormat) (response TaskResponseFormat, err error) {
-	task := Task{}
-	task, err = task.CreateRequestFormat(requestFormat)
+func (s *TaskServiceImpl) Create(ctx context.Context, requestFormat TaskRequestFormat) (response TaskResponseFormat, err error) {
+	task, err := s.newTask(ctx, requestFormat)
 	if err != nil {
-		return response, failure.BadRequest(err)
+		return
 	}
 
-	err = s.TaskRepository.Create(task)
+	err = s.TaskRepository.Create(ctx, task)
 	if err != nil {
 		log.Err(err).Msg("[Create] error TaskRepository.Create")
-		return response, failure.InternalError(err)
+		return response, err
 	}
+	s.Searcher.Index(task)
 
 	response = task.ToJSONResponseFormat(MessageSuccessCreatedData)
 	return
 }
 
+// newTask makes a new Task from its request format, checked against the
+// workflow and the team, users, parent and labels it refers to.
+func (s *TaskServiceImpl) newTask(ctx context.Context, requestFormat TaskRequestFormat) (task Task, err error) {
+	task, err = task.CreateRequestFormat(requestFormat)
+	if err != nil {
+		return task, failure.BadRequest(err)
+	}
+
+	err = s.Workflow.CheckCreate(&task)
+	if err != nil {
+		return
+	}
+
+	err = task.SetCompletion(s.Workflow.IsDone(task.Status.String), requestFormat.CompletedAt)
+	if err != nil {
+		return
+	}
+
+	err = s.checkTeam(ctx, task.TeamID)
+	if err != nil {
+		return
+	}
+
+	err = s.checkUsers(ctx, "assignees", task.Assignees)
+	if err != nil {
+		return
+	}
+
+	err = s.checkParent(ctx, task)
+	if err != nil {
+		return
+	}
+
+	task.Labels, err = s.resolveLabels(ctx, requestFormat.Labels)
+	return
+}
+
 // ResolveByFilter resolves tasks by filter
-func (s *TaskServiceImpl) ResolveByFilter(filter TaskFilter) (taskResponse TaskFilterResponseFormat, err error) {
+func (s *TaskServiceImpl) ResolveByFilter(ctx context.Context, filter TaskFilter) (taskResponse TaskFilterResponseFormat, err error) {
 	err = filter.Sort.SetDefaults()
 	if err != nil {
 		return
 	}
 	filter.Pagination.SetDefaults()
-	tasks, err := s.TaskRepository.ResolveByFilter(filter)
+	if filter.Cursor != "" {
+		var after TaskCursor
+		if err = s.Cursors.Verify(filter.Cursor, &after); err != nil {
+			return taskResponse, failure.InvalidFields(map[string]string{"cursor": err.Error()})
+		}
+		if err = after.Validate(filter); err != nil {
+			return taskResponse, failure.InvalidFields(map[string]string{"cursor": err.Error()})
+		}
+		filter.After = &after
+	}
+
+	tasks, err := s.TaskRepository.ResolveByFilter(ctx, filter)
 	if err != nil {
 		return
 	}
+	hasMore := len(tasks) > filter.Pagination.PageSize
+	if hasMore {
+		tasks = tasks[:filter.Pagination.PageSize]
+	}
+	backwards := filter.After != nil && filter.After.Direction == CursorPrev
+	if backwards {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+
+	if filter.WithCount {
+		filter.Pagination.Count, err = s.TaskRepository.CountByFilter(ctx, filter)
+		if err != nil {
+			return
+		}
+	}
+
+	// past versions have no progress, their subtasks and checklists are not kept
+	if filter.AsOf == nil {
+		ids := make([]uuid.UUID, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		progress, err := s.TaskRepository.ResolveProgress(ctx, ids)
+		if err != nil {
+			log.Err(err).Msg("[ResolveByFilter] error TaskRepository.ResolveProgress")
+			return taskResponse, err
+		}
+		for i := range tasks {
+			tasks[i].Progress = progress[tasks[i].ID]
+		}
+	}
 
+	taskResponse.Tasks = make([]TaskResponse, 0, len(tasks))
 	for _, task := range tasks {
 		taskResponse.Tasks = append(taskResponse.Tasks, task.ToResponseFormat())
 	}
+	taskResponse.SetSortAndPagination(filter)
+
+	if len(tasks) > 0 {
+		hasNext := hasMore || backwards
+		hasPrev := (backwards && hasMore) || (filter.After != nil && !backwards) || (filter.After == nil && filter.Pagination.Page > 1)
+		if hasNext {
+			taskResponse.Cursors.Next, err = s.Cursors.Sign(NewTaskCursor(filter, tasks[len(tasks)-1].Task, CursorNext))
+			if err != nil {
+				return
+			}
+		}
+		if hasPrev {
+			taskResponse.Cursors.Prev, err = s.Cursors.Sign(NewTaskCursor(filter, tasks[0].Task, CursorPrev))
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Search resolves a page of the Tasks whose title or description match the
+// words of filter, most relevant first.
+func (s *TaskServiceImpl) Search(ctx context.Context, filter TaskSearchFilter) (response TaskSearchResponseFormat, err error) {
+	filter.Pagination.SetDefaults()
+	tasks, err := s.Searcher.Search(ctx, filter.Parsed, filter.Pagination)
+	if err != nil {
+		log.Err(err).Msg("[Search] error TaskSearcher.Search")
+		return
+	}
+
+	response.Tasks = make([]TaskSearchResponse, 0, len(tasks))
+	for _, task := range tasks {
+		response.Tasks = append(response.Tasks, task.ToSearchResponseFormat(filter))
+	}
 	if len(tasks) > 0 {
 		filter.Pagination.Count = tasks[0].FilterCount
-		taskResponse.SetSortAndPagination(filter)
+		filter.Pagination.TotalPage = int(math.Ceil(float64(filter.Pagination.Count) / float64(filter.Pagination.PageSize)))
 	}
+	response.Query = filter.Query
+	response.Pagination = filter.Pagination
 	return
 }
 
 // ResolveByID resolves a Task by its ID.
-func (s *TaskServiceImpl) ResolveByID(id uuid.UUID) (task Task, err error) {
-	task, exist, err := s.TaskRepository.ResolveByID(id)
+func (s *TaskServiceImpl) ResolveByID(ctx context.Context, id uuid.UUID) (task Task, err error) {
+	task, exist, err := s.TaskRepository.ResolveByID(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("[ResolveByID] error Task ResolveByID")
+		return
+	}
+	if !exist {
+		return task, failure.NotFound("Task")
+	}
+
+	progress, err := s.TaskRepository.ResolveProgress(ctx, []uuid.UUID{task.ID})
+	if err != nil {
+		log.Err(err).Msg("[ResolveByID] error TaskRepository.ResolveProgress")
+		return
+	}
+	task.Progress = progress[task.ID]
+	return
+}
+
+// ResolveByIDAsOf resolves a Task as it was at asOf.
+func (s *TaskServiceImpl) ResolveByIDAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (task Task, err error) {
+	task, exist, err := s.TaskRepository.ResolveByIDAsOf(ctx, id, asOf)
+	if err != nil {
+		log.Err(err).Msg("[ResolveByIDAsOf] error Task ResolveByIDAsOf")
+		return
 	}
 	if !exist {
 		return task, failure.NotFound("Task")
@@ -89,8 +306,9 @@ func (s *TaskServiceImpl) ResolveByID(id uuid.UUID) (task Task, err error) {
 }
 
 // Update updates a Task.
-func (s *TaskServiceImpl) Update(id uuid.UUID, requestFormat TaskRequestFormat) (response TaskResponseFormat, err error) {
-	task, exist, err := s.TaskRepository.ResolveByID(id)
+// The Task must still have the version given by precondition.
+func (s *TaskServiceImpl) Update(ctx context.Context, id uuid.UUID, requestFormat TaskRequestFormat, precondition Precondition) (response TaskResponseFormat, err error) {
+	task, exist, err := s.TaskRepository.ResolveByID(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("[Update] error TaskRepository.ResolveByID")
 		return
@@ -100,24 +318,78 @@ func (s *TaskServiceImpl) Update(id uuid.UUID, requestFormat TaskRequestFormat)
 		return
 	}
 
+	err = task.CheckVersion("update", precondition)
+	if err != nil {
+		return
+	}
+
+	status, teamID, parentID := task.Status.String, task.TeamID, task.ParentID
 	err = task.UpdateRequestFormat(requestFormat)
 	if err != nil {
 		return
 	}
 
-	err = s.TaskRepository.Update(task)
+	if task.TeamID != teamID {
+		err = s.checkTeam(ctx, task.TeamID)
+		if err != nil {
+			return
+		}
+	}
+
+	if task.ParentID != parentID {
+		err = s.checkParent(ctx, task)
+		if err != nil {
+			return
+		}
+	}
+
+	if requestFormat.Labels != nil {
+		task.Labels, err = s.resolveLabels(ctx, requestFormat.Labels)
+		if err != nil {
+			return
+		}
+	}
+
+	err = s.Workflow.CheckTransition(ctx, status, task)
+	if err != nil {
+		return
+	}
+
+	if s.Workflow.IsDone(task.Status.String) && !s.Workflow.IsDone(status) {
+		err = s.checkBlockers(ctx, task)
+		if err != nil {
+			return
+		}
+	}
+
+	err = task.SetCompletion(s.Workflow.IsDone(task.Status.String), requestFormat.CompletedAt)
+	if err != nil {
+		return
+	}
+
+	err = s.TaskRepository.Update(ctx, task)
+	if errors.Is(err, ErrVersionMismatch) {
+		err = precondition.Mismatch("update")
+	}
 	if err != nil {
 		log.Err(err).Msg("[Update] error TaskRepository.Update")
 		return
 	}
+	task.Version++
+	s.Searcher.Index(task)
+
+	if s.Workflow.IsDone(task.Status.String) && !s.Workflow.IsDone(status) {
+		s.continueRecurrence(ctx, task)
+	}
 
 	response = task.ToJSONResponseFormat(MessageSuccessUpdatedData)
 	return
 }
 
-// SoftDelete marks a Task as deleted by setting its `deletedAt` and `deletedBy` properties.
-func (s *TaskServiceImpl) SoftDelete(id uuid.UUID, deletedBy string) (response TaskResponseFormat, err error) {
-	task, exist, err := s.TaskRepository.ResolveByID(id)
+// SoftDelete marks a Task as deleted by setting its `deletedAt` and `deletedBy` properties,
+// and its subtasks along with it. The Task must still have the version given by precondition.
+func (s *TaskServiceImpl) SoftDelete(ctx context.Context, id uuid.UUID, deletedBy string, precondition Precondition) (response TaskResponseFormat, err error) {
+	task, exist, err := s.TaskRepository.ResolveByID(ctx, id)
 	if err != nil {
 		log.Err(err).Msg("[SoftDelete] error TaskRepository.ResolveByID")
 		return
@@ -127,17 +399,1441 @@ func (s *TaskServiceImpl) SoftDelete(id uuid.UUID, deletedBy string) (response T
 		return
 	}
 
+	err = task.CheckVersion("softDelete", precondition)
+	if err != nil {
+		return
+	}
+
 	err = task.SoftDelete(deletedBy)
 	if err != nil {
 		return
 	}
 
-	err = s.TaskRepository.SoftDelete(task)
+	cascaded, err := s.TaskRepository.SoftDelete(ctx, task)
+	if errors.Is(err, ErrVersionMismatch) {
+		err = precondition.Mismatch("softDelete")
+	}
 	if err != nil {
 		log.Err(err).Msg("[SoftDelete] error TaskRepository.SoftDelete")
-		err = failure.InternalError(err)
 		return
 	}
+	s.Searcher.Remove(task.ID)
+	for _, subtask := range cascaded {
+		s.Searcher.Remove(subtask.ID)
+	}
 	response.Message = MessageSuccessDeletedData
 	return
 }
+
+// ResolveTrash resolves a page of soft deleted Tasks.
+func (s *TaskServiceImpl) ResolveTrash(ctx context.Context, pagination Pagination) (response TaskTrashResponseFormat, err error) {
+	pagination.SetDefaults()
+	tasks, err := s.TaskRepository.ResolveTrash(ctx, pagination)
+	if err != nil {
+		log.Err(err).Msg("[ResolveTrash] error TaskRepository.ResolveTrash")
+		return
+	}
+
+	response.Tasks = make([]TaskTrashResponse, 0, len(tasks))
+	for _, task := range tasks {
+		response.Tasks = append(response.Tasks, task.ToTrashResponseFormat())
+	}
+	if len(tasks) > 0 {
+		pagination.Count = tasks[0].FilterCount
+		pagination.TotalPage = int(math.Ceil(float64(pagination.Count) / float64(pagination.PageSize)))
+	}
+	response.Pagination = pagination
+	return
+}
+
+// ResolveHistory resolves a page of the recorded changes of a Task, most
+// recent first.
+func (s *TaskServiceImpl) ResolveHistory(ctx context.Context, id uuid.UUID, pagination Pagination) (response TaskHistoryResponseFormat, err error) {
+	pagination.SetDefaults()
+	history, err := s.TaskRepository.ResolveHistory(ctx, id, pagination)
+	if err != nil {
+		log.Err(err).Msg("[ResolveHistory] error TaskRepository.ResolveHistory")
+		return
+	}
+	// every Task has at least its creation recorded
+	if len(history) == 0 && pagination.Page == 1 {
+		err = failure.NotFound(fmt.Sprintf("TaskID %s", id.String()))
+		return
+	}
+
+	response.History = make([]TaskHistoryResponse, 0, len(history))
+	for _, entry := range history {
+		formatted, err := entry.ToResponseFormat()
+		if err != nil {
+			log.Err(err).Str("id", entry.ID.String()).Msg("[ResolveHistory] error decoding changes")
+			return response, failure.InternalError(err)
+		}
+		response.History = append(response.History, formatted)
+	}
+	if len(history) > 0 {
+		pagination.Count = history[0].FilterCount
+		pagination.TotalPage = int(math.Ceil(float64(pagination.Count) / float64(pagination.PageSize)))
+	}
+	response.Pagination = pagination
+	return
+}
+
+// Restore takes a soft deleted Task out of the trash, along with the subtasks
+// deleted with it. A subtask cannot be restored while its parent is deleted,
+// and the Task must still have the version given by precondition.
+func (s *TaskServiceImpl) Restore(ctx context.Context, id uuid.UUID, precondition Precondition) (response TaskResponseFormat, err error) {
+	task, exist, err := s.TaskRepository.ResolveTrashedByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[Restore] error TaskRepository.ResolveTrashedByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("Deleted TaskID %s", id.String()))
+		return
+	}
+
+	err = task.CheckVersion("restore", precondition)
+	if err != nil {
+		return
+	}
+
+	if task.ParentID.Valid {
+		parentID, parseErr := uuid.Parse(task.ParentID.String)
+		if parseErr != nil {
+			log.Err(parseErr).Msg("[Restore] error parsing ParentID")
+			return response, failure.InternalError(parseErr)
+		}
+		_, exist, err = s.TaskRepository.ResolveByID(ctx, parentID)
+		if err != nil {
+			log.Err(err).Msg("[Restore] error TaskRepository.ResolveByID")
+			return
+		}
+		if !exist {
+			err = failure.Conflict("restore", "Task", "its parent Task is deleted, restore the parent instead")
+			return
+		}
+	}
+
+	err = task.Restore()
+	if err != nil {
+		return
+	}
+
+	cascaded, err := s.TaskRepository.Restore(ctx, task)
+	if errors.Is(err, ErrVersionMismatch) {
+		err = precondition.Mismatch("restore")
+	}
+	if err != nil {
+		log.Err(err).Msg("[Restore] error TaskRepository.Restore")
+		return
+	}
+	task.Version++
+	s.Searcher.Index(task)
+	for _, subtask := range cascaded {
+		s.Searcher.Index(subtask)
+	}
+
+	response = task.ToJSONResponseFormat(MessageSuccessRestoredData)
+	return
+}
+
+// HardDelete permanently deletes a Task and its subtasks. Only Tasks in the
+// trash can be permanently deleted, and the Task must still have the version
+// given by precondition.
+func (s *TaskServiceImpl) HardDelete(ctx context.Context, id uuid.UUID, precondition Precondition) (response TaskResponseFormat, err error) {
+	task, exist, err := s.TaskRepository.ResolveTrashedByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[HardDelete] error TaskRepository.ResolveTrashedByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("Deleted TaskID %s", id.String()))
+		return
+	}
+
+	err = task.CheckVersion("hardDelete", precondition)
+	if err != nil {
+		return
+	}
+
+	cascaded, err := s.TaskRepository.HardDelete(ctx, task)
+	if errors.Is(err, ErrVersionMismatch) {
+		err = precondition.Mismatch("hardDelete")
+	}
+	if err != nil {
+		log.Err(err).Msg("[HardDelete] error TaskRepository.HardDelete")
+		return
+	}
+	s.deleteAttachmentFiles(ctx, append(cascaded, task))
+
+	response.Message = MessageSuccessPurgedData
+	return
+}
+
+// PurgeTrash permanently deletes Tasks that have been in the trash longer than
+// the configured retention. Tasks restored or changed meanwhile are skipped, as
+// are subtasks already purged along with their parent.
+func (s *TaskServiceImpl) PurgeTrash(ctx context.Context) (purged int, err error) {
+	retention := s.Config.Task.Trash.RetentionDays
+	if retention <= 0 {
+		return
+	}
+	batchSize := s.Config.Task.Trash.PurgeBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPurgeBatchSize
+	}
+	deletedBefore := time.Now().AddDate(0, 0, -retention)
+
+	for {
+		tasks, err := s.TaskRepository.ResolveExpiredTrash(ctx, deletedBefore, batchSize)
+		if err != nil {
+			log.Err(err).Msg("[PurgeTrash] error TaskRepository.ResolveExpiredTrash")
+			return purged, err
+		}
+
+		for _, task := range tasks {
+			cascaded, err := s.TaskRepository.HardDelete(ctx, task)
+			if errors.Is(err, ErrVersionMismatch) {
+				continue
+			}
+			if err != nil {
+				log.Err(err).Msg("[PurgeTrash] error TaskRepository.HardDelete")
+				return purged, err
+			}
+			s.deleteAttachmentFiles(ctx, append(cascaded, task))
+			purged++
+		}
+
+		if len(tasks) < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// Assign assigns a Task to more users, leaving those it is assigned to
+// already. The Task must still have the version given by precondition.
+func (s *TaskServiceImpl) Assign(ctx context.Context, id uuid.UUID, userIDs []int64, precondition Precondition) (response TaskResponseFormat, err error) {
+	task, exist, err := s.TaskRepository.ResolveByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[Assign] error TaskRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("TaskID %s", id.String()))
+		return
+	}
+
+	err = task.CheckVersion("assign", precondition)
+	if err != nil {
+		return
+	}
+
+	err = s.checkUsers(ctx, "userIds", userIDs)
+	if err != nil {
+		return
+	}
+
+	assignees := task.Assignees.With(userIDs...)
+	if len(assignees) > maxAssignees {
+		err = failure.InvalidFields(map[string]string{
+			"userIds": fmt.Sprintf("a Task can be assigned to at most %d users", maxAssignees),
+		})
+		return
+	}
+
+	return s.reassign(ctx, task, assignees, "assign", precondition, MessageSuccessAssignedData)
+}
+
+// Unassign unassigns a user from a Task. The Task must still have the version
+// given by precondition.
+func (s *TaskServiceImpl) Unassign(ctx context.Context, id uuid.UUID, userID int64, precondition Precondition) (response TaskResponseFormat, err error) {
+	task, exist, err := s.TaskRepository.ResolveByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[Unassign] error TaskRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("TaskID %s", id.String()))
+		return
+	}
+
+	err = task.CheckVersion("unassign", precondition)
+	if err != nil {
+		return
+	}
+
+	if !task.Assignees.Contains(userID) {
+		err = failure.NotFound(fmt.Sprintf("Assignee %d of TaskID %s", userID, id.String()))
+		return
+	}
+
+	return s.reassign(ctx, task, task.Assignees.Without(userID), "unassign", precondition, MessageSuccessUnassignedData)
+}
+
+// reassign assigns task to assignees, unless it is assigned to them already.
+func (s *TaskServiceImpl) reassign(ctx context.Context, task Task, assignees UserIDs, operationName string, precondition Precondition, message string) (response TaskResponseFormat, err error) {
+	if equalValues(task.Assignees, assignees) {
+		return task.ToJSONResponseFormat(message), nil
+	}
+
+	task.Assignees = assignees
+	err = s.TaskRepository.Reassign(ctx, task)
+	if errors.Is(err, ErrVersionMismatch) {
+		err = precondition.Mismatch(operationName)
+	}
+	if err != nil {
+		log.Err(err).Msg("[" + operationName + "] error TaskRepository.Reassign")
+		return
+	}
+	task.Version++
+	s.Searcher.Index(task)
+
+	response = task.ToJSONResponseFormat(message)
+	return
+}
+
+// ResolveChecklist resolves the checklist of a Task, in order.
+func (s *TaskServiceImpl) ResolveChecklist(ctx context.Context, id uuid.UUID) (response ChecklistResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	items, err := s.Checklists.ResolveByTaskID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[ResolveChecklist] error ChecklistRepository.ResolveByTaskID")
+		return
+	}
+	response.Items = make([]ChecklistItemResponse, 0, len(items))
+	for _, item := range items {
+		response.Items = append(response.Items, item.ToResponseFormat())
+	}
+	return
+}
+
+// AddChecklistItem adds an item to the checklist of a Task, last unless the
+// request positions it.
+func (s *TaskServiceImpl) AddChecklistItem(ctx context.Context, id uuid.UUID, requestFormat ChecklistItemRequestFormat) (response ChecklistItemResponseFormat, err error) {
+	task, err := s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	items, err := s.Checklists.ResolveByTaskID(ctx, task.ID)
+	if err != nil {
+		log.Err(err).Msg("[AddChecklistItem] error ChecklistRepository.ResolveByTaskID")
+		return
+	}
+	if len(items) >= maxChecklistItems {
+		err = failure.InvalidFields(map[string]string{
+			"title": fmt.Sprintf("a Task can have at most %d checklist items", maxChecklistItems),
+		})
+		return
+	}
+	position := 1
+	if len(items) > 0 {
+		position = items[len(items)-1].Position + 1
+	}
+
+	item, err := NewChecklistItem(task.ID, requestFormat, position)
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	err = s.Checklists.Create(ctx, item)
+	if err != nil {
+		log.Err(err).Msg("[AddChecklistItem] error ChecklistRepository.Create")
+		return
+	}
+
+	formatted := item.ToResponseFormat()
+	response = ChecklistItemResponseFormat{Message: MessageSuccessCreatedChecklistItem, Item: &formatted}
+	return
+}
+
+// UpdateChecklistItem updates an item of the checklist of a Task.
+func (s *TaskServiceImpl) UpdateChecklistItem(ctx context.Context, id uuid.UUID, itemID uuid.UUID, requestFormat ChecklistItemRequestFormat) (response ChecklistItemResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	item, exist, err := s.Checklists.ResolveByID(ctx, id, itemID)
+	if err != nil {
+		log.Err(err).Msg("[UpdateChecklistItem] error ChecklistRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("ChecklistItem %s of TaskID %s", itemID, id))
+		return
+	}
+
+	err = item.Update(requestFormat)
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	err = s.Checklists.Update(ctx, item)
+	if err != nil {
+		log.Err(err).Msg("[UpdateChecklistItem] error ChecklistRepository.Update")
+		return
+	}
+
+	formatted := item.ToResponseFormat()
+	response = ChecklistItemResponseFormat{Message: MessageSuccessUpdatedChecklistItem, Item: &formatted}
+	return
+}
+
+// DeleteChecklistItem deletes an item of the checklist of a Task.
+func (s *TaskServiceImpl) DeleteChecklistItem(ctx context.Context, id uuid.UUID, itemID uuid.UUID) (response ChecklistItemResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	err = s.Checklists.Delete(ctx, id, itemID)
+	if err != nil {
+		log.Err(err).Msg("[DeleteChecklistItem] error ChecklistRepository.Delete")
+		return
+	}
+
+	response.Message = MessageSuccessDeletedChecklistItem
+	return
+}
+
+// ResolveComments resolves a page of the comments on a Task, oldest first.
+func (s *TaskServiceImpl) ResolveComments(ctx context.Context, id uuid.UUID, pagination Pagination) (response TaskCommentListResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	pagination.SetDefaults()
+	comments, err := s.Comments.ResolveByTaskID(ctx, id, pagination)
+	if err != nil {
+		log.Err(err).Msg("[ResolveComments] error CommentRepository.ResolveByTaskID")
+		return
+	}
+
+	response.Comments = make([]TaskCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response.Comments = append(response.Comments, comment.ToResponseFormat())
+	}
+	if len(comments) > 0 {
+		pagination.Count = comments[0].FilterCount
+		pagination.TotalPage = int(math.Ceil(float64(pagination.Count) / float64(pagination.PageSize)))
+	}
+	response.Pagination = pagination
+	return
+}
+
+// ResolveCommentEdits resolves a comment on a Task with the bodies it had
+// before each of its edits, most recent first.
+func (s *TaskServiceImpl) ResolveCommentEdits(ctx context.Context, id uuid.UUID, commentID uuid.UUID) (response TaskCommentEditsResponseFormat, err error) {
+	comment, err := s.resolveComment(ctx, id, commentID)
+	if err != nil {
+		return
+	}
+
+	edits, err := s.Comments.ResolveEdits(ctx, comment.ID)
+	if err != nil {
+		log.Err(err).Msg("[ResolveCommentEdits] error CommentRepository.ResolveEdits")
+		return
+	}
+
+	response.Comment = comment.ToResponseFormat()
+	response.Edits = make([]TaskCommentEditResponse, 0, len(edits))
+	for _, edit := range edits {
+		response.Edits = append(response.Edits, edit.ToResponseFormat())
+	}
+	return
+}
+
+// AddComment adds a comment to a Task, notifying the users it mentions.
+func (s *TaskServiceImpl) AddComment(ctx context.Context, id uuid.UUID, requestFormat TaskCommentRequestFormat) (response TaskCommentResponseFormat, err error) {
+	task, err := s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	comment, err := NewTaskComment(task.ID, requestFormat, shared.ActorFromContext(ctx))
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	mentioned, err := s.resolveMentions(ctx, comment.Body)
+	if err != nil {
+		return
+	}
+	for _, user := range mentioned {
+		comment.Mentions = comment.Mentions.With(user.ID)
+	}
+
+	err = s.Comments.Create(ctx, comment, mentioned)
+	if err != nil {
+		log.Err(err).Msg("[AddComment] error CommentRepository.Create")
+		return
+	}
+
+	formatted := comment.ToResponseFormat()
+	response = TaskCommentResponseFormat{Message: MessageSuccessCreatedComment, Comment: &formatted}
+	return
+}
+
+// UpdateComment edits a comment on a Task, keeping its previous body. Only the
+// users it newly mentions are notified.
+func (s *TaskServiceImpl) UpdateComment(ctx context.Context, id uuid.UUID, commentID uuid.UUID, requestFormat TaskCommentRequestFormat) (response TaskCommentResponseFormat, err error) {
+	comment, err := s.resolveComment(ctx, id, commentID)
+	if err != nil {
+		return
+	}
+
+	actor := shared.ActorFromContext(ctx)
+	err = comment.CheckAuthor(actor)
+	if err != nil {
+		return
+	}
+
+	if strings.TrimSpace(requestFormat.Body) == comment.Body {
+		formatted := comment.ToResponseFormat()
+		return TaskCommentResponseFormat{Message: MessageSuccessUpdatedComment, Comment: &formatted}, nil
+	}
+	edit, err := comment.Edit(requestFormat, actor)
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	mentioned, err := s.resolveMentions(ctx, comment.Body)
+	if err != nil {
+		return
+	}
+	previous := comment.Mentions
+	comment.Mentions = nil
+	newlyMentioned := make([]MentionedUser, 0, len(mentioned))
+	for _, user := range mentioned {
+		comment.Mentions = comment.Mentions.With(user.ID)
+		if !previous.Contains(user.ID) {
+			newlyMentioned = append(newlyMentioned, user)
+		}
+	}
+
+	err = s.Comments.Update(ctx, comment, edit, newlyMentioned)
+	if errors.Is(err, ErrVersionMismatch) {
+		return response, failure.Conflict("update", "TaskComment", err.Error())
+	}
+	if err != nil {
+		log.Err(err).Msg("[UpdateComment] error CommentRepository.Update")
+		return
+	}
+	comment.Version++
+
+	formatted := comment.ToResponseFormat()
+	response = TaskCommentResponseFormat{Message: MessageSuccessUpdatedComment, Comment: &formatted}
+	return
+}
+
+// DeleteComment soft deletes a comment on a Task.
+func (s *TaskServiceImpl) DeleteComment(ctx context.Context, id uuid.UUID, commentID uuid.UUID) (response TaskCommentResponseFormat, err error) {
+	comment, err := s.resolveComment(ctx, id, commentID)
+	if err != nil {
+		return
+	}
+
+	actor := shared.ActorFromContext(ctx)
+	err = comment.CheckAuthor(actor)
+	if err != nil {
+		return
+	}
+
+	comment.SoftDelete(actor)
+	err = s.Comments.SoftDelete(ctx, comment)
+	if errors.Is(err, ErrVersionMismatch) {
+		return response, failure.Conflict("delete", "TaskComment", err.Error())
+	}
+	if err != nil {
+		log.Err(err).Msg("[DeleteComment] error CommentRepository.SoftDelete")
+		return
+	}
+
+	response.Message = MessageSuccessDeletedComment
+	return
+}
+
+// ResolveAttachments resolves the files attached to a Task, oldest first.
+func (s *TaskServiceImpl) ResolveAttachments(ctx context.Context, id uuid.UUID) (response TaskAttachmentListResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	attachments, err := s.Attachments.ResolveByTaskID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[ResolveAttachments] error AttachmentRepository.ResolveByTaskID")
+		return
+	}
+	response.Attachments = make([]TaskAttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		response.Attachments = append(response.Attachments, attachment.ToResponseFormat())
+	}
+	return
+}
+
+// AddAttachment stores the file read from content and attaches it to a Task.
+// Its content type is detected from the file rather than trusted from the
+// client, and its size is limited by the upload configuration.
+func (s *TaskServiceImpl) AddAttachment(ctx context.Context, id uuid.UUID, fileName string, content io.Reader) (response TaskAttachmentResponseFormat, err error) {
+	task, err := s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	attachments, err := s.Attachments.ResolveByTaskID(ctx, task.ID)
+	if err != nil {
+		log.Err(err).Msg("[AddAttachment] error AttachmentRepository.ResolveByTaskID")
+		return
+	}
+	if len(attachments) >= maxAttachments {
+		err = failure.InvalidFields(map[string]string{
+			"file": fmt.Sprintf("a Task can have at most %d attachments", maxAttachments),
+		})
+		return
+	}
+
+	attachment, err := NewTaskAttachment(task.ID, fileName, shared.ActorFromContext(ctx))
+	if err != nil {
+		return response, failure.BadRequest(err)
+	}
+
+	maxSize := s.maxAttachmentSize()
+	attachment.ContentType, content, err = SniffContentType(&limitedReader{reader: content, limit: maxSize})
+	if err == nil {
+		attachment.Size, err = s.Storage.Save(ctx, attachment.StorageKey, content)
+	}
+	if errors.Is(err, ErrAttachmentTooLarge) {
+		err = failure.RequestEntityTooLarge(fmt.Sprintf("an attachment can be at most %d bytes", maxSize))
+		return
+	}
+	if err != nil {
+		log.Err(err).Msg("[AddAttachment] error Storage.Save")
+		return response, failure.InternalError(err)
+	}
+
+	err = s.Attachments.Create(ctx, attachment)
+	if err != nil {
+		log.Err(err).Msg("[AddAttachment] error AttachmentRepository.Create")
+		s.deleteAttachmentFile(ctx, attachment)
+		return
+	}
+
+	formatted := attachment.ToResponseFormat()
+	response = TaskAttachmentResponseFormat{Message: MessageSuccessCreatedAttachment, Attachment: &formatted}
+	return
+}
+
+// OpenAttachment resolves a file attached to a Task and opens it for reading.
+// The caller closes content.
+func (s *TaskServiceImpl) OpenAttachment(ctx context.Context, id uuid.UUID, attachmentID uuid.UUID) (attachment TaskAttachment, content io.ReadSeekCloser, err error) {
+	attachment, err = s.resolveAttachment(ctx, id, attachmentID)
+	if err != nil {
+		return
+	}
+
+	content, err = s.Storage.Open(ctx, attachment.StorageKey)
+	if errors.Is(err, infras.ErrNotStored) {
+		log.Warn().Str("key", attachment.StorageKey).Msg("[OpenAttachment] attachment missing from storage")
+		err = failure.NotFound(fmt.Sprintf("TaskAttachment %s of TaskID %s", attachmentID, id))
+		return
+	}
+	if err != nil {
+		log.Err(err).Msg("[OpenAttachment] error Storage.Open")
+		err = failure.InternalError(err)
+	}
+	return
+}
+
+// DeleteAttachment detaches a file from a Task and deletes it.
+func (s *TaskServiceImpl) DeleteAttachment(ctx context.Context, id uuid.UUID, attachmentID uuid.UUID) (response TaskAttachmentResponseFormat, err error) {
+	attachment, err := s.resolveAttachment(ctx, id, attachmentID)
+	if err != nil {
+		return
+	}
+
+	err = s.Attachments.Delete(ctx, id, attachmentID)
+	if err != nil {
+		log.Err(err).Msg("[DeleteAttachment] error AttachmentRepository.Delete")
+		return
+	}
+	s.deleteAttachmentFile(ctx, attachment)
+
+	response.Message = MessageSuccessDeletedAttachment
+	return
+}
+
+// AddBlocker makes a Task wait for blocker until blocker is completed. A
+// dependency making blocker wait, directly or not, for the Task is refused.
+func (s *TaskServiceImpl) AddBlocker(ctx context.Context, id uuid.UUID, blockerID uuid.UUID) (response TaskDependencyResponseFormat, err error) {
+	if id == blockerID {
+		err = failure.InvalidFields(map[string]string{"blockerId": "a Task cannot block itself"})
+		return
+	}
+
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	_, exist, err := s.TaskRepository.ResolveByID(ctx, blockerID)
+	if err != nil {
+		log.Err(err).Msg("[AddBlocker] error TaskRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.InvalidFields(map[string]string{"blockerId": fmt.Sprintf("Task %s does not exist", blockerID)})
+		return
+	}
+
+	actor := shared.ActorFromContext(ctx)
+	err = s.Dependencies.Create(ctx, TaskDependency{
+		TaskID:    id,
+		BlockerID: blockerID,
+		CreatedBy: null.NewString(actor, actor != ""),
+		CreatedAt: time.Now(),
+	})
+	if errors.Is(err, ErrDependencyCycle) {
+		err = failure.Conflict("addBlocker", "TaskDependency",
+			fmt.Sprintf("Task %s already waits for Task %s", blockerID, id))
+	}
+	if err != nil {
+		log.Err(err).Msg("[AddBlocker] error DependencyRepository.Create")
+		return
+	}
+
+	response.Message = MessageSuccessAddedBlocker
+	response.Dependency = &TaskDependencyEdge{TaskID: id, BlockerID: blockerID}
+	return
+}
+
+// RemoveBlocker stops a Task from waiting for blocker.
+func (s *TaskServiceImpl) RemoveBlocker(ctx context.Context, id uuid.UUID, blockerID uuid.UUID) (response TaskDependencyResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	err = s.Dependencies.Delete(ctx, id, blockerID)
+	if err != nil {
+		log.Err(err).Msg("[RemoveBlocker] error DependencyRepository.Delete")
+		return
+	}
+
+	response.Message = MessageSuccessRemovedBlocker
+	return
+}
+
+// ResolveDependencies resolves the dependency graph of a Task: the Tasks it
+// waits for and the Tasks waiting for it, directly or not. Deleted Tasks are
+// left out, and graphs of more than maxDependencyEdges dependencies each way
+// are truncated.
+func (s *TaskServiceImpl) ResolveDependencies(ctx context.Context, id uuid.UUID) (response TaskDependencyGraphResponseFormat, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	upstream, err := s.Dependencies.ResolveUpstream(ctx, []uuid.UUID{id}, maxDependencyEdges+1)
+	if err != nil {
+		log.Err(err).Msg("[ResolveDependencies] error DependencyRepository.ResolveUpstream")
+		return
+	}
+	downstream, err := s.Dependencies.ResolveDownstream(ctx, id, maxDependencyEdges+1)
+	if err != nil {
+		log.Err(err).Msg("[ResolveDependencies] error DependencyRepository.ResolveDownstream")
+		return
+	}
+	if len(upstream) > maxDependencyEdges {
+		upstream, response.Truncated = upstream[:maxDependencyEdges], true
+	}
+	if len(downstream) > maxDependencyEdges {
+		downstream, response.Truncated = downstream[:maxDependencyEdges], true
+	}
+
+	// a Task reached by several paths is as far as its nearest one
+	upstreamDepths, downstreamDepths := make(map[uuid.UUID]int), make(map[uuid.UUID]int)
+	ids := []uuid.UUID{id}
+	for _, edge := range upstream {
+		if _, ok := upstreamDepths[edge.BlockerID]; !ok {
+			upstreamDepths[edge.BlockerID] = edge.Depth
+			ids = append(ids, edge.BlockerID)
+		}
+	}
+	for _, edge := range downstream {
+		if _, ok := downstreamDepths[edge.TaskID]; !ok {
+			downstreamDepths[edge.TaskID] = edge.Depth
+			ids = append(ids, edge.TaskID)
+		}
+	}
+
+	nodes, err := s.resolveGraphNodes(ctx, ids)
+	if err != nil {
+		return
+	}
+
+	response.Task = nodes[id].ToResponseFormat(0)
+	response.Upstream = graphNodeResponses(nodes, upstreamDepths)
+	response.Downstream = graphNodeResponses(nodes, downstreamDepths)
+	response.Edges = liveEdges(nodes, append(upstream, downstream...))
+	return
+}
+
+// Plan orders Tasks so that every Task comes after the Tasks it waits for,
+// directly or through Tasks outside of ids. Tasks of the same stage do not
+// wait for one another and keep the order they were given in.
+func (s *TaskServiceImpl) Plan(ctx context.Context, ids []uuid.UUID) (response TaskPlanResponseFormat, err error) {
+	edges, err := s.Dependencies.ResolveUpstream(ctx, ids, maxDependencyEdges+1)
+	if err != nil {
+		log.Err(err).Msg("[Plan] error DependencyRepository.ResolveUpstream")
+		return
+	}
+	if len(edges) > maxDependencyEdges {
+		err = failure.InvalidFields(map[string]string{
+			"ids": fmt.Sprintf("the Tasks wait for more than %d dependencies", maxDependencyEdges),
+		})
+		return
+	}
+
+	all := append([]uuid.UUID{}, ids...)
+	for _, edge := range edges {
+		all = append(all, edge.TaskID, edge.BlockerID)
+	}
+	nodes, err := s.resolveGraphNodes(ctx, all)
+	if err != nil {
+		return
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := nodes[id]; !ok {
+			missing = append(missing, id.String())
+		}
+	}
+	if len(missing) > 0 {
+		message := "Tasks " + strings.Join(missing, ", ") + " do not exist"
+		if len(missing) == 1 {
+			message = "Task " + missing[0] + " does not exist"
+		}
+		err = failure.InvalidFields(map[string]string{"ids": message})
+		return
+	}
+
+	edges = liveEdges(nodes, edges)
+	stages, err := PlanStages(edges)
+	if err != nil {
+		err = failure.Conflict("plan", "TaskDependency", "the dependencies of the Tasks form a cycle")
+		return
+	}
+
+	response.Tasks = make([]TaskPlanStep, 0, len(ids))
+	for _, id := range ids {
+		step := TaskPlanStep{
+			TaskGraphNodeResponse: nodes[id].ToResponseFormat(0),
+			Stage:                 stages[id],
+			BlockedBy:             []string{},
+		}
+		for _, edge := range edges {
+			if edge.TaskID == id {
+				step.BlockedBy = append(step.BlockedBy, edge.BlockerID.String())
+			}
+		}
+		response.Tasks = append(response.Tasks, step)
+	}
+	sort.SliceStable(response.Tasks, func(i, j int) bool {
+		return response.Tasks[i].Stage < response.Tasks[j].Stage
+	})
+	return
+}
+
+// ResolveRecurrences resolves a page of the recurring Tasks, most recent first.
+func (s *TaskServiceImpl) ResolveRecurrences(ctx context.Context, pagination Pagination) (response TaskRecurrenceListResponseFormat, err error) {
+	pagination.SetDefaults()
+	recurrences, err := s.Recurrences.ResolveAll(ctx, pagination)
+	if err != nil {
+		log.Err(err).Msg("[ResolveRecurrences] error RecurrenceRepository.ResolveAll")
+		return
+	}
+
+	response.Recurrences = make([]TaskRecurrenceResponse, 0, len(recurrences))
+	for _, recurrence := range recurrences {
+		response.Recurrences = append(response.Recurrences, recurrence.ToResponseFormat())
+	}
+	if len(recurrences) > 0 {
+		pagination.Count = recurrences[0].FilterCount
+		pagination.TotalPage = int(math.Ceil(float64(pagination.Count) / float64(pagination.PageSize)))
+	}
+	response.Pagination = pagination
+	return
+}
+
+// ResolveRecurrence resolves a recurring Task with its upcoming occurrences.
+func (s *TaskServiceImpl) ResolveRecurrence(ctx context.Context, id uuid.UUID) (response TaskRecurrenceResponseFormat, err error) {
+	recurrence, err := s.resolveRecurrence(ctx, id)
+	if err != nil {
+		return
+	}
+
+	formatted := recurrence.ToResponseFormat()
+	response.Recurrence = &formatted
+	return
+}
+
+// CreateRecurrence creates a recurring Task. Its template is checked as a new
+// Task would be. A recurrence generated on completion makes its first
+// occurrence a Task at once; one generated on schedule leaves it to the
+// scheduler.
+func (s *TaskServiceImpl) CreateRecurrence(ctx context.Context, requestFormat TaskRecurrenceRequestFormat) (response TaskRecurrenceResponseFormat, err error) {
+	recurrence, err := NewTaskRecurrence(requestFormat, shared.ActorFromContext(ctx))
+	if err != nil {
+		return
+	}
+
+	err = s.checkTemplate(ctx, recurrence)
+	if err != nil {
+		return
+	}
+
+	first, err := s.firstOccurrence(recurrence)
+	if err != nil {
+		return
+	}
+	recurrence.NextOccurrenceAt = null.TimeFrom(first)
+
+	err = s.Recurrences.Create(ctx, recurrence)
+	if err != nil {
+		log.Err(err).Msg("[CreateRecurrence] error RecurrenceRepository.Create")
+		return
+	}
+
+	if recurrence.Generation == GenerationCompletion {
+		recurrence, _, err = s.materialize(ctx, recurrence, first)
+		if err != nil {
+			log.Err(err).Msg("[CreateRecurrence] error materialize")
+			return
+		}
+	}
+
+	formatted := recurrence.ToResponseFormat()
+	response = TaskRecurrenceResponseFormat{Message: MessageSuccessCreatedRecurrence, Recurrence: &formatted}
+	return
+}
+
+// UpdateRecurrence updates a recurring Task. Its next occurrence is worked out
+// again from now; the Tasks of past occurrences are left as they are.
+func (s *TaskServiceImpl) UpdateRecurrence(ctx context.Context, id uuid.UUID, requestFormat TaskRecurrenceRequestFormat) (response TaskRecurrenceResponseFormat, err error) {
+	recurrence, err := s.resolveRecurrence(ctx, id)
+	if err != nil {
+		return
+	}
+
+	generation, scheduled := recurrence.Generation, recurrence.NextOccurrenceAt.Valid
+	err = recurrence.Update(requestFormat)
+	if err != nil {
+		return
+	}
+
+	err = s.checkTemplate(ctx, recurrence)
+	if err != nil {
+		return
+	}
+
+	first, err := s.firstOccurrence(recurrence)
+	if err != nil {
+		return
+	}
+	recurrence.NextOccurrenceAt = null.TimeFrom(first)
+
+	err = s.Recurrences.Update(ctx, recurrence)
+	if errors.Is(err, ErrVersionMismatch) {
+		return response, failure.Conflict("update", "TaskRecurrence", err.Error())
+	}
+	if err != nil {
+		log.Err(err).Msg("[UpdateRecurrence] error RecurrenceRepository.Update")
+		return
+	}
+	recurrence.Version++
+
+	// nothing else would make the next occurrence a Task when the recurrence
+	// was not waiting on the completion of one
+	if recurrence.Generation == GenerationCompletion && (generation != GenerationCompletion || !scheduled) {
+		recurrence, _, err = s.materialize(ctx, recurrence, first)
+		if err != nil {
+			log.Err(err).Msg("[UpdateRecurrence] error materialize")
+			return
+		}
+	}
+
+	formatted := recurrence.ToResponseFormat()
+	response = TaskRecurrenceResponseFormat{Message: MessageSuccessUpdatedRecurrence, Recurrence: &formatted}
+	return
+}
+
+// DeleteRecurrence deletes a recurring Task. The Tasks of its past
+// occurrences are kept.
+func (s *TaskServiceImpl) DeleteRecurrence(ctx context.Context, id uuid.UUID) (response TaskRecurrenceResponseFormat, err error) {
+	err = s.Recurrences.Delete(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[DeleteRecurrence] error RecurrenceRepository.Delete")
+		return
+	}
+
+	response.Message = MessageSuccessDeletedRecurrence
+	return
+}
+
+// MaterializeRecurrences makes the due occurrences of the recurrences
+// generated on schedule Tasks, catching up on any missed meanwhile. A
+// recurrence failing to is logged and retried on the next run, so that it
+// does not hold back the others.
+func (s *TaskServiceImpl) MaterializeRecurrences(ctx context.Context) (materialized int, err error) {
+	batchSize := s.Config.Task.Recurrence.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRecurrenceBatchSize
+	}
+
+	for {
+		recurrences, err := s.Recurrences.ResolveDue(ctx, time.Now().UTC(), batchSize)
+		if err != nil {
+			log.Err(err).Msg("[MaterializeRecurrences] error RecurrenceRepository.ResolveDue")
+			return materialized, err
+		}
+
+		advanced := 0
+		for _, recurrence := range recurrences {
+			_, created, err := s.materialize(ctx, recurrence, recurrence.NextOccurrenceAt.Time)
+			if err != nil {
+				log.Err(err).Str("id", recurrence.ID.String()).Msg("[MaterializeRecurrences] error materialize")
+				continue
+			}
+			if created {
+				materialized++
+			}
+			advanced++
+		}
+
+		if len(recurrences) < batchSize || advanced == 0 {
+			return materialized, nil
+		}
+	}
+}
+
+// checkBlockers verifies that no open Task blocks task from being done.
+func (s *TaskServiceImpl) checkBlockers(ctx context.Context, task Task) (err error) {
+	blockers, err := s.Dependencies.ResolveOpenBlockers(ctx, task.ID)
+	if err != nil {
+		log.Err(err).Msg("[checkBlockers] error DependencyRepository.ResolveOpenBlockers")
+		return
+	}
+	if len(blockers) == 0 {
+		return
+	}
+	open := make([]TaskGraphNodeResponse, 0, len(blockers))
+	for _, blocker := range blockers {
+		open = append(open, blocker.ToResponseFormat(0))
+	}
+	return failure.ConflictWithData("update", "Task",
+		fmt.Sprintf("cannot move to %s while blocked by open Tasks", task.Status.String),
+		map[string]interface{}{"blockers": open})
+}
+
+// resolveGraphNodes resolves the Tasks with the given IDs that are not
+// deleted, by ID.
+func (s *TaskServiceImpl) resolveGraphNodes(ctx context.Context, ids []uuid.UUID) (nodes map[uuid.UUID]TaskGraphNode, err error) {
+	unique := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	found, err := s.Dependencies.ResolveNodes(ctx, unique)
+	if err != nil {
+		log.Err(err).Msg("[resolveGraphNodes] error DependencyRepository.ResolveNodes")
+		return
+	}
+	nodes = make(map[uuid.UUID]TaskGraphNode, len(found))
+	for _, node := range found {
+		nodes[node.ID] = node
+	}
+	return
+}
+
+// graphNodeResponses returns the nodes at depths, nearest first.
+func graphNodeResponses(nodes map[uuid.UUID]TaskGraphNode, depths map[uuid.UUID]int) (responses []TaskGraphNodeResponse) {
+	responses = make([]TaskGraphNodeResponse, 0, len(depths))
+	for id, depth := range depths {
+		if node, ok := nodes[id]; ok {
+			responses = append(responses, node.ToResponseFormat(depth))
+		}
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		if responses[i].Depth != responses[j].Depth {
+			return responses[i].Depth < responses[j].Depth
+		}
+		return responses[i].Title < responses[j].Title
+	})
+	return
+}
+
+// liveEdges returns the distinct edges between the nodes.
+func liveEdges(nodes map[uuid.UUID]TaskGraphNode, edges []TaskDependencyEdge) (live []TaskDependencyEdge) {
+	live = make([]TaskDependencyEdge, 0, len(edges))
+	seen := make(map[TaskDependencyEdge]bool)
+	for _, edge := range edges {
+		_, taskFound := nodes[edge.TaskID]
+		_, blockerFound := nodes[edge.BlockerID]
+		key := TaskDependencyEdge{TaskID: edge.TaskID, BlockerID: edge.BlockerID}
+		if taskFound && blockerFound && !seen[key] {
+			seen[key] = true
+			live = append(live, key)
+		}
+	}
+	return
+}
+
+// resolveExisting resolves a Task that is not deleted, such as the one whose
+// checklist or dependencies are worked on.
+func (s *TaskServiceImpl) resolveExisting(ctx context.Context, id uuid.UUID) (task Task, err error) {
+	task, exist, err := s.TaskRepository.ResolveByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[resolveExisting] error TaskRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("TaskID %s", id.String()))
+	}
+	return
+}
+
+// resolveComment resolves a comment that is not deleted on a Task that is not
+// deleted either.
+func (s *TaskServiceImpl) resolveComment(ctx context.Context, id uuid.UUID, commentID uuid.UUID) (comment TaskComment, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	comment, exist, err := s.Comments.ResolveByID(ctx, id, commentID)
+	if err != nil {
+		log.Err(err).Msg("[resolveComment] error CommentRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("TaskComment %s of TaskID %s", commentID, id))
+	}
+	return
+}
+
+// resolveMentions resolves the users mentioned in body. Mentions of unknown
+// usernames are left as plain text.
+func (s *TaskServiceImpl) resolveMentions(ctx context.Context, body string) (mentioned []MentionedUser, err error) {
+	mentioned, err = s.Comments.ResolveUsersByName(ctx, ParseMentions(body))
+	if err != nil {
+		log.Err(err).Msg("[resolveMentions] error CommentRepository.ResolveUsersByName")
+	}
+	return
+}
+
+// resolveAttachment resolves a file attached to a Task that is not deleted.
+func (s *TaskServiceImpl) resolveAttachment(ctx context.Context, id uuid.UUID, attachmentID uuid.UUID) (attachment TaskAttachment, err error) {
+	_, err = s.resolveExisting(ctx, id)
+	if err != nil {
+		return
+	}
+
+	attachment, exist, err := s.Attachments.ResolveByID(ctx, id, attachmentID)
+	if err != nil {
+		log.Err(err).Msg("[resolveAttachment] error AttachmentRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("TaskAttachment %s of TaskID %s", attachmentID, id))
+	}
+	return
+}
+
+// maxAttachmentSize is the size limit of an attachment, in bytes.
+func (s *TaskServiceImpl) maxAttachmentSize() int64 {
+	maxSizeMB := s.Config.Upload.Image.MaxSize
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxAttachmentSizeMB
+	}
+	return int64(maxSizeMB) << 20
+}
+
+// deleteAttachmentFile deletes the file of an attachment from storage. A file
+// left behind only takes space, so failures are logged and not returned.
+func (s *TaskServiceImpl) deleteAttachmentFile(ctx context.Context, attachment TaskAttachment) {
+	err := s.Storage.Delete(ctx, attachment.StorageKey)
+	if err != nil {
+		log.Err(err).Str("key", attachment.StorageKey).Msg("[deleteAttachmentFile] error Storage.Delete")
+	}
+}
+
+// deleteAttachmentFiles deletes the files attached to permanently deleted
+// Tasks, whose attachment records went with them.
+func (s *TaskServiceImpl) deleteAttachmentFiles(ctx context.Context, tasks []Task) {
+	for _, task := range tasks {
+		err := s.Storage.DeleteAll(ctx, attachmentPrefix(task.ID))
+		if err != nil {
+			log.Err(err).Str("id", task.ID.String()).Msg("[deleteAttachmentFiles] error Storage.DeleteAll")
+		}
+	}
+}
+
+// resolveRecurrence resolves a recurrence, failing when it does not exist.
+func (s *TaskServiceImpl) resolveRecurrence(ctx context.Context, id uuid.UUID) (recurrence TaskRecurrence, err error) {
+	recurrence, exist, err := s.Recurrences.ResolveByID(ctx, id)
+	if err != nil {
+		log.Err(err).Msg("[resolveRecurrence] error RecurrenceRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		err = failure.NotFound(fmt.Sprintf("TaskRecurrenceID %s", id.String()))
+	}
+	return
+}
+
+// checkTemplate verifies that the occurrences of recurrence can become Tasks,
+// as far as can be told before they are due.
+func (s *TaskServiceImpl) checkTemplate(ctx context.Context, recurrence TaskRecurrence) (err error) {
+	requestFormat, err := recurrence.OccurrenceRequest(recurrence.StartsAt)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+	_, err = s.newTask(ctx, requestFormat)
+	return
+}
+
+// firstOccurrence returns the first occurrence of recurrence from now, in UTC,
+// failing when the recurrence has ended already.
+func (s *TaskServiceImpl) firstOccurrence(recurrence TaskRecurrence) (first time.Time, err error) {
+	first, ok, err := recurrence.FirstOccurrence(time.Now())
+	if err != nil {
+		return first, failure.BadRequest(err)
+	}
+	if !ok {
+		return first, failure.InvalidFields(map[string]string{"rrule": "the recurrence has no occurrence from now on"})
+	}
+	return first.UTC(), nil
+}
+
+// materialize makes the occurrence of recurrence at occurrenceAt a Task and
+// advances the recurrence past it. When the occurrence became a Task already,
+// or the recurrence was changed meanwhile, no Task is created; the returned
+// recurrence is then the one given.
+func (s *TaskServiceImpl) materialize(ctx context.Context, recurrence TaskRecurrence, occurrenceAt time.Time) (advanced TaskRecurrence, created bool, err error) {
+	advanced = recurrence
+	next, ok, err := recurrence.OccurrenceAfter(occurrenceAt)
+	if err != nil {
+		return
+	}
+
+	requestFormat, err := recurrence.OccurrenceRequest(occurrenceAt)
+	if err != nil {
+		return
+	}
+	task, err := s.newTask(ctx, requestFormat)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	occurrence := TaskOccurrence{
+		RecurrenceID: recurrence.ID,
+		OccurrenceAt: occurrenceAt.UTC(),
+		TaskID:       null.StringFrom(task.ID.String()),
+		CreatedAt:    now,
+	}
+	advanced.NextOccurrenceAt = null.NewTime(next.UTC(), ok)
+	advanced.LastTaskID = null.StringFrom(task.ID.String())
+	advanced.UpdatedAt = null.TimeFrom(now)
+
+	err = s.Recurrences.Materialize(ctx, advanced, occurrence, task)
+	if errors.Is(err, ErrOccurrenceMaterialized) {
+		// the Task was created by a previous attempt; only advance past it
+		advanced.LastTaskID = recurrence.LastTaskID
+		err = s.Recurrences.Update(ctx, advanced)
+	} else if err == nil {
+		// the Task is created along with the occurrence, past the cache
+		s.Invalidator.Invalidate(ctx, task.ID)
+		s.Searcher.Index(task)
+		created = true
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		return recurrence, false, nil
+	}
+	if err != nil {
+		return recurrence, false, err
+	}
+	advanced.Version++
+	return
+}
+
+// continueRecurrence makes the next occurrence of the recurrence generated on
+// completion, whose latest Task task is, a Task. Occurrences that passed while
+// task was open are skipped. Task is done already, so failures are logged and
+// not returned.
+func (s *TaskServiceImpl) continueRecurrence(ctx context.Context, task Task) {
+	recurrence, exist, err := s.Recurrences.ResolveByLastTaskID(ctx, task.ID)
+	if err != nil {
+		log.Err(err).Msg("[continueRecurrence] error RecurrenceRepository.ResolveByLastTaskID")
+		return
+	}
+	if !exist || recurrence.Generation != GenerationCompletion || !recurrence.NextOccurrenceAt.Valid {
+		return
+	}
+
+	occurrenceAt := recurrence.NextOccurrenceAt.Time
+	if now := time.Now(); occurrenceAt.Before(now) {
+		var ok bool
+		occurrenceAt, ok, err = recurrence.FirstOccurrence(now)
+		if err == nil && !ok {
+			recurrence.NextOccurrenceAt = null.Time{}
+			recurrence.UpdatedAt = null.TimeFrom(now)
+			err = s.Recurrences.Update(ctx, recurrence)
+		}
+		if err != nil || !ok {
+			if err != nil && !errors.Is(err, ErrVersionMismatch) {
+				log.Err(err).Str("id", recurrence.ID.String()).Msg("[continueRecurrence] error ending recurrence")
+			}
+			return
+		}
+	}
+
+	_, _, err = s.materialize(ctx, recurrence, occurrenceAt)
+	if err != nil {
+		log.Err(err).Str("id", recurrence.ID.String()).Msg("[continueRecurrence] error materialize")
+	}
+}
+
+// checkTeam verifies that teamID, when set, is a team.
+func (s *TaskServiceImpl) checkTeam(ctx context.Context, teamID null.Int) (err error) {
+	if !teamID.Valid {
+		return
+	}
+	exists, err := s.TaskRepository.TeamExists(ctx, teamID.Int64)
+	if err != nil {
+		log.Err(err).Msg("[checkTeam] error TaskRepository.TeamExists")
+		return
+	}
+	if !exists {
+		return failure.InvalidFields(map[string]string{"teamId": fmt.Sprintf("team %d does not exist", teamID.Int64)})
+	}
+	return
+}
+
+// checkParent verifies that the parent of task, when set, is a Task that is
+// not task itself nor below it, and that task with its subtasks nests no
+// deeper than the configured depth below it.
+func (s *TaskServiceImpl) checkParent(ctx context.Context, task Task) (err error) {
+	if !task.ParentID.Valid {
+		return
+	}
+	parentID, err := uuid.Parse(task.ParentID.String)
+	if err != nil {
+		log.Err(err).Msg("[checkParent] error parsing ParentID")
+		return failure.InternalError(err)
+	}
+	if parentID == task.ID {
+		return failure.InvalidFields(map[string]string{"parentId": "a Task cannot be its own parent"})
+	}
+
+	_, exist, err := s.TaskRepository.ResolveByID(ctx, parentID)
+	if err != nil {
+		log.Err(err).Msg("[checkParent] error TaskRepository.ResolveByID")
+		return
+	}
+	if !exist {
+		return failure.InvalidFields(map[string]string{"parentId": fmt.Sprintf("Task %s does not exist", parentID)})
+	}
+
+	subtree, err := s.TaskRepository.ResolveSubtree(ctx, task.ID)
+	if err != nil {
+		log.Err(err).Msg("[checkParent] error TaskRepository.ResolveSubtree")
+		return
+	}
+	height := 0
+	for _, node := range subtree {
+		if node.ID == parentID {
+			return failure.InvalidFields(map[string]string{"parentId": "a Task cannot be a subtask of its own subtasks"})
+		}
+		if node.Depth > height {
+			height = node.Depth
+		}
+	}
+
+	depth, err := s.TaskRepository.ResolveDepth(ctx, parentID)
+	if err != nil {
+		log.Err(err).Msg("[checkParent] error TaskRepository.ResolveDepth")
+		return
+	}
+	maxDepth := s.Config.Task.Subtasks.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxSubtaskDepth
+	}
+	if depth+1+height > maxDepth {
+		return failure.InvalidFields(map[string]string{
+			"parentId": fmt.Sprintf("subtasks nest at most %d levels below a top-level Task", maxDepth),
+		})
+	}
+	return
+}
+
+// resolveLabels resolves the labels with the given IDs, all of which must exist.
+func (s *TaskServiceImpl) resolveLabels(ctx context.Context, ids []string) (labels TaskLabels, err error) {
+	for i := range ids {
+		ids[i] = strings.ToLower(ids[i])
+	}
+	found, err := s.Labels.ResolveByIDs(ctx, ids)
+	if err != nil {
+		log.Err(err).Msg("[resolveLabels] error LabelRepository.ResolveByIDs")
+		return
+	}
+	labels = NewTaskLabels(found)
+
+	var missing []string
+	for _, id := range ids {
+		if !labels.Contains(id) && !contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		message := "labels " + strings.Join(missing, ", ") + " do not exist"
+		if len(missing) == 1 {
+			message = "label " + missing[0] + " does not exist"
+		}
+		return nil, failure.InvalidFields(map[string]string{"labels": message})
+	}
+	return
+}
+
+// checkUsers verifies that userIDs, given as field, are all users.
+func (s *TaskServiceImpl) checkUsers(ctx context.Context, field string, userIDs []int64) (err error) {
+	missing, err := s.TaskRepository.ResolveMissingUsers(ctx, userIDs)
+	if err != nil {
+		log.Err(err).Msg("[checkUsers] error TaskRepository.ResolveMissingUsers")
+		return
+	}
+	if len(missing) > 0 {
+		ids := make([]string, 0, len(missing))
+		for _, id := range missing {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		message := "users " + strings.Join(ids, ", ") + " do not exist"
+		if len(ids) == 1 {
+			message = "user " + ids[0] + " does not exist"
+		}
+		return failure.InvalidFields(map[string]string{field: message})
+	}
+	return
+}
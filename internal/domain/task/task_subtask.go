@@ -0,0 +1,74 @@
+package task
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultMaxSubtaskDepth is how deep subtasks nest when TASK.SUBTASKS.MAX_DEPTH
+	// is not configured.
+	defaultMaxSubtaskDepth = 3
+	// ParentNone filters top-level Tasks, which are no subtask of another.
+	ParentNone = "none"
+)
+
+// TaskNode is a Task within the subtree of another, at Depth levels below it.
+type TaskNode struct {
+	ID    uuid.UUID `db:"id"`
+	Depth int       `db:"depth"`
+}
+
+// ProgressCount is how many of the subtasks or checklist items of a Task are done.
+type ProgressCount struct {
+	Total int `json:"total"`
+	Done  int `json:"done"`
+}
+
+// TaskProgress is how far a Task is along, from its direct subtasks, done when
+// completed, and its checklist items. Percent weighs every subtask and item
+// alike.
+type TaskProgress struct {
+	Subtasks  ProgressCount `json:"subtasks"`
+	Checklist ProgressCount `json:"checklist"`
+	Percent   int           `json:"percent"`
+}
+
+// NewTaskProgress computes the progress of a Task, nil for Tasks without
+// subtasks or checklist items.
+func NewTaskProgress(subtasks ProgressCount, checklist ProgressCount) *TaskProgress {
+	total := subtasks.Total + checklist.Total
+	if total == 0 {
+		return nil
+	}
+	done := subtasks.Done + checklist.Done
+	return &TaskProgress{
+		Subtasks:  subtasks,
+		Checklist: checklist,
+		Percent:   int(math.Floor(float64(done) * 100 / float64(total))),
+	}
+}
+
+// progressQueryData counts the subtasks or the checklist items of a Task.
+type progressQueryData struct {
+	TaskID uuid.UUID `db:"task_id"`
+	Kind   string    `db:"kind"`
+	Total  int       `db:"total"`
+	Done   int       `db:"done"`
+}
+
+// Kinds of progressQueryData.
+const (
+	progressKindSubtasks  = "subtasks"
+	progressKindChecklist = "checklist"
+)
+
+// Conditions on the descendants of a Task walked by a cascading soft delete,
+// restore and permanent delete. A restore takes back the descendants deleted
+// along with their parent, which share its deletion time.
+const (
+	descendantsLive              = "deleted_at IS NULL"
+	descendantsDeletedWithParent = "deleted_at = PRIOR deleted_at"
+	descendantsDeleted           = "deleted_at IS NOT NULL"
+)
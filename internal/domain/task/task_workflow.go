@@ -0,0 +1,242 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+	"github.com/tarkiman/go/shared"
+	"github.com/tarkiman/go/shared/failure"
+)
+
+// anyStatus matches every status in the From of a Transition.
+const anyStatus = "*"
+
+// requirableFields are the Task fields a Transition can require, and how to
+// tell whether a Task has them.
+var requirableFields = map[string]func(t Task) bool{
+	"title":       func(t Task) bool { return strings.TrimSpace(t.Title) != "" },
+	"description": func(t Task) bool { return strings.TrimSpace(t.Description.String) != "" },
+	"dueAt":       func(t Task) bool { return t.DueAt.Valid },
+	"priority":    func(t Task) bool { return t.Priority.String != "" },
+}
+
+// Workflow defines the statuses of a Task and how it moves between them.
+type Workflow struct {
+	Statuses []string `json:"statuses"`
+	// Initial are the statuses a Task can be created in, the first being the
+	// default.
+	Initial []string `json:"initial"`
+	// Done are the statuses in which a Task is finished: it gets completed
+	// when entering one and is never overdue in one.
+	Done        []string     `json:"done"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// Transition allows Tasks in one of From to move To another status. The Task
+// must have the Requires fields, and when Roles are given, the user moving it
+// must hold one of them.
+type Transition struct {
+	From     []string `json:"from"`
+	To       string   `json:"to"`
+	Requires []string `json:"requires,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+}
+
+// DefaultWorkflow lets Tasks move freely between pending and completed.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []string{"pending", "completed"},
+		Initial:  []string{"pending", "completed"},
+		Done:     []string{"completed"},
+		Transitions: []Transition{
+			{From: []string{"pending"}, To: "completed"},
+			{From: []string{"completed"}, To: "pending"},
+		},
+	}
+}
+
+// ProvideWorkflow is the provider for the Workflow defined by the JSON file
+// at TASK.WORKFLOW.PATH, or the DefaultWorkflow when no file is configured.
+func ProvideWorkflow(config *configs.Config) *Workflow {
+	path := config.Task.Workflow.Path
+	if path == "" {
+		return DefaultWorkflow()
+	}
+
+	workflow, err := LoadWorkflow(path)
+	if err != nil {
+		log.Fatal().Err(err).Str("path", path).Msg("Failed loading task workflow")
+	}
+	log.Info().Str("path", path).Strs("statuses", workflow.Statuses).Msg("Loaded task workflow")
+	return workflow
+}
+
+// LoadWorkflow reads and validates a Workflow from a JSON file.
+func LoadWorkflow(path string) (workflow *Workflow, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	workflow = new(Workflow)
+	if err = json.Unmarshal(content, workflow); err != nil {
+		return nil, err
+	}
+	return workflow, workflow.Validate()
+}
+
+// Validate checks that the Workflow only refers to its own statuses and to
+// fields that can be required.
+func (w *Workflow) Validate() (err error) {
+	if len(w.Statuses) == 0 {
+		return errors.New("workflow has no statuses")
+	}
+	if len(w.Initial) == 0 {
+		return errors.New("workflow has no initial status")
+	}
+	known := make(map[string]bool)
+	for _, status := range w.Statuses {
+		if status == "" || status == anyStatus || known[status] {
+			return fmt.Errorf("workflow status %q is empty, reserved or repeated", status)
+		}
+		known[status] = true
+	}
+	for _, status := range w.Initial {
+		if !known[status] {
+			return fmt.Errorf("initial status %q is not a workflow status", status)
+		}
+	}
+	for _, status := range w.Done {
+		if !known[status] {
+			return fmt.Errorf("done status %q is not a workflow status", status)
+		}
+	}
+	for _, transition := range w.Transitions {
+		if !known[transition.To] {
+			return fmt.Errorf("transition to unknown status %q", transition.To)
+		}
+		for _, from := range transition.From {
+			if from != anyStatus && !known[from] {
+				return fmt.Errorf("transition from unknown status %q", from)
+			}
+		}
+		for _, field := range transition.Requires {
+			if requirableFields[field] == nil {
+				return fmt.Errorf("transition to %q requires unknown field %q", transition.To, field)
+			}
+		}
+	}
+	return
+}
+
+// IsDone tells whether a Task in status is finished.
+func (w *Workflow) IsDone(status string) bool {
+	return contains(w.Done, status)
+}
+
+// Next returns the statuses a Task in status can move to.
+func (w *Workflow) Next(status string) (next []string) {
+	next = []string{}
+	for _, transition := range w.Transitions {
+		if transition.appliesFrom(status) && !contains(next, transition.To) && transition.To != status {
+			next = append(next, transition.To)
+		}
+	}
+	return
+}
+
+// CheckCreate verifies task is created in an initial status, defaulting its
+// status to the first one.
+func (w *Workflow) CheckCreate(task *Task) (err error) {
+	if task.Status.String == "" {
+		task.Status.SetValid(w.Initial[0])
+		return
+	}
+	if !contains(w.Initial, task.Status.String) {
+		return failure.InvalidFields(map[string]string{
+			"status": "must be one of " + strings.Join(w.Initial, " ") + " for a new Task",
+		})
+	}
+	return
+}
+
+// CheckTransition verifies that task may move from status from to its
+// current status, as the user acting in ctx. Staying in the same status is
+// no transition and always allowed.
+func (w *Workflow) CheckTransition(ctx context.Context, from string, task Task) (err error) {
+	to := task.Status.String
+	if !contains(w.Statuses, to) {
+		return failure.InvalidFields(map[string]string{
+			"status": "must be one of " + strings.Join(w.Statuses, " "),
+		})
+	}
+	if from == to {
+		return
+	}
+	// Tasks left in a status the workflow no longer has may start over
+	if !contains(w.Statuses, from) && contains(w.Initial, to) {
+		return
+	}
+
+	var transitions []Transition
+	for _, transition := range w.Transitions {
+		if transition.appliesFrom(from) && transition.To == to {
+			transitions = append(transitions, transition)
+		}
+	}
+	if len(transitions) == 0 {
+		return failure.ConflictWithData("update", "Task",
+			fmt.Sprintf("cannot move from %s to %s", from, to),
+			map[string]interface{}{"status": from, "allowed": w.Next(from)})
+	}
+
+	// any transition whose guards pass allows the move; report the first one
+	// otherwise
+	roles := shared.RolesFromContext(ctx)
+	for _, transition := range transitions {
+		if err = transition.check(task, roles); err == nil {
+			return
+		}
+	}
+	return transitions[0].check(task, roles)
+}
+
+func (t Transition) appliesFrom(status string) bool {
+	return contains(t.From, anyStatus) || contains(t.From, status)
+}
+
+func (t Transition) check(task Task, roles []string) (err error) {
+	missing := make(map[string]string)
+	for _, field := range t.Requires {
+		if !requirableFields[field](task) {
+			missing[field] = "is required to move to " + t.To
+		}
+	}
+	if len(missing) > 0 {
+		return failure.InvalidFields(missing)
+	}
+
+	if len(t.Roles) == 0 {
+		return
+	}
+	for _, role := range roles {
+		if contains(t.Roles, role) {
+			return
+		}
+	}
+	return failure.Forbidden(fmt.Sprintf("moving a Task to %s requires one of the roles %s", t.To, strings.Join(t.Roles, ", ")))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
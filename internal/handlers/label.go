@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/internal/domain/task"
+	"github.com/tarkiman/go/shared"
+	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/transport/http/response"
+)
+
+// LabelHandler is the HTTP handler for the Labels of Tasks.
+type LabelHandler struct {
+	LabelService task.LabelService
+}
+
+// ProvideLabelHandler is the provider for this handler.
+func ProvideLabelHandler(labelService task.LabelService) LabelHandler {
+	return LabelHandler{
+		LabelService: labelService,
+	}
+}
+
+// Router sets up the router for this domain.
+func (h *LabelHandler) Router(r chi.Router) {
+	r.Route("/labels", func(r chi.Router) {
+		r.Post("/", h.CreateLabel)
+		r.Get("/", h.ResolveLabels)
+		r.Get("/{id}", h.ResolveLabelByID)
+		r.Put("/{id}", h.UpdateLabel)
+		r.Delete("/{id}", h.DeleteLabel)
+	})
+}
+
+// CreateLabel creates a new Label.
+// @Summary Create a new Label.
+// @Description This endpoint creates a new Label for Tasks. Names are unique
+// @Description regardless of case; colors are hex colors such as #1f77b4.
+// @Tags Label
+// @Security OauthToken
+// @Param Label body task.LabelRequestFormat true "The Label to be created."
+// @Produce json
+// @Success 201 {object} response.Base{data=task.LabelResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/labels [post]
+func (h *LabelHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	requestFormat, err := decodeLabelRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.LabelService.Create(r.Context(), requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, resp)
+}
+
+// ResolveLabels lists all Labels.
+// @Summary List Labels
+// @Description This endpoint lists all Labels by name.
+// @Tags Label
+// @Security OauthToken
+// @Produce json
+// @Success 200 {object} response.Base{data=task.LabelListResponseFormat}
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/labels [get]
+func (h *LabelHandler) ResolveLabels(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.LabelService.ResolveAll(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// ResolveLabelByID resolves a Label by its ID.
+// @Summary Resolve Label by ID
+// @Description This endpoint resolves a Label by its ID.
+// @Tags Label
+// @Security OauthToken
+// @Param id path string true "The Label's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.LabelResponse}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/labels/{id} [get]
+func (h *LabelHandler) ResolveLabelByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	label, err := h.LabelService.ResolveByID(r.Context(), id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, label.ToResponseFormat())
+}
+
+// UpdateLabel updates a Label.
+// @Summary Update a Label.
+// @Description This endpoint updates an existing Label. The Tasks carrying it show
+// @Description the change from then on; their past versions keep the Label as it was.
+// @Tags Label
+// @Security OauthToken
+// @Param id path string true "The Label's identifier."
+// @Param Label body task.LabelRequestFormat true "The Label to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.LabelResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/labels/{id} [put]
+func (h *LabelHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	requestFormat, err := decodeLabelRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.LabelService.Update(r.Context(), id, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// DeleteLabel deletes a Label.
+// @Summary Delete a Label.
+// @Description This endpoint deletes a Label and takes it off the Tasks carrying it.
+// @Tags Label
+// @Security OauthToken
+// @Param id path string true "The Label's identifier."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.LabelService.Delete(r.Context(), id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// decodeLabelRequest reads and validates a LabelRequestFormat from the body.
+func decodeLabelRequest(r *http.Request) (requestFormat task.LabelRequestFormat, err error) {
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+	return
+}
@@ -2,14 +2,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/tarkiman/go/internal/domain/task"
 	"github.com/tarkiman/go/shared"
 	"github.com/tarkiman/go/shared/failure"
+	"github.com/tarkiman/go/shared/search"
 	"github.com/tarkiman/go/transport/http/middleware"
 	"github.com/tarkiman/go/transport/http/response"
 )
@@ -33,18 +41,62 @@ func (h *TaskHandler) Router(r chi.Router) {
 	r.Route("/tasks", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
 			// r.Use(h.AuthMiddleware.ClientCredential)
-			r.Post("/", h.CreateTask)
-			r.Get("/", h.ResolveTaskByFilter)
+			// writes are audited as the user of an access token when there is one
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/", h.CreateTask)
+			// "assignee=me" may refer to the user of an access token
+			r.With(h.AuthMiddleware.OptionalClientCredential).Get("/", h.ResolveTaskByFilter)
+			r.Get("/search", h.SearchTasks)
+			r.Get("/plan", h.PlanTasks)
+			r.Get("/recurrences", h.ResolveTaskRecurrences)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/recurrences", h.CreateTaskRecurrence)
+			r.Get("/recurrences/{recurrenceId}", h.ResolveTaskRecurrence)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Put("/recurrences/{recurrenceId}", h.UpdateTaskRecurrence)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/recurrences/{recurrenceId}", h.DeleteTaskRecurrence)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Get("/overdue", h.ResolveOverdueTasks)
 			r.Get("/{id}", h.ResolveTaskByID)
-			r.Put("/{id}", h.UpdateTask)
-			r.Delete("/{id}", h.SoftDeleteTask)
+			// status transitions may be guarded by the roles of the user
+			r.With(h.AuthMiddleware.OptionalClientCredential).Put("/{id}", h.UpdateTask)
+			// the trash names the user of an access token as who deleted a Task
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/{id}", h.SoftDeleteTask)
+			r.Get("/trash", h.ResolveTaskTrash)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/{id}/restore", h.RestoreTask)
+			r.Get("/{id}/history", h.ResolveTaskHistory)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/{id}/assignees", h.AssignTask)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/{id}/assignees/{userId}", h.UnassignTask)
+			r.Get("/{id}/checklist", h.ResolveTaskChecklist)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/{id}/checklist", h.AddTaskChecklistItem)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Put("/{id}/checklist/{itemId}", h.UpdateTaskChecklistItem)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/{id}/checklist/{itemId}", h.DeleteTaskChecklistItem)
+			r.Get("/{id}/comments", h.ResolveTaskComments)
+			r.Get("/{id}/comments/{commentId}/edits", h.ResolveTaskCommentEdits)
+			// comments are written, and only changed, by the user of an access
+			// token when there is one
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/{id}/comments", h.AddTaskComment)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Put("/{id}/comments/{commentId}", h.UpdateTaskComment)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/{id}/comments/{commentId}", h.DeleteTaskComment)
+			r.Get("/{id}/attachments", h.ResolveTaskAttachments)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/{id}/attachments", h.AddTaskAttachment)
+			r.Get("/{id}/attachments/{attachmentId}/content", h.DownloadTaskAttachment)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/{id}/attachments/{attachmentId}", h.DeleteTaskAttachment)
+			r.Get("/{id}/dependencies", h.ResolveTaskDependencies)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Post("/{id}/blockers", h.AddTaskBlocker)
+			r.With(h.AuthMiddleware.OptionalClientCredential).Delete("/{id}/blockers/{blockerId}", h.RemoveTaskBlocker)
+		})
+		r.Group(func(r chi.Router) {
+			// permanent deletes are only allowed to clients whose role holds
+			// the permission for this endpoint, i.e. admins
+			r.Use(h.AuthMiddleware.ClientCredential)
+			r.Delete("/{id}/permanent", h.HardDeleteTask)
 		})
 	})
 }
 
 // CreateTask creates a new Task.
 // @Summary Create a new Task.
-// @Description This endpoint creates a new Task.
+// @Description This endpoint creates a new Task. Its status must be one the workflow
+// @Description allows new Tasks to have, and defaults to the first of them. The team,
+// @Description the assignees and the labels, when given, must exist. A parentId makes
+// @Description the Task a subtask, nested no deeper than the configured depth.
 // @Tags Task
 // @Security OauthToken
 // @Param Task body task.TaskRequestFormat true "The Task to be created."
@@ -53,6 +105,7 @@ func (h *TaskHandler) Router(r chi.Router) {
 // @Failure 400 {object} response.Base
 // @Failure 409 {object} response.Base
 // @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
 // @Router /v1/tasks [post]
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
@@ -76,7 +129,7 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 	// requestFormat.CreatedBy = createdBy
-	resp, err := h.TaskService.Create(requestFormat)
+	resp, err := h.TaskService.Create(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
@@ -87,26 +140,46 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 // ResolveTaskByFilter resolves a Task by filter.
 // @Summary Resolve Task by filter
-// @Description This endpoint resolves a Task by filter.
+// @Description This endpoint resolves Tasks matching the filter given in the query
+// @Description string. Invalid parameters are reported together in the data of a 400
+// @Description response, keyed by parameter name.
+// @Description Pages can be walked by page number or, more efficiently, by following the
+// @Description next and prev cursors, which are also given as links and in the Link header.
 // @Tags Task
-// @Param TaskFilter body task.TaskFilter true "The filter of task to be searched"
+// @Param keyword query string false "Part of the title or description to search for, in any case."
+// @Param status query string false "The status the Tasks must have."
+// @Param priority query string false "The priority the Tasks must have: low, medium, high or urgent."
+// @Param dueAfter query string false "Keeps Tasks due at or after this RFC 3339 time."
+// @Param dueBefore query string false "Keeps Tasks due before this RFC 3339 time."
+// @Param overdue query bool false "Keeps only Tasks past their due date and not completed."
+// @Param assignee query string false "Keeps Tasks assigned to this user ID, or to the requesting user with me."
+// @Param team query int false "Keeps Tasks owned by this team ID."
+// @Param labels query string false "Keeps Tasks carrying these comma separated label IDs."
+// @Param labelMatch query string false "Whether Tasks must carry any or all of the labels; defaults to any."
+// @Param parent query string false "Keeps the subtasks of this Task ID, or top-level Tasks with none."
+// @Param q query string false "A filter expression, e.g. status:pending,completed created>2026-01-01 (createdBy:7 OR has:description)."
+// @Param sort query string false "The field to sort by: title, status, created_at, due_at, priority or completed_at."
+// @Param order query string false "The sort order: ASC or DESC."
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of Tasks per page."
+// @Param asOf query string false "Lists the Tasks as they were at this RFC 3339 time."
+// @Param cursor query string false "A next or prev cursor from a previous page; replaces page."
+// @Param count query bool false "Whether to count all matching Tasks; defaults to true without a cursor."
 // @Produce json
 // @Success 200 {object} response.Base{data=task.TaskFilterResponseFormat}
 // @Failure 400 {object} response.Base
 // @Failure 404 {object} response.Base
 // @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
 // @Router /v1/tasks  [get]
 func (h *TaskHandler) ResolveTaskByFilter(w http.ResponseWriter, r *http.Request) {
-	var taskFilter task.TaskFilter
-
-	// err := json.NewDecoder(r.Body).Decode(&taskFilter)
-	// if err != nil {
-	// 	response.WithError(w, failure.BadRequest(err))
-	// 	log.Info().Msg(err.Error())
-	// 	return
-	// }
+	taskFilter, err := parseTaskFilter(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
-	tasks, err := h.TaskService.ResolveByFilter(taskFilter)
+	tasks, err := h.TaskService.ResolveByFilter(r.Context(), taskFilter)
 	if err != nil {
 		if err.Error() == task.EmptyFilterError {
 			err = failure.BadRequest(err)
@@ -115,20 +188,130 @@ func (h *TaskHandler) ResolveTaskByFilter(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	tasks.Links.Next = cursorLink(r, tasks.Cursors.Next)
+	tasks.Links.Prev = cursorLink(r, tasks.Cursors.Prev)
+	var links []string
+	if tasks.Links.Next != "" {
+		links = append(links, `<`+tasks.Links.Next+`>; rel="next"`)
+	}
+	if tasks.Links.Prev != "" {
+		links = append(links, `<`+tasks.Links.Prev+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	response.WithJSON(w, http.StatusOK, tasks)
+}
+
+// ResolveOverdueTasks resolves the Tasks past their due date that are not completed.
+// @Summary Resolve overdue Tasks
+// @Description This endpoint resolves the Tasks past their due date that are not
+// @Description completed, the longest overdue first unless sorted otherwise. It takes
+// @Description the same parameters as the Task list.
+// @Tags Task
+// @Param priority query string false "The priority the Tasks must have: low, medium, high or urgent."
+// @Param assignee query string false "Keeps Tasks assigned to this user ID, or to the requesting user with me."
+// @Param team query int false "Keeps Tasks owned by this team ID."
+// @Param labels query string false "Keeps Tasks carrying these comma separated label IDs."
+// @Param labelMatch query string false "Whether Tasks must carry any or all of the labels; defaults to any."
+// @Param parent query string false "Keeps the subtasks of this Task ID, or top-level Tasks with none."
+// @Param sort query string false "The field to sort by; defaults to due_at."
+// @Param order query string false "The sort order; defaults to ASC when sorting by due_at."
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of Tasks per page."
+// @Param cursor query string false "A next or prev cursor from a previous page; replaces page."
+// @Param count query bool false "Whether to count all matching Tasks; defaults to true without a cursor."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskFilterResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/overdue [get]
+func (h *TaskHandler) ResolveOverdueTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("sort") == "" {
+		query.Set("sort", "due_at")
+		if query.Get("order") == "" {
+			query.Set("order", "ASC")
+		}
+	}
+	query.Set("overdue", "true")
+	// links to further pages carry the defaults, which their cursors were made for
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = query.Encode()
+
+	h.ResolveTaskByFilter(w, r)
+}
+
+// SearchTasks searches Tasks by the words of their title and description.
+// @Summary Search Tasks
+// @Description This endpoint resolves the Tasks whose title or description contain all
+// @Description words of q, in English or Indonesian, most relevant first. Words match
+// @Description other forms of the same word, and a word ending in * or the last word
+// @Description matches every word starting with it. Highlights mark the matched words.
+// @Tags Task
+// @Param q query string true "The words to search for."
+// @Param highlight query bool false "Whether to return highlights; defaults to true."
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of Tasks per page."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskSearchResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/search [get]
+func (h *TaskHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	pagination, err := queryPagination(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	invalid := make(map[string]string)
+	filter := task.TaskSearchFilter{Pagination: pagination, Query: strings.TrimSpace(query.Get("q")), Highlight: true}
+	parsed, err := search.ParseQuery(query.Get("q"))
+	if err != nil {
+		invalid["q"] = err.Error()
+	}
+	filter.Parsed = parsed
+	if v := query.Get("highlight"); v != "" {
+		filter.Highlight, err = strconv.ParseBool(v)
+		if err != nil {
+			invalid["highlight"] = "must be true or false"
+		}
+	}
+	if len(invalid) > 0 {
+		response.WithError(w, failure.InvalidFields(invalid))
+		return
+	}
+
+	tasks, err := h.TaskService.Search(r.Context(), filter)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
 	response.WithJSON(w, http.StatusOK, tasks)
 }
 
 // ResolveTaskByID resolves a Task by its ID.
 // @Summary Resolve Task by ID
-// @Description This endpoint resolves a Task by its ID.
+// @Description This endpoint resolves a Task by its ID. The ETag response header
+// @Description carries the Task's version, to be sent back as If-Match on updates.
+// @Description Its progress is computed from its subtasks and checklist items.
+// @Description With asOf, the Task is resolved as it was at that time, without progress.
 // @Tags Task
 // @Security OauthToken
 // @Param id path string true "The Task's identifier."
+// @Param asOf query string false "An RFC 3339 time to resolve the Task at."
 // @Produce json
 // @Success 200 {object} response.Base{data=task.TaskResponseFormat}
 // @Failure 400 {object} response.Base
 // @Failure 404 {object} response.Base
 // @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
 // @Router /v1/task/{id} [get]
 func (h *TaskHandler) ResolveTaskByID(w http.ResponseWriter, r *http.Request) {
 	idString := chi.URLParam(r, "id")
@@ -140,27 +323,51 @@ func (h *TaskHandler) ResolveTaskByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.TaskService.ResolveByID(id)
+	asOf, err := queryAsOf(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	var task task.Task
+	if asOf != nil {
+		task, err = h.TaskService.ResolveByIDAsOf(r.Context(), id, *asOf)
+	} else {
+		task, err = h.TaskService.ResolveByID(r.Context(), id)
+	}
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(task.Version))
 	response.WithJSON(w, http.StatusOK, task)
 }
 
 // UpdateTask updates a Task.
 // @Summary Update a Task.
-// @Description This endpoint updates an existing Task.
+// @Description This endpoint updates an existing Task. The expected version is
+// @Description given by the If-Match header or the version field of the body.
+// @Description A change of status must be allowed by the workflow; an illegal one is
+// @Description answered with a 409 whose data lists the allowed next statuses. Guarded
+// @Description transitions need the access token of a user holding one of their roles.
+// @Description A Task cannot move to a done status while blocked by open Tasks, which
+// @Description the data of the 409 lists.
 // @Tags Task
 // @Security OauthToken
 // @Param id path string true "The Task's identifier."
+// @Param If-Match header string false "The ETag of the Task being updated."
 // @Param task body task.TaskRequestFormat true "The Task to be updated."
 // @Produce json
 // @Success 200 {object} task.TaskResponseFormat
 // @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 403 {object} response.Base
 // @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
 // @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
 // @Router /v1/tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	idString := chi.URLParam(r, "id")
@@ -194,27 +401,43 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	// }
 	// requestFormat.UpdatedBy = updatedBy
 
-	resp, err := h.TaskService.Update(id, requestFormat)
+	precondition, err := parsePrecondition(r, requestFormat.Version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.Update(r.Context(), id, requestFormat, precondition)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	if updated, ok := resp.Task.(task.TaskResponse); ok {
+		w.Header().Set("ETag", etag(updated.Version))
+	}
 	response.WithJSON(w, http.StatusOK, resp)
 }
 
 // SoftDeleteTask marks a Task as deleted.
 // @Summary Marks a Task as deleted.
 // @Description This endpoint marks an existing Task as deleted. This is done by
-// @Description set values of "deletedAt" and "deletedBy" properties of the Task.
+// @Description set values of "deletedAt" and "deletedBy" properties of the Task, and
+// @Description of its subtasks that are not deleted yet.
+// @Description The expected version is given by the If-Match header or the version query parameter.
 // @Tags Task
 // @Security OauthToken
 // @Param id path string true "The Task's identifier."
+// @Param If-Match header string false "The ETag of the Task being deleted."
+// @Param version query int false "The version of the Task being deleted."
 // @Produce json
 // @Success 200 {object} task.TaskResponse
 // @Failure 400 {object} response.Base
 // @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
 // @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
 // @Router /v1/tasks/{id} [delete]
 func (h *TaskHandler) SoftDeleteTask(w http.ResponseWriter, r *http.Request) {
 	idString := chi.URLParam(r, "id")
@@ -225,8 +448,197 @@ func (h *TaskHandler) SoftDeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deletedBy := r.Header.Get("x-userid")
-	resp, err := h.TaskService.SoftDelete(id, deletedBy)
+	version, err := queryVersion(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	precondition, err := parsePrecondition(r, version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	deletedBy := shared.ActorFromContext(r.Context())
+	resp, err := h.TaskService.SoftDelete(r.Context(), id, deletedBy, precondition)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// ResolveTaskTrash lists soft deleted Tasks.
+// @Summary List deleted Tasks
+// @Description This endpoint lists soft deleted Tasks, most recently deleted
+// @Description first, with who deleted them and when.
+// @Tags Task
+// @Security OauthToken
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of Tasks per page."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskTrashResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/trash [get]
+func (h *TaskHandler) ResolveTaskTrash(w http.ResponseWriter, r *http.Request) {
+	pagination, err := queryPagination(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	tasks, err := h.TaskService.ResolveTrash(r.Context(), pagination)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, tasks)
+}
+
+// ResolveTaskHistory resolves the recorded changes of a Task.
+// @Summary Resolve Task history
+// @Description This endpoint resolves the recorded changes of a Task, most recent
+// @Description first. Each entry lists the changed fields with their old and new
+// @Description values, who made the change and the ID of the request.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of entries per page."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskHistoryResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/history [get]
+func (h *TaskHandler) ResolveTaskHistory(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	pagination, err := queryPagination(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	history, err := h.TaskService.ResolveHistory(r.Context(), id, pagination)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, history)
+}
+
+// RestoreTask takes a soft deleted Task out of the trash.
+// @Summary Restore a deleted Task.
+// @Description This endpoint restores a soft deleted Task by clearing its "deletedAt"
+// @Description and "deletedBy" properties, along with the subtasks deleted with it. A
+// @Description subtask whose parent is deleted cannot be restored on its own. The
+// @Description expected version is given by the If-Match header or the version query parameter.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param If-Match header string false "The ETag of the Task being restored."
+// @Param version query int false "The version of the Task being restored."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/restore [post]
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	version, err := queryVersion(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	precondition, err := parsePrecondition(r, version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.Restore(r.Context(), id, precondition)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	if restored, ok := resp.Task.(task.TaskResponse); ok {
+		w.Header().Set("ETag", etag(restored.Version))
+	}
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// HardDeleteTask permanently deletes a Task from the trash.
+// @Summary Permanently delete a Task.
+// @Description This endpoint permanently deletes a soft deleted Task and its
+// @Description subtasks. It is restricted to clients whose role holds the permission for this
+// @Description endpoint. The expected version is given by the If-Match header or
+// @Description the version query parameter.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param If-Match header string false "The ETag of the Task being deleted."
+// @Param version query int false "The version of the Task being deleted."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/permanent [delete]
+func (h *TaskHandler) HardDeleteTask(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	version, err := queryVersion(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	precondition, err := parsePrecondition(r, version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.HardDelete(r.Context(), id, precondition)
 	if err != nil {
 		response.WithError(w, err)
 		return
@@ -234,3 +646,1288 @@ func (h *TaskHandler) SoftDeleteTask(w http.ResponseWriter, r *http.Request) {
 
 	response.WithMessage(w, http.StatusOK, resp.Message)
 }
+
+// AssignTask assigns a Task to users.
+// @Summary Assign a Task to users.
+// @Description This endpoint assigns a Task to more users; users it is assigned to
+// @Description already stay so. The users must exist. The expected version is given by
+// @Description the If-Match header or the version field of the body.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param If-Match header string false "The ETag of the Task being assigned."
+// @Param assignees body task.TaskAssigneesRequestFormat true "The users to assign the Task to."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/assignees [post]
+func (h *TaskHandler) AssignTask(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat task.TaskAssigneesRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	precondition, err := parsePrecondition(r, requestFormat.Version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.Assign(r.Context(), id, requestFormat.UserIDs, precondition)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	if assigned, ok := resp.Task.(task.TaskResponse); ok {
+		w.Header().Set("ETag", etag(assigned.Version))
+	}
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// UnassignTask unassigns a user from a Task.
+// @Summary Unassign a user from a Task.
+// @Description This endpoint unassigns a user from a Task. The expected version is
+// @Description given by the If-Match header or the version query parameter.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param userId path int true "The user to unassign."
+// @Param If-Match header string false "The ETag of the Task being unassigned."
+// @Param version query int false "The version of the Task being unassigned."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/assignees/{userId} [delete]
+func (h *TaskHandler) UnassignTask(w http.ResponseWriter, r *http.Request) {
+	idString := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		response.WithError(w, failure.BadRequestFromString("userId must be a number"))
+		return
+	}
+
+	version, err := queryVersion(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	precondition, err := parsePrecondition(r, version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.Unassign(r.Context(), id, userID, precondition)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	if unassigned, ok := resp.Task.(task.TaskResponse); ok {
+		w.Header().Set("ETag", etag(unassigned.Version))
+	}
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// ResolveTaskChecklist lists the checklist items of a Task.
+// @Summary List the checklist of a Task
+// @Description This endpoint lists the checklist items of a Task by position.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.ChecklistResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/checklist [get]
+func (h *TaskHandler) ResolveTaskChecklist(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.ResolveChecklist(r.Context(), id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// AddTaskChecklistItem adds an item to the checklist of a Task.
+// @Summary Add a checklist item to a Task.
+// @Description This endpoint adds an item to the checklist of a Task, last unless a
+// @Description position is given. Checklist items count towards the progress of the
+// @Description Task but do not change its version.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param item body task.ChecklistItemRequestFormat true "The checklist item to be added."
+// @Produce json
+// @Success 201 {object} response.Base{data=task.ChecklistItemResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/checklist [post]
+func (h *TaskHandler) AddTaskChecklistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	requestFormat, err := decodeChecklistItemRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.AddChecklistItem(r.Context(), id, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, resp)
+}
+
+// UpdateTaskChecklistItem updates a checklist item of a Task.
+// @Summary Update a checklist item of a Task.
+// @Description This endpoint updates a checklist item of a Task, checking it off or
+// @Description clearing it with done.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param itemId path string true "The checklist item's identifier."
+// @Param item body task.ChecklistItemRequestFormat true "The checklist item to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.ChecklistItemResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/checklist/{itemId} [put]
+func (h *TaskHandler) UpdateTaskChecklistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	requestFormat, err := decodeChecklistItemRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.UpdateChecklistItem(r.Context(), id, itemID, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// DeleteTaskChecklistItem deletes a checklist item of a Task.
+// @Summary Delete a checklist item of a Task.
+// @Description This endpoint deletes a checklist item of a Task.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param itemId path string true "The checklist item's identifier."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/checklist/{itemId} [delete]
+func (h *TaskHandler) DeleteTaskChecklistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.DeleteChecklistItem(r.Context(), id, itemID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// ResolveTaskComments lists the comments on a Task.
+// @Summary List the comments on a Task
+// @Description This endpoint lists the comments on a Task that are not deleted, oldest
+// @Description first.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of comments per page."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskCommentListResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/comments [get]
+func (h *TaskHandler) ResolveTaskComments(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	pagination, err := queryPagination(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.ResolveComments(r.Context(), id, pagination)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// AddTaskComment adds a comment to a Task.
+// @Summary Comment on a Task.
+// @Description This endpoint adds a comment to a Task, written by the user making the
+// @Description request. Every known user mentioned with "@username" is notified.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param comment body task.TaskCommentRequestFormat true "The comment to be added."
+// @Produce json
+// @Success 201 {object} response.Base{data=task.TaskCommentResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/comments [post]
+func (h *TaskHandler) AddTaskComment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	requestFormat, err := decodeCommentRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.AddComment(r.Context(), id, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, resp)
+}
+
+// UpdateTaskComment edits a comment on a Task.
+// @Summary Edit a comment on a Task.
+// @Description This endpoint edits a comment on a Task, keeping its previous body in
+// @Description its edit history. Only its author may edit it, and only the users it
+// @Description newly mentions are notified.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param commentId path string true "The comment's identifier."
+// @Param comment body task.TaskCommentRequestFormat true "The edited comment."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskCommentResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/comments/{commentId} [put]
+func (h *TaskHandler) UpdateTaskComment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	requestFormat, err := decodeCommentRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.UpdateComment(r.Context(), id, commentID, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// DeleteTaskComment soft deletes a comment on a Task.
+// @Summary Delete a comment on a Task.
+// @Description This endpoint soft deletes a comment on a Task. Only its author may
+// @Description delete it.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param commentId path string true "The comment's identifier."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/comments/{commentId} [delete]
+func (h *TaskHandler) DeleteTaskComment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.DeleteComment(r.Context(), id, commentID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// ResolveTaskCommentEdits resolves the edit history of a comment on a Task.
+// @Summary Resolve the edits of a comment on a Task
+// @Description This endpoint resolves a comment on a Task with the bodies it had before
+// @Description each of its edits, most recent first.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param commentId path string true "The comment's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskCommentEditsResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/comments/{commentId}/edits [get]
+func (h *TaskHandler) ResolveTaskCommentEdits(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	commentID, err := uuid.Parse(chi.URLParam(r, "commentId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.ResolveCommentEdits(r.Context(), id, commentID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// ResolveTaskAttachments lists the files attached to a Task.
+// @Summary List the attachments of a Task
+// @Description This endpoint lists the files attached to a Task, oldest first.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskAttachmentListResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/attachments [get]
+func (h *TaskHandler) ResolveTaskAttachments(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.ResolveAttachments(r.Context(), id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// AddTaskAttachment uploads a file and attaches it to a Task.
+// @Summary Attach a file to a Task.
+// @Description This endpoint uploads a file as the "file" part of a multipart form and
+// @Description attaches it to a Task. Its content type is detected from its content, and
+// @Description its size is limited by the upload configuration.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param file formData file true "The file to be attached."
+// @Accept multipart/form-data
+// @Produce json
+// @Success 201 {object} response.Base{data=task.TaskAttachmentResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 413 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/attachments [post]
+func (h *TaskHandler) AddTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	// the file is streamed to storage rather than buffered by ParseMultipartForm
+	reader, err := r.MultipartReader()
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			response.WithError(w, failure.BadRequestFromString("the file part is missing"))
+			return
+		}
+		if err != nil {
+			response.WithError(w, failure.BadRequest(err))
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		resp, err := h.TaskService.AddAttachment(r.Context(), id, part.FileName(), part)
+		if err != nil {
+			response.WithError(w, err)
+			return
+		}
+
+		response.WithJSON(w, http.StatusCreated, resp)
+		return
+	}
+}
+
+// DownloadTaskAttachment streams the content of a file attached to a Task.
+// @Summary Download an attachment of a Task
+// @Description This endpoint streams the content of a file attached to a Task. Range
+// @Description requests are supported, for downloads to be resumed.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param attachmentId path string true "The attachment's identifier."
+// @Param Range header string false "The byte ranges to download."
+// @Produce octet-stream
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 416 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/tasks/{id}/attachments/{attachmentId}/content [get]
+func (h *TaskHandler) DownloadTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	attachment, content, err := h.TaskService.OpenAttachment(r.Context(), id, attachmentID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	defer content.Close()
+
+	// attachments are always downloaded, never rendered by the browser
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// the content of an attachment never changes
+	w.Header().Set("ETag", strconv.Quote(attachment.ID.String()))
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, content)
+}
+
+// DeleteTaskAttachment deletes a file attached to a Task.
+// @Summary Delete an attachment of a Task.
+// @Description This endpoint detaches a file from a Task and deletes it.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param attachmentId path string true "The attachment's identifier."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/attachments/{attachmentId} [delete]
+func (h *TaskHandler) DeleteTaskAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "attachmentId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.DeleteAttachment(r.Context(), id, attachmentID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// ResolveTaskDependencies resolves the dependency graph of a Task.
+// @Summary Resolve the dependency graph of a Task
+// @Description This endpoint resolves the Tasks a Task waits for, upstream, and the
+// @Description Tasks waiting for it, downstream, directly or not, with the dependencies
+// @Description between them. Deleted Tasks are left out.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskDependencyGraphResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/dependencies [get]
+func (h *TaskHandler) ResolveTaskDependencies(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.ResolveDependencies(r.Context(), id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// AddTaskBlocker makes a Task wait for another one.
+// @Summary Add a blocker to a Task.
+// @Description This endpoint makes a Task blocked by another one until that one is
+// @Description completed. A blocker that waits, directly or not, for the Task is
+// @Description refused with a 409, as it would form a cycle.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param blocker body task.TaskDependencyRequestFormat true "The Task blocking it."
+// @Produce json
+// @Success 201 {object} response.Base{data=task.TaskDependencyResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/blockers [post]
+func (h *TaskHandler) AddTaskBlocker(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	var requestFormat task.TaskDependencyRequestFormat
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	blockerID, err := uuid.Parse(requestFormat.BlockerID)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	resp, err := h.TaskService.AddBlocker(r.Context(), id, blockerID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, resp)
+}
+
+// RemoveTaskBlocker stops a Task from waiting for another one.
+// @Summary Remove a blocker from a Task.
+// @Description This endpoint stops a Task from being blocked by another one.
+// @Tags Task
+// @Security OauthToken
+// @Param id path string true "The Task's identifier."
+// @Param blockerId path string true "The blocking Task's identifier."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/{id}/blockers/{blockerId} [delete]
+func (h *TaskHandler) RemoveTaskBlocker(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	blockerID, err := uuid.Parse(chi.URLParam(r, "blockerId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.RemoveBlocker(r.Context(), id, blockerID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// PlanTasks orders Tasks by their dependencies.
+// @Summary Plan Tasks
+// @Description This endpoint orders the given Tasks so that every Task comes after the
+// @Description Tasks it waits for, directly or through other Tasks. Tasks of the same
+// @Description stage do not wait for one another and keep the order they were given in.
+// @Tags Task
+// @Security OauthToken
+// @Param ids query string true "The comma separated IDs of the Tasks to plan."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskPlanResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/plan [get]
+func (h *TaskHandler) PlanTasks(w http.ResponseWriter, r *http.Request) {
+	var ids []uuid.UUID
+	for _, v := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.WithError(w, failure.InvalidFields(map[string]string{"ids": "must be comma separated Task IDs"}))
+			return
+		}
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > maxPlanTasks {
+		response.WithError(w, failure.InvalidFields(map[string]string{
+			"ids": "must list from 1 to " + strconv.Itoa(maxPlanTasks) + " Task IDs",
+		}))
+		return
+	}
+
+	resp, err := h.TaskService.Plan(r.Context(), ids)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// ResolveTaskRecurrences lists the recurring Tasks.
+// @Summary List recurring Tasks
+// @Description This endpoint lists the recurring Tasks, most recently created first,
+// @Description each with its next few occurrences.
+// @Tags Task
+// @Security OauthToken
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param pageSize query int false "The number of recurrences per page."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskRecurrenceListResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/recurrences [get]
+func (h *TaskHandler) ResolveTaskRecurrences(w http.ResponseWriter, r *http.Request) {
+	pagination, err := queryPagination(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.ResolveRecurrences(r.Context(), pagination)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// ResolveTaskRecurrence resolves a recurring Task.
+// @Summary Resolve a recurring Task
+// @Description This endpoint resolves a recurring Task with its next few occurrences.
+// @Tags Task
+// @Security OauthToken
+// @Param recurrenceId path string true "The recurrence's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskRecurrenceResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/recurrences/{recurrenceId} [get]
+func (h *TaskHandler) ResolveTaskRecurrence(w http.ResponseWriter, r *http.Request) {
+	recurrenceID, err := uuid.Parse(chi.URLParam(r, "recurrenceId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.ResolveRecurrence(r.Context(), recurrenceID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// CreateTaskRecurrence creates a recurring Task.
+// @Summary Create a recurring Task.
+// @Description This endpoint creates a template of Tasks repeating on an RFC 5545
+// @Description RRULE, such as "FREQ=WEEKLY;BYDAY=MO,WE", in a timezone from startsAt.
+// @Description Each occurrence becomes a Task due at the occurrence, except on the
+// @Description dates of exdates. With the "schedule" generation, the default, an
+// @Description occurrence becomes a Task once it is due, less leadMinutes; with the
+// @Description "completion" generation the next occurrence becomes a Task when the
+// @Description Task of the previous one is done, and the first one at once.
+// @Tags Task
+// @Security OauthToken
+// @Param recurrence body task.TaskRecurrenceRequestFormat true "The recurrence to be created."
+// @Produce json
+// @Success 201 {object} response.Base{data=task.TaskRecurrenceResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/recurrences [post]
+func (h *TaskHandler) CreateTaskRecurrence(w http.ResponseWriter, r *http.Request) {
+	requestFormat, err := decodeRecurrenceRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.CreateRecurrence(r.Context(), requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusCreated, resp)
+}
+
+// UpdateTaskRecurrence updates a recurring Task.
+// @Summary Update a recurring Task.
+// @Description This endpoint updates a recurring Task. Its next occurrence is worked
+// @Description out again from now; the Tasks of past occurrences are left as they are.
+// @Tags Task
+// @Security OauthToken
+// @Param recurrenceId path string true "The recurrence's identifier."
+// @Param recurrence body task.TaskRecurrenceRequestFormat true "The recurrence to be updated."
+// @Produce json
+// @Success 200 {object} response.Base{data=task.TaskRecurrenceResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/recurrences/{recurrenceId} [put]
+func (h *TaskHandler) UpdateTaskRecurrence(w http.ResponseWriter, r *http.Request) {
+	recurrenceID, err := uuid.Parse(chi.URLParam(r, "recurrenceId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	requestFormat, err := decodeRecurrenceRequest(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	resp, err := h.TaskService.UpdateRecurrence(r.Context(), recurrenceID, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, resp)
+}
+
+// DeleteTaskRecurrence deletes a recurring Task.
+// @Summary Delete a recurring Task.
+// @Description This endpoint deletes a recurring Task, so that no more of its
+// @Description occurrences become Tasks. The Tasks of past occurrences are kept.
+// @Tags Task
+// @Security OauthToken
+// @Param recurrenceId path string true "The recurrence's identifier."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Failure 504 {object} response.Base
+// @Router /v1/tasks/recurrences/{recurrenceId} [delete]
+func (h *TaskHandler) DeleteTaskRecurrence(w http.ResponseWriter, r *http.Request) {
+	recurrenceID, err := uuid.Parse(chi.URLParam(r, "recurrenceId"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		log.Info().Msg(err.Error())
+		return
+	}
+
+	resp, err := h.TaskService.DeleteRecurrence(r.Context(), recurrenceID)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithMessage(w, http.StatusOK, resp.Message)
+}
+
+// decodeChecklistItemRequest reads and validates a ChecklistItemRequestFormat
+// from the body.
+func decodeChecklistItemRequest(r *http.Request) (requestFormat task.ChecklistItemRequestFormat, err error) {
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+	return
+}
+
+// decodeRecurrenceRequest reads and validates a TaskRecurrenceRequestFormat
+// from the body.
+func decodeRecurrenceRequest(r *http.Request) (requestFormat task.TaskRecurrenceRequestFormat, err error) {
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+	return
+}
+
+func decodeCommentRequest(r *http.Request) (requestFormat task.TaskCommentRequestFormat, err error) {
+	err = json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		return requestFormat, failure.BadRequest(err)
+	}
+	return
+}
+
+// maxFilterLabels is the most labels a Task list can be filtered by.
+const maxFilterLabels = 20
+
+// maxPlanTasks is the most Tasks planned at once.
+const maxPlanTasks = 100
+
+// sortParameters maps the TaskSort fields to the query parameters they are
+// read from.
+var sortParameters = map[string]string{
+	"Field": "sort",
+	"Order": "order",
+}
+
+// parseTaskFilter reads a TaskFilter from the query string. Every invalid
+// parameter is reported in one failure, keyed by parameter name.
+func parseTaskFilter(r *http.Request) (filter task.TaskFilter, err error) {
+	query := r.URL.Query()
+	invalid := make(map[string]string)
+
+	filter.Keyword = strings.TrimSpace(query.Get("keyword"))
+	filter.Status = strings.TrimSpace(query.Get("status"))
+	filter.Priority = strings.TrimSpace(query.Get("priority"))
+	if filter.Priority != "" && !contains(task.Priorities, filter.Priority) {
+		invalid["priority"] = "must be one of " + strings.Join(task.Priorities, " ")
+	}
+	for name, target := range map[string]**time.Time{
+		"dueAfter":  &filter.DueAfter,
+		"dueBefore": &filter.DueBefore,
+	} {
+		if v := query.Get(name); v != "" {
+			parsed, parseErr := time.Parse(time.RFC3339, v)
+			if parseErr != nil {
+				invalid[name] = "must be an RFC 3339 time"
+				continue
+			}
+			*target = &parsed
+		}
+	}
+	if v := query.Get("overdue"); v != "" {
+		overdue, parseErr := strconv.ParseBool(v)
+		if parseErr != nil {
+			invalid["overdue"] = "must be true or false"
+		}
+		filter.Overdue = overdue
+	}
+	if v := strings.TrimSpace(query.Get("assignee")); v != "" {
+		if v == "me" {
+			v = shared.ActorFromContext(r.Context())
+		}
+		assignee, parseErr := strconv.ParseInt(v, 10, 64)
+		if parseErr != nil || assignee < 1 {
+			invalid["assignee"] = "must be a user ID, or me for an identified user"
+		}
+		filter.Assignee = assignee
+	}
+	if v := query.Get("team"); v != "" {
+		teamID, parseErr := strconv.ParseInt(v, 10, 64)
+		if parseErr != nil || teamID < 1 {
+			invalid["team"] = "must be a team ID"
+		}
+		filter.TeamID = teamID
+	}
+	if v := query.Get("labels"); v != "" {
+		for _, id := range strings.Split(v, ",") {
+			parsed, parseErr := uuid.Parse(strings.TrimSpace(id))
+			if parseErr != nil {
+				invalid["labels"] = "must be comma separated label IDs"
+				break
+			}
+			if !contains(filter.Labels, parsed.String()) {
+				filter.Labels = append(filter.Labels, parsed.String())
+			}
+		}
+		if len(filter.Labels) > maxFilterLabels {
+			invalid["labels"] = "must list at most " + strconv.Itoa(maxFilterLabels) + " labels"
+		}
+		filter.LabelMatch = task.LabelMatchAny
+	}
+	if v := query.Get("labelMatch"); v != "" {
+		if v != task.LabelMatchAny && v != task.LabelMatchAll {
+			invalid["labelMatch"] = "must be one of " + task.LabelMatchAny + " " + task.LabelMatchAll
+		}
+		if len(filter.Labels) > 0 {
+			filter.LabelMatch = v
+		}
+	}
+	if v := strings.TrimSpace(query.Get("parent")); v != "" {
+		filter.Parent = task.ParentNone
+		if v != task.ParentNone {
+			parsed, parseErr := uuid.Parse(v)
+			if parseErr != nil {
+				invalid["parent"] = "must be a Task ID, or none for top-level Tasks"
+			}
+			filter.Parent = parsed.String()
+		}
+	}
+	if filter.Query = strings.TrimSpace(query.Get("q")); filter.Query != "" {
+		condition, parseErr := task.ParseFilterExpression(filter.Query)
+		if parseErr != nil {
+			invalid["q"] = parseErr.Error()
+		} else {
+			filter.Condition = &condition
+		}
+	}
+
+	filter.Sort.Field = query.Get("sort")
+	filter.Sort.Order = strings.ToUpper(query.Get("order"))
+	if sortErr := filter.Sort.SetDefaults(); sortErr != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(sortErr, &validationErrors) {
+			return filter, failure.BadRequest(sortErr)
+		}
+		for _, fieldErr := range validationErrors {
+			invalid[sortParameters[fieldErr.Field()]] = "must be one of " + fieldErr.Param()
+		}
+	}
+
+	for name, target := range map[string]*int{
+		"page":     &filter.Pagination.Page,
+		"pageSize": &filter.Pagination.PageSize,
+	} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		parsed, parseErr := strconv.Atoi(v)
+		if parseErr != nil || parsed < 1 {
+			invalid[name] = "must be a positive number"
+			continue
+		}
+		*target = parsed
+	}
+
+	filter.AsOf, err = queryAsOf(r)
+	if err != nil {
+		invalid["asOf"] = "must be an RFC 3339 time"
+	}
+
+	filter.Cursor = query.Get("cursor")
+	filter.WithCount = filter.Cursor == ""
+	if v := query.Get("count"); v != "" {
+		withCount, parseErr := strconv.ParseBool(v)
+		if parseErr != nil {
+			invalid["count"] = "must be true or false"
+		}
+		filter.WithCount = withCount
+	}
+
+	if len(invalid) > 0 {
+		return filter, failure.InvalidFields(invalid)
+	}
+	return filter, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// cursorLink returns the request's URL moved to the page of cursor, or
+// nothing when there is no such page.
+func cursorLink(r *http.Request, cursor string) string {
+	if cursor == "" {
+		return ""
+	}
+	link := *r.URL
+	query := link.Query()
+	query.Set("cursor", cursor)
+	query.Del("page")
+	link.RawQuery = query.Encode()
+	return link.RequestURI()
+}
+
+// queryAsOf reads the optional asOf query parameter.
+func queryAsOf(r *http.Request) (asOf *time.Time, err error) {
+	v := r.URL.Query().Get("asOf")
+	if v == "" {
+		return
+	}
+	parsed, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, failure.BadRequestFromString("asOf must be an RFC 3339 time")
+	}
+	return &parsed, nil
+}
+
+// queryPagination reads the optional page and pageSize query parameters.
+func queryPagination(r *http.Request) (pagination task.Pagination, err error) {
+	if page := r.URL.Query().Get("page"); page != "" {
+		pagination.Page, err = strconv.Atoi(page)
+		if err != nil || pagination.Page < 1 {
+			return pagination, failure.BadRequestFromString("page must be a positive number")
+		}
+	}
+	if pageSize := r.URL.Query().Get("pageSize"); pageSize != "" {
+		pagination.PageSize, err = strconv.Atoi(pageSize)
+		if err != nil || pagination.PageSize < 1 {
+			return pagination, failure.BadRequestFromString("pageSize must be a positive number")
+		}
+	}
+	return
+}
+
+// queryVersion reads the optional version query parameter.
+func queryVersion(r *http.Request) (version *int64, err error) {
+	v := r.URL.Query().Get("version")
+	if v == "" {
+		return
+	}
+	parsed, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, failure.BadRequestFromString("version must be a number")
+	}
+	return &parsed, nil
+}
+
+// etag renders a Task version as a strong entity tag.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parsePrecondition reads the version a client expects from the If-Match
+// header, falling back to the version given in the request itself.
+func parsePrecondition(r *http.Request, version *int64) (precondition task.Precondition, err error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch {
+	case ifMatch == "*":
+		return task.Precondition{Any: true, IfMatch: true}, nil
+	case ifMatch != "":
+		value := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+		precondition.Version, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return precondition, failure.BadRequestFromString("If-Match must be an ETag returned by this API")
+		}
+		precondition.IfMatch = true
+		return
+	case version != nil:
+		precondition.Version = *version
+		return
+	}
+	return precondition, failure.PreconditionRequired("an If-Match header or a version is required")
+}
@@ -0,0 +1,84 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+// CursorValue is a key value of the last migrated row. Binary keys are kept
+// as bytes so that they can be bound back to MySQL unchanged.
+type CursorValue struct {
+	Text  string `json:"text,omitempty"`
+	Bytes []byte `json:"bytes,omitempty"`
+}
+
+// TableProgress is the migration progress of a single table.
+type TableProgress struct {
+	LastKey   []CursorValue `json:"lastKey,omitempty"`
+	Rows      int64         `json:"rows"`
+	Done      bool          `json:"done"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+// Checkpoint persists the migration progress so that an interrupted run can
+// be resumed where it stopped.
+type Checkpoint struct {
+	path   string
+	Tables map[string]*TableProgress `json:"tables"`
+}
+
+// LoadCheckpoint reads the checkpoint stored at path. A missing file yields
+// an empty checkpoint.
+func LoadCheckpoint(path string) (checkpoint *Checkpoint, err error) {
+	checkpoint = &Checkpoint{path: path, Tables: make(map[string]*TableProgress)}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(content, checkpoint)
+	if checkpoint.Tables == nil {
+		checkpoint.Tables = make(map[string]*TableProgress)
+	}
+	return
+}
+
+// Progress returns the progress of a table, creating it when absent.
+func (c *Checkpoint) Progress(table string) *TableProgress {
+	progress, ok := c.Tables[table]
+	if !ok {
+		progress = new(TableProgress)
+		c.Tables[table] = progress
+	}
+	return progress
+}
+
+// Reset forgets all progress.
+func (c *Checkpoint) Reset() {
+	c.Tables = make(map[string]*TableProgress)
+}
+
+// Save writes the checkpoint atomically by replacing the file.
+func (c *Checkpoint) Save() (err error) {
+	if c.path == "" {
+		return
+	}
+
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return
+	}
+
+	tmp := c.path + ".tmp"
+	err = os.WriteFile(tmp, content, 0o644)
+	if err != nil {
+		return
+	}
+	return os.Rename(tmp, c.path)
+}
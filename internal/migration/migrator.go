@@ -0,0 +1,266 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultBatchSize is the number of rows copied per Oracle transaction.
+	DefaultBatchSize = 500
+)
+
+// Migrator streams rows from MySQL into Oracle in batches.
+type Migrator struct {
+	Source     *sqlx.DB
+	Target     *sqlx.DB
+	Checkpoint *Checkpoint
+	BatchSize  int
+}
+
+// NewMigrator creates a Migrator copying from source into target.
+func NewMigrator(source, target *sqlx.DB, checkpoint *Checkpoint, batchSize int) *Migrator {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Migrator{
+		Source:     source,
+		Target:     target,
+		Checkpoint: checkpoint,
+		BatchSize:  batchSize,
+	}
+}
+
+// Run migrates the given tables in order, skipping tables already marked as
+// done in the checkpoint and resuming the others after their last key.
+func (m *Migrator) Run(tables []Table) (err error) {
+	for _, table := range tables {
+		progress := m.Checkpoint.Progress(table.Source)
+		if progress.Done {
+			log.Info().Str("table", table.Source).Int64("rows", progress.Rows).Msg("Table already migrated, skipping")
+			continue
+		}
+
+		err = m.migrateTable(table, progress)
+		if err != nil {
+			return fmt.Errorf("migrating %s: %w", table.Source, err)
+		}
+	}
+	return
+}
+
+func (m *Migrator) migrateTable(table Table, progress *TableProgress) (err error) {
+	columns, err := describe(m.Source, table)
+	if err != nil {
+		return
+	}
+
+	keyIndexes, err := keyIndexes(table, columns)
+	if err != nil {
+		return
+	}
+
+	merge := mergeStatement(table, columns)
+	log.Info().Str("table", table.Source).Int64("resumeAt", progress.Rows).Msg("Migrating table")
+
+	for {
+		query, args := selectBatch(table, columns, progress.LastKey, m.BatchSize)
+		var batch [][]interface{}
+		var lastKey []CursorValue
+		batch, lastKey, err = m.readBatch(table, columns, keyIndexes, query, args)
+		if err != nil {
+			return
+		}
+
+		if len(batch) > 0 {
+			err = m.writeBatch(merge, batch)
+			if err != nil {
+				return
+			}
+			progress.LastKey = lastKey
+			progress.Rows += int64(len(batch))
+		}
+
+		progress.Done = len(batch) < m.BatchSize
+		progress.UpdatedAt = time.Now()
+		err = m.Checkpoint.Save()
+		if err != nil {
+			return
+		}
+
+		log.Info().Str("table", table.Source).Int64("rows", progress.Rows).Msg("Batch migrated")
+		if progress.Done {
+			return
+		}
+	}
+}
+
+// readBatch reads one batch from MySQL, returning the converted rows and the
+// raw key of the last row.
+func (m *Migrator) readBatch(table Table, columns []column, keyIndexes []int, query string, args []interface{}) (batch [][]interface{}, lastKey []CursorValue, err error) {
+	rows, err := m.Source.Queryx(query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		raw, errScan := rows.SliceScan()
+		if errScan != nil {
+			return nil, nil, errScan
+		}
+
+		row := make([]interface{}, len(columns))
+		for i, c := range columns {
+			row[i], err = convert(c.Kind, raw[i])
+			if err != nil {
+				return nil, nil, fmt.Errorf("column %s: %w", c.Name, err)
+			}
+		}
+		batch = append(batch, row)
+
+		lastKey = make([]CursorValue, len(keyIndexes))
+		for i, index := range keyIndexes {
+			lastKey[i] = cursorValue(columns[index].Kind, raw[index])
+		}
+	}
+	err = rows.Err()
+	return
+}
+
+// writeBatch writes one batch into Oracle within a single transaction.
+func (m *Migrator) writeBatch(merge string, batch [][]interface{}) (err error) {
+	tx, err := m.Target.Beginx()
+	if err != nil {
+		return
+	}
+
+	stmt, err := tx.Preparex(merge)
+	if err != nil {
+		_ = tx.Rollback()
+		return
+	}
+	defer stmt.Close()
+
+	for _, row := range batch {
+		_, err = stmt.Exec(row...)
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+// describe resolves the columns of the source table and their logical types.
+func describe(db *sqlx.DB, table Table) (columns []column, err error) {
+	rows, err := db.Queryx("SELECT * FROM " + table.Source + " WHERE 1 = 0")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return
+	}
+
+	for _, t := range types {
+		c := column{Name: strings.ToLower(t.Name()), Kind: kindOf(t)}
+		if table.isUUID(c.Name) {
+			c.Kind = kindUUID
+		}
+		columns = append(columns, c)
+	}
+	return
+}
+
+func keyIndexes(table Table, columns []column) (indexes []int, err error) {
+	for _, key := range table.Keys {
+		found := false
+		for i, c := range columns {
+			if strings.EqualFold(c.Name, key) {
+				indexes = append(indexes, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("key column %s not found", key)
+		}
+	}
+	return
+}
+
+// cursorValue keeps binary keys as bytes and renders every other key as text,
+// so that MySQL compares it using the column's own collation.
+func cursorValue(k kind, value interface{}) CursorValue {
+	switch v := value.(type) {
+	case []byte:
+		if k == kindBytes || (k == kindUUID && len(v) == 16) {
+			return CursorValue{Bytes: append([]byte(nil), v...)}
+		}
+		return CursorValue{Text: string(v)}
+	case time.Time:
+		return CursorValue{Text: v.Format(mysqlTimeLayout)}
+	default:
+		return CursorValue{Text: fmt.Sprint(v)}
+	}
+}
+
+func columnNames(columns []column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// selectBatch builds the keyset query reading the batch after lastKey.
+func selectBatch(table Table, columns []column, lastKey []CursorValue, batchSize int) (query string, args []interface{}) {
+	query = "SELECT " + strings.Join(columnNames(columns), ", ") + " FROM " + table.Source
+
+	if len(lastKey) == len(table.Keys) {
+		placeholders := make([]string, len(lastKey))
+		for i, key := range lastKey {
+			placeholders[i] = "?"
+			if key.Bytes != nil {
+				args = append(args, key.Bytes)
+			} else {
+				args = append(args, key.Text)
+			}
+		}
+		query += fmt.Sprintf(" WHERE (%s) > (%s)", strings.Join(table.Keys, ", "), strings.Join(placeholders, ", "))
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", strings.Join(table.Keys, ", "), batchSize)
+	return
+}
+
+// mergeStatement builds an Oracle MERGE that inserts a row unless a row with
+// the same key already exists, which makes replaying a batch harmless.
+func mergeStatement(table Table, columns []column) string {
+	selects := make([]string, len(columns))
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		selects[i] = fmt.Sprintf(":%d AS %s", i+1, c.Name)
+		values[i] = "s." + c.Name
+	}
+
+	on := make([]string, len(table.Keys))
+	for i, key := range table.Keys {
+		on[i] = fmt.Sprintf("t.%s = s.%s", key, key)
+	}
+
+	return fmt.Sprintf(
+		"MERGE INTO %s t USING (SELECT %s FROM dual) s ON (%s) WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		table.Target,
+		strings.Join(selects, ", "),
+		strings.Join(on, " AND "),
+		strings.Join(columnNames(columns), ", "),
+		strings.Join(values, ", "))
+}
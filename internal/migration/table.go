@@ -0,0 +1,66 @@
+package migration
+
+import "strings"
+
+// Table describes a table copied from MySQL into Oracle.
+type Table struct {
+	// Source is the table name on the MySQL side.
+	Source string
+	// Target is the table name on the Oracle side, including its schema when needed.
+	Target string
+	// Keys are the columns that uniquely identify a row. They drive the
+	// keyset streaming and the idempotent MERGE on the Oracle side.
+	Keys []string
+	// UUIDColumns are columns holding UUIDs, either as BINARY(16) or CHAR(36).
+	UUIDColumns []string
+}
+
+// DefaultTables are the tables migrated when no explicit selection is given,
+// ordered so that referenced rows are copied before the rows referencing them.
+var DefaultTables = []Table{
+	{Source: "tasks", Target: "temp.tasks", Keys: []string{"id"}, UUIDColumns: []string{"id"}},
+	{Source: "users", Target: "users", Keys: []string{"id"}},
+	{Source: "role", Target: "role", Keys: []string{"id"}},
+	{Source: "permission", Target: "permission", Keys: []string{"id"}},
+	{Source: "user_role", Target: "user_role", Keys: []string{"id_user", "id_role"}},
+	{Source: "role_permission", Target: "role_permission", Keys: []string{"id_role", "id_permission"}},
+	{Source: "oauth_clients", Target: "oauth_clients", Keys: []string{"client_id"}},
+	{Source: "oauth_access_tokens", Target: "oauth_access_tokens", Keys: []string{"access_token"}},
+	{Source: "oauth_refresh_tokens", Target: "oauth_refresh_tokens", Keys: []string{"refresh_token"}},
+}
+
+// SelectTables returns the default tables matching the given source names.
+// An empty selection returns all default tables.
+func SelectTables(names []string) (tables []Table, unknown []string) {
+	if len(names) == 0 {
+		return DefaultTables, nil
+	}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, table := range DefaultTables {
+			if table.Source == name {
+				tables = append(tables, table)
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	return
+}
+
+func (t Table) isUUID(column string) bool {
+	for _, c := range t.UUIDColumns {
+		if strings.EqualFold(c, column) {
+			return true
+		}
+	}
+	return false
+}
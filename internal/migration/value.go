@@ -0,0 +1,207 @@
+package migration
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// kind is the logical type of a column, shared by both databases.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindDecimal
+	kindFloat
+	kindTime
+	kindBytes
+	kindUUID
+)
+
+const mysqlTimeLayout = "2006-01-02 15:04:05"
+
+// column is a source column together with its logical type.
+type column struct {
+	Name string
+	Kind kind
+}
+
+// kindOf maps a MySQL column type to its logical type.
+func kindOf(columnType *sql.ColumnType) kind {
+	switch strings.ToUpper(columnType.DatabaseTypeName()) {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		return kindInt
+	case "DECIMAL", "NUMERIC":
+		return kindDecimal
+	case "FLOAT", "DOUBLE", "REAL":
+		return kindFloat
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return kindTime
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BIT":
+		return kindBytes
+	default:
+		return kindString
+	}
+}
+
+// convert turns a value scanned from either database into the value bound
+// on the Oracle side. Byte slices are always copied because the MySQL driver
+// reuses its buffers between rows.
+func convert(k kind, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch k {
+	case kindUUID:
+		return convertUUID(value)
+	case kindInt:
+		return convertInt(value)
+	case kindDecimal, kindFloat:
+		return convertFloat(value)
+	case kindTime:
+		return convertTime(value)
+	case kindBytes:
+		if b, ok := value.([]byte); ok {
+			return append([]byte(nil), b...), nil
+		}
+		return value, nil
+	default:
+		switch v := value.(type) {
+		case []byte:
+			return string(v), nil
+		case string:
+			return v, nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	}
+}
+
+func convertUUID(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 16 {
+			id, err := uuid.FromBytes(v)
+			if err != nil {
+				return nil, err
+			}
+			return id.String(), nil
+		}
+		return convertUUID(string(v))
+	case string:
+		id, err := uuid.Parse(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	case uuid.UUID:
+		return v.String(), nil
+	}
+	return nil, fmt.Errorf("unsupported UUID value %T", value)
+}
+
+func convertInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int32:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case []byte:
+		return convertInt(string(v))
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			// values beyond int64, e.g. unsigned BIGINT, are kept as text
+			return v, nil
+		}
+		return i, nil
+	}
+	return nil, fmt.Errorf("unsupported integer value %T", value)
+}
+
+func convertFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case []byte:
+		return convertFloat(string(v))
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return nil, fmt.Errorf("unsupported numeric value %T", value)
+}
+
+func convertTime(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return convertTime(string(v))
+	case string:
+		if strings.HasPrefix(v, "0000-00-00") {
+			// MySQL zero dates have no Oracle equivalent
+			return nil, nil
+		}
+		if len(v) > len(mysqlTimeLayout) {
+			v = v[:len(mysqlTimeLayout)]
+		}
+		return time.Parse(mysqlTimeLayout, v)
+	}
+	return nil, fmt.Errorf("unsupported time value %T", value)
+}
+
+// canonical renders a value in a form that is identical for both databases,
+// so that checksums computed on each side can be compared. Oracle stores
+// empty strings as NULL, hence both are rendered the same way.
+func canonical(k kind, value interface{}) (string, error) {
+	converted, err := convert(k, value)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := converted.(type) {
+	case nil:
+		return "\x00", nil
+	case string:
+		if v == "" {
+			return "\x00", nil
+		}
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', 15, 64), nil
+	case time.Time:
+		// Oracle DATE columns have second precision
+		return v.Truncate(time.Second).Format(mysqlTimeLayout), nil
+	case []byte:
+		if len(v) == 0 {
+			return "\x00", nil
+		}
+		return hex.EncodeToString(v), nil
+	}
+	return fmt.Sprint(converted), nil
+}
@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+)
+
+// The statuses of an Event. A pending Event is published by the relay, or
+// becomes dead once its deliveries failed MaxAttempts times.
+const (
+	StatusPending   = "pending"
+	StatusPublished = "published"
+	StatusDead      = "dead"
+)
+
+// Event is a domain event stored in the outbox, in the same transaction as
+// the change it describes, until the relay publishes it.
+type Event struct {
+	ID            uuid.UUID   `db:"id" json:"id"`
+	Sequence      int64       `db:"seq" json:"sequence"`
+	AggregateType string      `db:"aggregate_type" json:"aggregateType"`
+	AggregateID   string      `db:"aggregate_id" json:"aggregateId"`
+	EventType     string      `db:"event_type" json:"eventType"`
+	Payload       string      `db:"payload" json:"-"`
+	CreatedAt     time.Time   `db:"created_at" json:"createdAt"`
+	Status        string      `db:"status" json:"-"`
+	PublishedAt   null.Time   `db:"published_at" json:"-"`
+	Attempts      int         `db:"attempts" json:"-"`
+	NextAttemptAt null.Time   `db:"next_attempt_at" json:"-"`
+	LastError     null.String `db:"last_error" json:"-"`
+}
+
+// NewEvent creates an Event for an aggregate with payload encoded as JSON.
+func NewEvent(aggregateType string, aggregateID string, eventType string, payload interface{}) (event Event, err error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	event = Event{
+		ID:            uuid.New(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(encoded),
+		CreatedAt:     time.Now(),
+		Status:        StatusPending,
+	}
+	return
+}
+
+// key identifies the aggregate an Event belongs to. Events of the same
+// aggregate are published in order.
+func (e Event) key() string {
+	return e.AggregateType + "/" + e.AggregateID
+}
+
+// Message is the representation of an Event handed to publishers.
+type Message struct {
+	Event
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ToMessage converts an Event into the Message sent to sinks.
+func (e Event) ToMessage() Message {
+	return Message{
+		Event:   e,
+		Payload: json.RawMessage(e.Payload),
+	}
+}
@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+var (
+	queries = struct {
+		insertEvent         string
+		selectPending       string
+		lockRelay           string
+		updateNextAttempt   string
+		updatePublished     string
+		updateFailedAttempt string
+	}{
+		insertEvent: `
+			INSERT INTO temp.outbox_events (
+				id,
+				aggregate_type,
+				aggregate_id,
+				event_type,
+				payload,
+				created_at,
+				status
+			) VALUES (
+				:id,
+				:aggregate_type,
+				:aggregate_id,
+				:event_type,
+				:payload,
+				:created_at,
+				:status)`,
+		// an Event is due once its retry time has come, and only when no
+		// earlier Event of its aggregate is still waiting for a retry
+		selectPending: `
+			SELECT
+				e.id,
+				e.seq,
+				e.aggregate_type,
+				e.aggregate_id,
+				e.event_type,
+				e.payload,
+				e.created_at,
+				e.status,
+				e.published_at,
+				e.attempts,
+				e.next_attempt_at,
+				e.last_error
+			FROM temp.outbox_events e
+			WHERE e.status = 'pending'
+			AND (e.next_attempt_at IS NULL OR e.next_attempt_at <= :1)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM temp.outbox_events w
+				WHERE w.aggregate_type = e.aggregate_type
+				AND w.aggregate_id = e.aggregate_id
+				AND w.status = 'pending'
+				AND w.seq < e.seq
+				AND w.next_attempt_at > :2)
+			ORDER BY e.seq
+			FETCH FIRST :3 ROWS ONLY`,
+		lockRelay: `SELECT id FROM temp.outbox_relay_lock WHERE id = 1 FOR UPDATE SKIP LOCKED`,
+		updateNextAttempt: `
+			UPDATE temp.outbox_events SET
+				next_attempt_at=:next_attempt_at
+			WHERE id=:id AND status='pending'`,
+		updatePublished: `
+			UPDATE temp.outbox_events SET
+				status='published',
+				published_at=:published_at,
+				attempts=:attempts,
+				next_attempt_at=NULL,
+				last_error=NULL
+			WHERE id=:id`,
+		updateFailedAttempt: `
+			UPDATE temp.outbox_events SET
+				status=:status,
+				attempts=:attempts,
+				next_attempt_at=:next_attempt_at,
+				last_error=:last_error
+			WHERE id=:id`,
+	}
+)
+
+// OutboxRepository is the repository for outbox Events.
+type OutboxRepository interface {
+	TxCreate(ctx context.Context, tx *sqlx.Tx, event Event) (err error)
+	TxLockRelay(ctx context.Context, tx *sqlx.Tx) (locked bool, err error)
+	TxResolvePending(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) (events []Event, err error)
+	TxClaim(ctx context.Context, tx *sqlx.Tx, event Event, until time.Time) (err error)
+	Release(ctx context.Context, event Event) (err error)
+	MarkPublished(ctx context.Context, event Event, publishedAt time.Time) (err error)
+	MarkFailed(ctx context.Context, event Event) (err error)
+}
+
+// OutboxRepositoryOracle is the Oracle-backed implementation of OutboxRepository.
+type OutboxRepositoryOracle struct {
+	DB *infras.OracleConn
+}
+
+// ProvideOutboxRepositoryOracle is the provider for this repository.
+func ProvideOutboxRepositoryOracle(db *infras.OracleConn) *OutboxRepositoryOracle {
+	s := new(OutboxRepositoryOracle)
+	s.DB = db
+	return s
+}
+
+// TxCreate stores an Event within the transaction of the change it describes.
+func (r *OutboxRepositoryOracle) TxCreate(ctx context.Context, tx *sqlx.Tx, event Event) (err error) {
+	_, err = tx.NamedExecContext(ctx, queries.insertEvent, event)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// TxLockRelay takes the relay lock for the duration of tx. It returns false
+// without waiting when another relay instance holds the lock.
+func (r *OutboxRepositoryOracle) TxLockRelay(ctx context.Context, tx *sqlx.Tx) (locked bool, err error) {
+	var ids []int
+	err = tx.SelectContext(ctx, &ids, queries.lockRelay)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	return len(ids) > 0, nil
+}
+
+// TxResolvePending resolves the oldest Events due at now in order.
+func (r *OutboxRepositoryOracle) TxResolvePending(ctx context.Context, tx *sqlx.Tx, now time.Time, limit int) (events []Event, err error) {
+	err = tx.SelectContext(ctx, &events, queries.selectPending, now, now, limit)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// TxClaim holds an Event back from other relay runs until the given time, by
+// which it is published or retried.
+func (r *OutboxRepositoryOracle) TxClaim(ctx context.Context, tx *sqlx.Tx, event Event, until time.Time) (err error) {
+	_, err = tx.NamedExecContext(ctx, queries.updateNextAttempt, map[string]interface{}{
+		"id":              event.ID,
+		"next_attempt_at": until,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// Release gives up the claim on an Event that was not published, making it
+// due at its previous retry time again.
+func (r *OutboxRepositoryOracle) Release(ctx context.Context, event Event) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, queries.updateNextAttempt, map[string]interface{}{
+		"id":              event.ID,
+		"next_attempt_at": event.NextAttemptAt,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// MarkPublished records a successful delivery.
+func (r *OutboxRepositoryOracle) MarkPublished(ctx context.Context, event Event, publishedAt time.Time) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, queries.updatePublished, map[string]interface{}{
+		"id":           event.ID,
+		"attempts":     event.Attempts,
+		"published_at": publishedAt,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
+
+// MarkFailed records a failed delivery and when it is retried, or that it is
+// dead.
+func (r *OutboxRepositoryOracle) MarkFailed(ctx context.Context, event Event) (err error) {
+	ctx, cancel := r.DB.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err = r.DB.Write.NamedExecContext(ctx, queries.updateFailedAttempt, map[string]interface{}{
+		"id":              event.ID,
+		"status":          event.Status,
+		"attempts":        event.Attempts,
+		"next_attempt_at": event.NextAttemptAt,
+		"last_error":      event.LastError,
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+)
+
+const (
+	// PublisherLog writes events to the application log.
+	PublisherLog = "log"
+	// PublisherWebhook posts events to an HTTP endpoint.
+	PublisherWebhook = "webhook"
+	// PublisherFile appends events to a JSON lines file.
+	PublisherFile = "file"
+
+	defaultWebhookTimeout = 5 * time.Second
+	defaultFilePath       = "outbox.jsonl"
+)
+
+// Publisher delivers outbox Events to a sink. Delivery is at-least-once, so
+// sinks should deduplicate on the event ID.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) (err error)
+}
+
+// ProvidePublisher is the provider for the Publisher selected in config.
+func ProvidePublisher(config *configs.Config) Publisher {
+	switch config.Outbox.Publisher {
+	case PublisherWebhook:
+		timeout := time.Duration(config.Outbox.Webhook.TimeoutSeconds) * time.Second
+		if timeout == 0 {
+			timeout = defaultWebhookTimeout
+		}
+		return &WebhookPublisher{
+			URL:    config.Outbox.Webhook.URL,
+			Client: &http.Client{Timeout: timeout},
+		}
+	case PublisherFile:
+		path := config.Outbox.File.Path
+		if path == "" {
+			path = defaultFilePath
+		}
+		return &FilePublisher{Path: path}
+	default:
+		return new(LogPublisher)
+	}
+}
+
+// LogPublisher writes Events to the application log.
+type LogPublisher struct{}
+
+// Publish logs the event.
+func (p *LogPublisher) Publish(ctx context.Context, event Event) (err error) {
+	log.Info().
+		Str("id", event.ID.String()).
+		Str("aggregateType", event.AggregateType).
+		Str("aggregateId", event.AggregateID).
+		Str("eventType", event.EventType).
+		RawJSON("payload", []byte(event.Payload)).
+		Msg("Outbox event published")
+	return
+}
+
+// WebhookPublisher posts Events as JSON to URL. Any non-2xx response is a
+// failed delivery.
+type WebhookPublisher struct {
+	URL    string
+	Client *http.Client
+}
+
+// Publish posts the event to the webhook.
+func (p *WebhookPublisher) Publish(ctx context.Context, event Event) (err error) {
+	body, err := json.Marshal(event.ToMessage())
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", event.ID.String())
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err = fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return
+}
+
+// FilePublisher appends Events as JSON lines to the file at Path.
+type FilePublisher struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Publish appends the event to the file.
+func (p *FilePublisher) Publish(ctx context.Context, event Event) (err error) {
+	line, err := json.Marshal(event.ToMessage())
+	if err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	file, err := os.OpenFile(p.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
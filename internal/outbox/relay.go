@@ -0,0 +1,214 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/logger"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultPollInterval = 5 * time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+	defaultMaxAttempts  = 20
+	defaultClaimTimeout = 10 * time.Minute
+	baseBackoff         = time.Second
+	maxLastErrorLength  = 4000
+)
+
+// Relay publishes pending outbox Events in order. An Event is only marked as
+// published after the Publisher accepted it, so a crash in between delivers
+// it again. An Event whose deliveries failed MaxAttempts times is dead and no
+// longer holds back the later Events of its aggregate.
+type Relay struct {
+	Config       *configs.Config
+	DB           *infras.OracleConn
+	Repository   OutboxRepository
+	Publisher    Publisher
+	BatchSize    int
+	PollInterval time.Duration
+	MaxBackoff   time.Duration
+	MaxAttempts  int
+	ClaimTimeout time.Duration
+}
+
+// ProvideRelay is the provider for Relay.
+func ProvideRelay(config *configs.Config, db *infras.OracleConn, repository OutboxRepository, publisher Publisher) *Relay {
+	r := &Relay{
+		Config:       config,
+		DB:           db,
+		Repository:   repository,
+		Publisher:    publisher,
+		BatchSize:    config.Outbox.BatchSize,
+		PollInterval: time.Duration(config.Outbox.PollIntervalSeconds) * time.Second,
+		MaxBackoff:   time.Duration(config.Outbox.MaxBackoffSeconds) * time.Second,
+		MaxAttempts:  config.Outbox.MaxAttempts,
+		ClaimTimeout: time.Duration(config.Outbox.ClaimTimeoutSeconds) * time.Second,
+	}
+	if r.BatchSize <= 0 {
+		r.BatchSize = defaultBatchSize
+	}
+	if r.PollInterval <= 0 {
+		r.PollInterval = defaultPollInterval
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = defaultMaxBackoff
+	}
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = defaultMaxAttempts
+	}
+	if r.ClaimTimeout <= 0 {
+		r.ClaimTimeout = defaultClaimTimeout
+	}
+	return r
+}
+
+// Run polls for pending Events until ctx is cancelled. It does nothing when
+// the outbox is disabled.
+func (r *Relay) Run(ctx context.Context) {
+	if !r.Config.Outbox.Enable {
+		log.Info().Msg("Outbox relay is disabled.")
+		return
+	}
+	log.Info().Dur("interval", r.PollInterval).Msg("Starting outbox relay.")
+
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RelayPending(ctx); err != nil {
+			logger.ErrorWithStack(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes one batch of pending Events and reports how many
+// were published. Only one relay instance claims a batch at a time; the others
+// skip it. The batch is claimed in a short transaction and published outside
+// of it, each Event being marked as it is published, so a slow sink neither
+// holds the transaction nor undoes the deliveries made before it. Once an Event
+// of an aggregate fails or is waiting for its retry, the later Events of that
+// aggregate are held back to keep them in order.
+func (r *Relay) RelayPending(ctx context.Context) (published int, err error) {
+	claimedUntil := time.Now().Add(r.ClaimTimeout)
+	events, err := r.claim(ctx, claimedUntil)
+	if err != nil {
+		return
+	}
+
+	blocked := make(map[string]bool)
+	for i, event := range events {
+		// what is left once the claim expired may be claimed by another run
+		if ctx.Err() != nil || time.Now().After(claimedUntil) {
+			r.release(ctx, events[i:])
+			return
+		}
+		if blocked[event.key()] {
+			r.release(ctx, events[i:i+1])
+			continue
+		}
+
+		event.Attempts++
+		if publishErr := r.Publisher.Publish(ctx, event); publishErr != nil {
+			blocked[event.key()] = true
+			event.LastError = null.StringFrom(truncate(publishErr.Error(), maxLastErrorLength))
+			if event.Attempts >= r.MaxAttempts {
+				event.Status = StatusDead
+				event.NextAttemptAt = null.Time{}
+				log.Error().
+					Err(publishErr).
+					Str("id", event.ID.String()).
+					Int("attempts", event.Attempts).
+					Msg("Giving up publishing outbox event")
+			} else {
+				event.NextAttemptAt = null.TimeFrom(time.Now().Add(r.backoff(event.Attempts)))
+				log.Warn().
+					Err(publishErr).
+					Str("id", event.ID.String()).
+					Int("attempts", event.Attempts).
+					Time("nextAttemptAt", event.NextAttemptAt.Time).
+					Msg("Failed publishing outbox event")
+			}
+			if err = r.Repository.MarkFailed(ctx, event); err != nil {
+				r.release(ctx, events[i+1:])
+				return
+			}
+			continue
+		}
+
+		// an Event left claimed here is published again once its claim expires
+		if err = r.Repository.MarkPublished(ctx, event, time.Now()); err != nil {
+			r.release(ctx, events[i+1:])
+			return
+		}
+		published++
+	}
+	return
+}
+
+// claim resolves the Events due now and claims them until the given time. It
+// resolves none when another relay instance holds the relay lock.
+func (r *Relay) claim(ctx context.Context, until time.Time) (events []Event, err error) {
+	err = r.DB.WithTransaction(ctx, func(ctx context.Context, tx *sqlx.Tx) (err error) {
+		locked, err := r.Repository.TxLockRelay(ctx, tx)
+		if err != nil || !locked {
+			return
+		}
+
+		events, err = r.Repository.TxResolvePending(ctx, tx, time.Now(), r.BatchSize)
+		if err != nil {
+			return
+		}
+		for _, event := range events {
+			if err = r.Repository.TxClaim(ctx, tx, event, until); err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// release gives up the claim on Events that were not published. An Event it
+// fails to release is due again once its claim expires.
+func (r *Relay) release(ctx context.Context, events []Event) {
+	for _, event := range events {
+		if err := r.Repository.Release(ctx, event); err != nil {
+			return
+		}
+	}
+}
+
+// backoff returns the delay before the given attempt is retried, doubling
+// from one second up to MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxBackoff {
+		delay = r.MaxBackoff
+	}
+	return delay
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
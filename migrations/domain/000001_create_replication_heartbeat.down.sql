@@ -0,0 +1 @@
+DROP TABLE temp.replication_heartbeat;
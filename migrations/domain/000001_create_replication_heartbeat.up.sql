@@ -0,0 +1,5 @@
+CREATE TABLE temp.replication_heartbeat (
+    id   NUMBER(1)  NOT NULL,
+    beat NUMBER(19) NOT NULL,
+    CONSTRAINT replication_heartbeat_pk PRIMARY KEY (id)
+);
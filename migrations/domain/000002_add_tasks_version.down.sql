@@ -0,0 +1 @@
+ALTER TABLE temp.tasks DROP COLUMN version;
@@ -0,0 +1 @@
+ALTER TABLE temp.tasks ADD (version NUMBER(19) DEFAULT 1 NOT NULL);
@@ -0,0 +1,2 @@
+DROP TABLE temp.outbox_relay_lock;
+DROP TABLE temp.outbox_events;
@@ -0,0 +1,23 @@
+CREATE TABLE temp.outbox_events (
+    id              VARCHAR2(36)   NOT NULL,
+    seq             NUMBER(19)     GENERATED ALWAYS AS IDENTITY,
+    aggregate_type  VARCHAR2(64)   NOT NULL,
+    aggregate_id    VARCHAR2(64)   NOT NULL,
+    event_type      VARCHAR2(64)   NOT NULL,
+    payload         CLOB           NOT NULL,
+    created_at      TIMESTAMP      NOT NULL,
+    published_at    TIMESTAMP,
+    attempts        NUMBER(10)     DEFAULT 0 NOT NULL,
+    next_attempt_at TIMESTAMP,
+    last_error      VARCHAR2(4000),
+    CONSTRAINT outbox_events_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX outbox_events_pending_idx ON temp.outbox_events (published_at, seq);
+
+CREATE TABLE temp.outbox_relay_lock (
+    id NUMBER(1) NOT NULL,
+    CONSTRAINT outbox_relay_lock_pk PRIMARY KEY (id)
+);
+
+INSERT INTO temp.outbox_relay_lock (id) VALUES (1);
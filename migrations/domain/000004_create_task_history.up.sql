@@ -0,0 +1,13 @@
+CREATE TABLE temp.task_history (
+    id         VARCHAR2(36)  NOT NULL,
+    seq        NUMBER(19)    GENERATED ALWAYS AS IDENTITY,
+    task_id    VARCHAR2(36)  NOT NULL,
+    action     VARCHAR2(16)  NOT NULL,
+    changes    CLOB          NOT NULL,
+    actor      VARCHAR2(64),
+    request_id VARCHAR2(128),
+    created_at TIMESTAMP     NOT NULL,
+    CONSTRAINT task_history_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX task_history_task_idx ON temp.task_history (task_id, seq);
@@ -0,0 +1 @@
+DROP TABLE temp.tasks_versions;
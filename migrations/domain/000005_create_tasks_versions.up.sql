@@ -0,0 +1,29 @@
+CREATE TABLE temp.tasks_versions (
+    id          VARCHAR2(36)   NOT NULL,
+    version     NUMBER(19)     NOT NULL,
+    title       VARCHAR2(255)  NOT NULL,
+    description VARCHAR2(4000),
+    status      VARCHAR2(64),
+    created_at  TIMESTAMP,
+    created_by  NUMBER(19),
+    updated_at  TIMESTAMP,
+    updated_by  NUMBER(19),
+    deleted_at  TIMESTAMP,
+    deleted_by  VARCHAR2(64),
+    valid_from  TIMESTAMP      NOT NULL,
+    valid_to    TIMESTAMP,
+    CONSTRAINT tasks_versions_pk PRIMARY KEY (id, version)
+);
+
+CREATE INDEX tasks_versions_valid_idx ON temp.tasks_versions (valid_from, valid_to);
+
+-- the current state of existing tasks is their only known version
+INSERT INTO temp.tasks_versions (
+    id, version, title, description, status,
+    created_at, created_by, updated_at, updated_by, deleted_at, deleted_by,
+    valid_from)
+SELECT
+    id, version, title, description, status,
+    created_at, created_by, updated_at, updated_by, deleted_at, deleted_by,
+    COALESCE(deleted_at, updated_at, created_at, SYSTIMESTAMP)
+FROM temp.tasks;
@@ -0,0 +1,18 @@
+DECLARE
+    indexed NUMBER;
+BEGIN
+    SELECT COUNT(*) INTO indexed
+    FROM all_indexes
+    WHERE owner = 'TEMP' AND index_name = 'TASKS_SEARCH_IDX';
+    IF indexed = 0 THEN
+        RETURN;
+    END IF;
+
+    EXECUTE IMMEDIATE 'DROP INDEX temp.tasks_search_idx';
+    EXECUTE IMMEDIATE q'[
+        BEGIN
+            CTX_DDL.DROP_PREFERENCE('task_search_wordlist');
+            CTX_DDL.DROP_SECTION_GROUP('task_search_sections');
+            CTX_DDL.DROP_PREFERENCE('task_search_datastore');
+        END;]';
+END;
@@ -0,0 +1,37 @@
+-- Oracle Text is an optional component. Without it the index is not created
+-- and TASK.SEARCH.BACKEND=auto falls back to the in-process index.
+DECLARE
+    available NUMBER;
+BEGIN
+    SELECT COUNT(*) INTO available
+    FROM all_objects
+    WHERE owner = 'CTXSYS' AND object_name = 'CTX_DDL' AND object_type = 'PACKAGE';
+    IF available = 0 THEN
+        RETURN;
+    END IF;
+
+    -- title and description are indexed together, each in its own section
+    -- so that title matches can be weighted
+    EXECUTE IMMEDIATE q'[
+        BEGIN
+            CTX_DDL.CREATE_PREFERENCE('task_search_datastore', 'MULTI_COLUMN_DATASTORE');
+            CTX_DDL.SET_ATTRIBUTE('task_search_datastore', 'COLUMNS', 'title, description');
+            CTX_DDL.CREATE_SECTION_GROUP('task_search_sections', 'BASIC_SECTION_GROUP');
+            CTX_DDL.ADD_FIELD_SECTION('task_search_sections', 'title', 'title', TRUE);
+            CTX_DDL.ADD_FIELD_SECTION('task_search_sections', 'description', 'description', TRUE);
+            CTX_DDL.CREATE_PREFERENCE('task_search_wordlist', 'BASIC_WORDLIST');
+            CTX_DDL.SET_ATTRIBUTE('task_search_wordlist', 'PREFIX_INDEX', 'TRUE');
+            CTX_DDL.SET_ATTRIBUTE('task_search_wordlist', 'PREFIX_MIN_LENGTH', '2');
+            CTX_DDL.SET_ATTRIBUTE('task_search_wordlist', 'PREFIX_MAX_LENGTH', '12');
+        END;]';
+
+    -- the application removes stop words itself, for English and Indonesian
+    EXECUTE IMMEDIATE q'[
+        CREATE INDEX temp.tasks_search_idx ON temp.tasks (title)
+        INDEXTYPE IS CTXSYS.CONTEXT
+        PARAMETERS ('DATASTORE task_search_datastore
+                     SECTION GROUP task_search_sections
+                     WORDLIST task_search_wordlist
+                     STOPLIST CTXSYS.EMPTY_STOPLIST
+                     SYNC (ON COMMIT)')]';
+END;
@@ -0,0 +1,5 @@
+DROP INDEX temp.tasks_due_idx;
+
+ALTER TABLE temp.tasks_versions DROP (due_at, priority, completed_at);
+
+ALTER TABLE temp.tasks DROP (due_at, priority, completed_at);
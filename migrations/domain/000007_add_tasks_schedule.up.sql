@@ -0,0 +1,18 @@
+ALTER TABLE temp.tasks ADD (
+    due_at       TIMESTAMP,
+    priority     VARCHAR2(16),
+    completed_at TIMESTAMP
+);
+
+ALTER TABLE temp.tasks_versions ADD (
+    due_at       TIMESTAMP,
+    priority     VARCHAR2(16),
+    completed_at TIMESTAMP
+);
+
+-- serves the overdue view: open tasks by due date
+CREATE INDEX tasks_due_idx ON temp.tasks (due_at, completed_at);
+
+-- tasks completed before completion was recorded count from their last update
+UPDATE temp.tasks SET completed_at = COALESCE(updated_at, created_at) WHERE status = 'completed';
+UPDATE temp.tasks_versions SET completed_at = COALESCE(updated_at, created_at) WHERE status = 'completed';
@@ -0,0 +1,10 @@
+DROP TABLE temp.task_assignees;
+
+ALTER TABLE temp.tasks_versions DROP (team_id, assignees);
+
+DROP INDEX temp.tasks_team_idx;
+
+ALTER TABLE temp.tasks DROP CONSTRAINT tasks_team_fk;
+ALTER TABLE temp.tasks DROP (team_id, assignees);
+
+DROP TABLE temp.teams;
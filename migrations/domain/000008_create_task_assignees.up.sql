@@ -0,0 +1,34 @@
+CREATE TABLE temp.teams (
+    id         NUMBER(19)    GENERATED BY DEFAULT AS IDENTITY,
+    name       VARCHAR2(128) NOT NULL,
+    created_at TIMESTAMP     DEFAULT SYSTIMESTAMP NOT NULL,
+    CONSTRAINT teams_pk PRIMARY KEY (id),
+    CONSTRAINT teams_name_uk UNIQUE (name)
+);
+
+ALTER TABLE temp.tasks ADD (
+    team_id   NUMBER(19),
+    assignees VARCHAR2(1000),
+    CONSTRAINT tasks_team_fk FOREIGN KEY (team_id) REFERENCES temp.teams (id)
+);
+
+CREATE INDEX tasks_team_idx ON temp.tasks (team_id);
+
+-- versions keep who a task was assigned to, as the assignments only hold the
+-- current ones
+ALTER TABLE temp.tasks_versions ADD (
+    team_id   NUMBER(19),
+    assignees VARCHAR2(1000)
+);
+
+CREATE TABLE temp.task_assignees (
+    task_id     VARCHAR2(36) NOT NULL,
+    user_id     NUMBER(19)   NOT NULL,
+    assigned_by VARCHAR2(64),
+    assigned_at TIMESTAMP    NOT NULL,
+    CONSTRAINT task_assignees_pk PRIMARY KEY (task_id, user_id),
+    CONSTRAINT task_assignees_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE CASCADE
+);
+
+-- serves "my tasks"
+CREATE INDEX task_assignees_user_idx ON temp.task_assignees (user_id, task_id);
@@ -0,0 +1,5 @@
+ALTER TABLE temp.tasks_versions DROP (labels);
+
+DROP TABLE temp.task_labels;
+
+DROP TABLE temp.labels;
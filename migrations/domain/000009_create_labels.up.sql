@@ -0,0 +1,26 @@
+CREATE TABLE temp.labels (
+    id          VARCHAR2(36)  NOT NULL,
+    name        VARCHAR2(64)  NOT NULL,
+    color       VARCHAR2(9)   NOT NULL,
+    description VARCHAR2(512),
+    created_at  TIMESTAMP     NOT NULL,
+    updated_at  TIMESTAMP,
+    CONSTRAINT labels_pk PRIMARY KEY (id)
+);
+
+-- label names are unique regardless of case
+CREATE UNIQUE INDEX labels_name_uk ON temp.labels (UPPER(name));
+
+CREATE TABLE temp.task_labels (
+    task_id  VARCHAR2(36) NOT NULL,
+    label_id VARCHAR2(36) NOT NULL,
+    CONSTRAINT task_labels_pk PRIMARY KEY (task_id, label_id),
+    CONSTRAINT task_labels_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE CASCADE,
+    CONSTRAINT task_labels_label_fk FOREIGN KEY (label_id) REFERENCES temp.labels (id) ON DELETE CASCADE
+);
+
+-- serves the label filters, which look tasks up by label
+CREATE INDEX task_labels_label_idx ON temp.task_labels (label_id, task_id);
+
+-- versions keep the labels a task carried, as a JSON array
+ALTER TABLE temp.tasks_versions ADD (labels VARCHAR2(4000));
@@ -0,0 +1,8 @@
+DROP TABLE temp.task_checklist_items;
+
+ALTER TABLE temp.tasks_versions DROP (parent_id);
+
+DROP INDEX temp.tasks_parent_idx;
+
+ALTER TABLE temp.tasks DROP CONSTRAINT tasks_parent_fk;
+ALTER TABLE temp.tasks DROP (parent_id);
@@ -0,0 +1,25 @@
+ALTER TABLE temp.tasks ADD (
+    parent_id VARCHAR2(36),
+    CONSTRAINT tasks_parent_fk FOREIGN KEY (parent_id) REFERENCES temp.tasks (id)
+);
+
+-- serves the children of a task and walking its subtree
+CREATE INDEX tasks_parent_idx ON temp.tasks (parent_id);
+
+ALTER TABLE temp.tasks_versions ADD (
+    parent_id VARCHAR2(36)
+);
+
+CREATE TABLE temp.task_checklist_items (
+    id         VARCHAR2(36)  NOT NULL,
+    task_id    VARCHAR2(36)  NOT NULL,
+    title      VARCHAR2(255) NOT NULL,
+    position   NUMBER(10)    NOT NULL,
+    done_at    TIMESTAMP,
+    created_at TIMESTAMP     NOT NULL,
+    updated_at TIMESTAMP,
+    CONSTRAINT task_checklist_items_pk PRIMARY KEY (id),
+    CONSTRAINT task_checklist_items_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_checklist_items_task_idx ON temp.task_checklist_items (task_id, position);
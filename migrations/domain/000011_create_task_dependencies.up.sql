@@ -0,0 +1,14 @@
+-- a task is blocked by its blockers until they are completed
+CREATE TABLE temp.task_dependencies (
+    task_id    VARCHAR2(36) NOT NULL,
+    blocker_id VARCHAR2(36) NOT NULL,
+    created_by VARCHAR2(64),
+    created_at TIMESTAMP    NOT NULL,
+    CONSTRAINT task_dependencies_pk PRIMARY KEY (task_id, blocker_id),
+    CONSTRAINT task_dependencies_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE CASCADE,
+    CONSTRAINT task_dependencies_blocker_fk FOREIGN KEY (blocker_id) REFERENCES temp.tasks (id) ON DELETE CASCADE,
+    CONSTRAINT task_dependencies_self_ck CHECK (task_id <> blocker_id)
+);
+
+-- serves walking the graph downstream
+CREATE INDEX task_dependencies_blocker_idx ON temp.task_dependencies (blocker_id, task_id);
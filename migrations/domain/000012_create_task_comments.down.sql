@@ -0,0 +1,2 @@
+DROP TABLE temp.task_comment_edits;
+DROP TABLE temp.task_comments;
@@ -0,0 +1,30 @@
+-- the discussion of a task; deleted comments are kept, marked by deleted_at
+CREATE TABLE temp.task_comments (
+    id         VARCHAR2(36)   NOT NULL,
+    task_id    VARCHAR2(36)   NOT NULL,
+    body       VARCHAR2(4000) NOT NULL,
+    author     VARCHAR2(64),
+    mentions   VARCHAR2(4000),
+    created_at TIMESTAMP      NOT NULL,
+    updated_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR2(64),
+    version    NUMBER(19)     DEFAULT 1 NOT NULL,
+    CONSTRAINT task_comments_pk PRIMARY KEY (id),
+    CONSTRAINT task_comments_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_comments_task_idx ON temp.task_comments (task_id, created_at);
+
+-- the bodies comments had before each of their edits
+CREATE TABLE temp.task_comment_edits (
+    id         VARCHAR2(36)   NOT NULL,
+    comment_id VARCHAR2(36)   NOT NULL,
+    body       VARCHAR2(4000) NOT NULL,
+    edited_by  VARCHAR2(64),
+    edited_at  TIMESTAMP      NOT NULL,
+    CONSTRAINT task_comment_edits_pk PRIMARY KEY (id),
+    CONSTRAINT task_comment_edits_comment_fk FOREIGN KEY (comment_id) REFERENCES temp.task_comments (id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_comment_edits_comment_idx ON temp.task_comment_edits (comment_id, edited_at);
@@ -0,0 +1 @@
+DROP TABLE temp.task_attachments;
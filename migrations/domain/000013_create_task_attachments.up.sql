@@ -0,0 +1,15 @@
+-- the metadata of files attached to tasks; the files are kept in storage
+CREATE TABLE temp.task_attachments (
+    id           VARCHAR2(36)  NOT NULL,
+    task_id      VARCHAR2(36)  NOT NULL,
+    file_name    VARCHAR2(255) NOT NULL,
+    content_type VARCHAR2(255) NOT NULL,
+    file_size    NUMBER(19)    NOT NULL,
+    storage_key  VARCHAR2(512) NOT NULL,
+    uploaded_by  VARCHAR2(64),
+    created_at   TIMESTAMP     NOT NULL,
+    CONSTRAINT task_attachments_pk PRIMARY KEY (id),
+    CONSTRAINT task_attachments_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE CASCADE
+);
+
+CREATE INDEX task_attachments_task_idx ON temp.task_attachments (task_id, created_at);
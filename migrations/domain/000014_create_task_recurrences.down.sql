@@ -0,0 +1,2 @@
+DROP TABLE temp.task_occurrences;
+DROP TABLE temp.task_recurrences;
@@ -0,0 +1,37 @@
+-- templates of tasks repeating on an RFC 5545 RRULE schedule; the times of
+-- occurrences are kept in UTC, their timezone alongside
+CREATE TABLE temp.task_recurrences (
+    id                 VARCHAR2(36)  NOT NULL,
+    template           CLOB          NOT NULL,
+    rrule              VARCHAR2(512) NOT NULL,
+    timezone           VARCHAR2(64)  NOT NULL,
+    starts_at          TIMESTAMP     NOT NULL,
+    exdates            VARCHAR2(4000),
+    generation         VARCHAR2(16)  NOT NULL,
+    lead_minutes       NUMBER(10)    DEFAULT 0 NOT NULL,
+    next_occurrence_at TIMESTAMP,
+    last_task_id       VARCHAR2(36),
+    created_by         VARCHAR2(64),
+    created_at         TIMESTAMP     NOT NULL,
+    updated_at         TIMESTAMP,
+    version            NUMBER(19)    DEFAULT 1 NOT NULL,
+    CONSTRAINT task_recurrences_pk PRIMARY KEY (id),
+    CONSTRAINT task_recurrences_last_task_fk FOREIGN KEY (last_task_id) REFERENCES temp.tasks (id) ON DELETE SET NULL,
+    CONSTRAINT task_recurrences_generation_ck CHECK (generation IN ('schedule', 'completion'))
+);
+
+CREATE INDEX task_recurrences_next_idx ON temp.task_recurrences (next_occurrence_at);
+CREATE INDEX task_recurrences_last_task_idx ON temp.task_recurrences (last_task_id);
+
+-- the occurrences that became tasks; the primary key materializes each once
+CREATE TABLE temp.task_occurrences (
+    recurrence_id VARCHAR2(36) NOT NULL,
+    occurrence_at TIMESTAMP    NOT NULL,
+    task_id       VARCHAR2(36),
+    created_at    TIMESTAMP    NOT NULL,
+    CONSTRAINT task_occurrences_pk PRIMARY KEY (recurrence_id, occurrence_at),
+    CONSTRAINT task_occurrences_recurrence_fk FOREIGN KEY (recurrence_id) REFERENCES temp.task_recurrences (id) ON DELETE CASCADE,
+    CONSTRAINT task_occurrences_task_fk FOREIGN KEY (task_id) REFERENCES temp.tasks (id) ON DELETE SET NULL
+);
+
+CREATE INDEX task_occurrences_task_idx ON temp.task_occurrences (task_id);
@@ -0,0 +1,7 @@
+DROP INDEX temp.outbox_events_aggregate_idx;
+DROP INDEX temp.outbox_events_pending_idx;
+
+ALTER TABLE temp.outbox_events DROP CONSTRAINT outbox_events_status_ck;
+ALTER TABLE temp.outbox_events DROP COLUMN status;
+
+CREATE INDEX outbox_events_pending_idx ON temp.outbox_events (published_at, seq);
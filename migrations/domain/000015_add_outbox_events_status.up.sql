@@ -0,0 +1,13 @@
+-- events whose deliveries failed too often are dead instead of pending forever
+ALTER TABLE temp.outbox_events ADD (
+    status VARCHAR2(16) DEFAULT 'pending' NOT NULL,
+    CONSTRAINT outbox_events_status_ck CHECK (status IN ('pending', 'published', 'dead'))
+);
+
+UPDATE temp.outbox_events SET status = 'published' WHERE published_at IS NOT NULL;
+
+DROP INDEX temp.outbox_events_pending_idx;
+CREATE INDEX outbox_events_pending_idx ON temp.outbox_events (status, seq);
+
+-- serves the check for earlier events of an aggregate waiting for a retry
+CREATE INDEX outbox_events_aggregate_idx ON temp.outbox_events (aggregate_type, aggregate_id, status, seq);
@@ -0,0 +1,92 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tarkiman/go/configs"
+)
+
+// Purposes of the tokens a Signer signs. A token signed for one purpose does
+// not verify for another.
+const (
+	PurposeTaskCursor  = "task-cursor"
+	PurposeReadPrimary = "read-primary"
+)
+
+// ErrInvalid is returned for tokens that are malformed or were not signed by
+// this Signer.
+var ErrInvalid = errors.New("cursor is invalid")
+
+// Signer turns pagination positions, and other state handed to clients such
+// as read-after-write tokens, into opaque tokens that clients cannot forge or
+// alter.
+type Signer struct {
+	secret []byte
+}
+
+// ProvideSigner is the provider for Signer. Without a configured secret a
+// random one is used, so tokens only stay valid on this instance until it
+// restarts.
+func ProvideSigner(config *configs.Config) *Signer {
+	secret := []byte(config.App.CursorSecret)
+	if len(secret) == 0 {
+		log.Warn().Msg("APP.CURSOR_SECRET is not set, pagination cursors and read-after-write tokens are only valid on this instance.")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal().Err(err).Msg("Failed generating cursor secret")
+		}
+	}
+	return &Signer{secret: secret}
+}
+
+// For returns a Signer for tokens of the given purpose, keyed with a secret
+// derived from this one so that tokens cannot be used for another purpose.
+func (s *Signer) For(purpose string) *Signer {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose))
+	return &Signer{secret: mac.Sum(nil)}
+}
+
+// Sign encodes payload as JSON and signs it.
+func (s *Signer) Sign(payload interface{}) (token string, err error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	body := base64.RawURLEncoding.EncodeToString(encoded)
+	return body + "." + base64.RawURLEncoding.EncodeToString(s.mac(body)), nil
+}
+
+// Verify checks the signature of token and decodes its payload.
+func (s *Signer) Verify(token string, payload interface{}) (err error) {
+	body, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalid
+	}
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, s.mac(body)) {
+		return ErrInvalid
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return ErrInvalid
+	}
+	if err = json.Unmarshal(decoded, payload); err != nil {
+		return ErrInvalid
+	}
+	return
+}
+
+func (s *Signer) mac(body string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
@@ -3,14 +3,17 @@ package failure
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 )
 
 // Failure is a wrapper for error messages and codes using standard HTTP response codes.
 type Failure struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Err     error       `json:"-"`
-	Data    interface{} `json:"data,omitempty"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Err       error       `json:"-"`
+	Data      interface{} `json:"data,omitempty"`
+	Retryable bool        `json:"-"`
 }
 
 // Error returns the error code and message in a formatted string.
@@ -37,6 +40,26 @@ func BadRequestFromString(msg string) error {
 	}
 }
 
+// InvalidFields returns a new Failure with code for bad requests, listing the
+// message of every invalid field in Data.
+func InvalidFields(fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, name+" "+fields[name])
+	}
+	return &Failure{
+		Code:    http.StatusBadRequest,
+		Message: "invalid fields: " + strings.Join(messages, "; "),
+		Data:    fields,
+	}
+}
+
 // Unauthorized returns a new Failure with code for unauthorized requests.
 func Unauthorized(msg string) error {
 	return &Failure{
@@ -80,6 +103,75 @@ func Conflict(operationName string, entityName string, message string) error {
 	}
 }
 
+// ConflictWithData returns a new Failure with code for conflict situations,
+// with data telling the client how to resolve the conflict.
+func ConflictWithData(operationName string, entityName string, message string, data interface{}) error {
+	return &Failure{
+		Code:    http.StatusConflict,
+		Message: fmt.Sprintf("%s on %s: %s", operationName, entityName, message),
+		Data:    data,
+	}
+}
+
+// Forbidden returns a new Failure with code for requests the user is not allowed to make.
+func Forbidden(msg string) error {
+	return &Failure{
+		Code:    http.StatusForbidden,
+		Message: msg,
+	}
+}
+
+// PreconditionFailed returns a new Failure with code for requests whose precondition, such as If-Match, does not hold.
+func PreconditionFailed(operationName string, entityName string, message string) error {
+	return &Failure{
+		Code:    http.StatusPreconditionFailed,
+		Message: fmt.Sprintf("%s on %s: %s", operationName, entityName, message),
+	}
+}
+
+// PreconditionRequired returns a new Failure with code for requests missing a mandatory precondition.
+func PreconditionRequired(msg string) error {
+	return &Failure{
+		Code:    http.StatusPreconditionRequired,
+		Message: msg,
+	}
+}
+
+// RequestEntityTooLarge returns a new Failure with code for request bodies exceeding their size limit.
+func RequestEntityTooLarge(msg string) error {
+	return &Failure{
+		Code:    http.StatusRequestEntityTooLarge,
+		Message: msg,
+	}
+}
+
+// GatewayTimeout returns a new Failure with code for operations that exceeded their deadline.
+func GatewayTimeout(operationName string) error {
+	return &Failure{
+		Code:    http.StatusGatewayTimeout,
+		Message: fmt.Sprintf("%s: deadline exceeded", operationName),
+	}
+}
+
+// Retryable returns a new Failure for transient conflicts, such as deadlocks,
+// after which the client can safely retry the same request.
+func Retryable(operationName string, entityName string, err error) error {
+	return &Failure{
+		Code:      http.StatusServiceUnavailable,
+		Message:   fmt.Sprintf("%s on %s: temporarily unavailable, please retry", operationName, entityName),
+		Err:       err,
+		Retryable: true,
+	}
+}
+
+// IsRetryable reports whether an error interface is a retryable Failure.
+func IsRetryable(err error) bool {
+	if f, ok := err.(*Failure); ok {
+		return f.Retryable
+	}
+	return false
+}
+
 // GetCode returns the error code of an error interface.
 func GetCode(err error) int {
 	if f, ok := err.(*Failure); ok {
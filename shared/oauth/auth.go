@@ -1,6 +1,8 @@
 package oauth
 
 import (
+	"context"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/tarkiman/go/shared/failure"
 )
@@ -48,8 +50,13 @@ func (t *Token) Create(credential Credential) (*TokenResponse, error) {
 }
 
 // ParseWithAccessToken is function to exchange valid token into token info
-func (t *Token) ParseWithAccessToken(accessToken string, method string, endpoint string) (OauthAccessToken, error) {
-	return NewParser(t.tokenRepository).Parse(accessToken, method, endpoint)
+func (t *Token) ParseWithAccessToken(ctx context.Context, accessToken string, method string, endpoint string) (OauthAccessToken, error) {
+	return NewParser(t.tokenRepository).Parse(ctx, accessToken, method, endpoint)
+}
+
+// ResolveRoles is function to get the names of the roles of a user
+func (t *Token) ResolveRoles(ctx context.Context, userID string) ([]string, error) {
+	return t.tokenRepository.resolveRoleNamesByUserID(ctx, userID)
 }
 
 // ClientScopeAllowed is function that is used to limit the client
@@ -6,6 +6,7 @@ import (
 
 	"github.com/guregu/null"
 	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
 )
 
 type RefreshCredentialsAuth struct {
@@ -73,5 +74,5 @@ func (a *TokenStore) extendRefreshTokeLifetimeWithTx(tx *sqlx.Tx, refreshToken s
 		"expires":       expires,
 	})
 
-	return
+	return infras.TranslateError("extendRefreshToken", "OauthRefreshToken", err)
 }
@@ -1,6 +1,7 @@
 package oauth
 
 import (
+	"context"
 	"errors"
 	"strings"
 )
@@ -15,7 +16,7 @@ func NewParser(tokenStore TokenStore) *Parser {
 	}
 }
 
-func (p *Parser) Parse(accessToken string, method string, endpoint string) (accessTokenClient OauthAccessToken, err error) {
+func (p *Parser) Parse(ctx context.Context, accessToken string, method string, endpoint string) (accessTokenClient OauthAccessToken, err error) {
 	valid := p.validToken(accessToken)
 	if !valid {
 		err = errors.New(ErrorEmptyCredential)
@@ -29,7 +30,7 @@ func (p *Parser) Parse(accessToken string, method string, endpoint string) (acce
 		return
 	}
 
-	accessTokenClient, err = p.TokenStore.resolveAccessTokenByAccessTokenAndEndpoint(token[1], method, endpoint)
+	accessTokenClient, err = p.TokenStore.resolveAccessTokenByAccessTokenAndEndpoint(ctx, token[1], method, endpoint)
 	if err != nil {
 		return
 	}
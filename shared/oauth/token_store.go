@@ -1,11 +1,13 @@
 package oauth
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/tarkiman/go/infras"
 	"github.com/tarkiman/go/shared/failure"
 )
 
@@ -78,6 +80,13 @@ const (
 				password
 			FROM
 				user`
+
+	querySelectRoleNames = `
+			SELECT
+				r.name
+			FROM user_role ur
+			JOIN role r ON r.id=ur.id_role
+			WHERE ur.id_user=?`
 )
 
 func NewTokenStore(db *sqlx.DB) TokenStore {
@@ -89,13 +98,13 @@ func NewTokenStore(db *sqlx.DB) TokenStore {
 func (a *TokenStore) createAccessToken(accessToken OauthAccessToken) error {
 	stmt, err := a.db.PrepareNamed(queryInsertAccessToken)
 	if err != nil {
-		return err
+		return infras.TranslateError("createAccessToken", "OauthAccessToken", err)
 	}
 	defer stmt.Close()
 
 	_, err = stmt.Exec(accessToken)
 	if err != nil {
-		return err
+		return infras.TranslateError("createAccessToken", "OauthAccessToken", err)
 	}
 
 	return nil
@@ -108,31 +117,41 @@ func (a *TokenStore) resolveAccessTokenByAccessToken(accessToken string) (oauthA
 		err = errors.New(ErrorClientNotFound)
 		return
 	case err != nil:
+		err = infras.TranslateError("resolveAccessToken", "OauthAccessToken", err)
 		return
 	}
 
 	return
 }
 
-func (a *TokenStore) resolveAccessTokenByAccessTokenAndEndpoint(accessToken string, method string, endpoint string) (oauthAccessToken OauthAccessToken, err error) {
-	err = a.db.Get(&oauthAccessToken, querySelectAccessTokenAndEndpoint+" WHERE oat.access_token=? AND p.method=? AND ? LIKE CONCAT(p.endpoint,'%') ORDER BY oat.access_token DESC LIMIT 1", accessToken, method, endpoint)
+func (a *TokenStore) resolveAccessTokenByAccessTokenAndEndpoint(ctx context.Context, accessToken string, method string, endpoint string) (oauthAccessToken OauthAccessToken, err error) {
+	err = a.db.GetContext(ctx, &oauthAccessToken, querySelectAccessTokenAndEndpoint+" WHERE oat.access_token=? AND p.method=? AND ? LIKE CONCAT(p.endpoint,'%') ORDER BY oat.access_token DESC LIMIT 1", accessToken, method, endpoint)
 	switch {
 	case err == sql.ErrNoRows:
 		err = errors.New(ErrorClientNotFound)
 		return
 	case err != nil:
+		err = infras.TranslateError("resolveAccessToken", "OauthAccessToken", err)
 		return
 	}
 
 	return
 }
 
+func (a *TokenStore) resolveRoleNamesByUserID(ctx context.Context, userID string) (roles []string, err error) {
+	err = a.db.SelectContext(ctx, &roles, a.db.Rebind(querySelectRoleNames), userID)
+	if err != nil {
+		err = infras.TranslateError("resolveRoles", "Role", err)
+	}
+	return
+}
+
 func (a *TokenStore) resolveAllClients(db *sqlx.DB) ([]OauthClient, error) {
 	var clients []OauthClient
 
 	err := a.db.Get(&clients, querySelectClients)
 	if err != nil {
-		return []OauthClient{}, err
+		return []OauthClient{}, infras.TranslateError("resolveClients", "OauthClient", err)
 	}
 
 	return clients, nil
@@ -145,6 +164,7 @@ func (a *TokenStore) resolveClientByClientID(clientID string) (client OauthClien
 		err = errors.New(ErrorClientNotFound)
 		return
 	case err != nil:
+		err = infras.TranslateError("resolveClient", "OauthClient", err)
 		return
 	}
 
@@ -159,7 +179,7 @@ func (a *TokenStore) resolveByTelephoneOrEmail(username string) (User, error) {
 	case err == sql.ErrNoRows:
 		return User{}, errors.New(ErrorClientNotFound)
 	case err != nil:
-		return User{}, err
+		return User{}, infras.TranslateError("resolveUser", "User", err)
 	}
 
 	return user, nil
@@ -187,13 +207,13 @@ func (a *TokenStore) WithTransaction(block func(db *sqlx.Tx, c chan error)) (err
 func (a *TokenStore) createRefreshTokenWithTx(tx *sqlx.Tx, refreshToken OauthRefreshToken) error {
 	stmt, err := tx.PrepareNamed(queryInsertRefreshToken)
 	if err != nil {
-		return err
+		return infras.TranslateError("createRefreshToken", "OauthRefreshToken", err)
 	}
 	defer stmt.Close()
 
 	_, err = stmt.Exec(refreshToken)
 	if err != nil {
-		return err
+		return infras.TranslateError("createRefreshToken", "OauthRefreshToken", err)
 	}
 
 	return nil
@@ -202,13 +222,13 @@ func (a *TokenStore) createRefreshTokenWithTx(tx *sqlx.Tx, refreshToken OauthRef
 func (a *TokenStore) createAccessTokenWithTx(tx *sqlx.Tx, accessToken OauthAccessToken) error {
 	stmt, err := tx.PrepareNamed(queryInsertAccessToken)
 	if err != nil {
-		return err
+		return infras.TranslateError("createAccessToken", "OauthAccessToken", err)
 	}
 	defer stmt.Close()
 
 	_, err = stmt.Exec(accessToken)
 	if err != nil {
-		return err
+		return infras.TranslateError("createAccessToken", "OauthAccessToken", err)
 	}
 
 	return nil
@@ -221,5 +241,5 @@ func (a *TokenStore) extendAccessTokenLifetimeWithTx(tx *sqlx.Tx, accessToken st
 		"expires":      expires,
 	})
 
-	return
+	return infras.TranslateError("extendAccessToken", "OauthAccessToken", err)
 }
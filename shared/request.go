@@ -0,0 +1,43 @@
+package shared
+
+import "context"
+
+type actorKey struct{}
+
+type requestIDKey struct{}
+
+type rolesKey struct{}
+
+// WithActor returns a copy of ctx carrying the ID of the user making the request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the ID of the user making the request, if known.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// WithRoles returns a copy of ctx carrying the roles of the user making the request.
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles of the user making the request, if
+// authenticated.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the ID of the request, if known.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
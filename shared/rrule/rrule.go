@@ -0,0 +1,470 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a Rule.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+const (
+	// MaxLength is the longest rule accepted, in bytes.
+	MaxLength = 512
+	// maxPeriods is the most periods walked looking for an occurrence, so that
+	// rules that never match, such as the 30th of February, end.
+	maxPeriods = 10000
+)
+
+var (
+	// ErrInvalid is returned for rules that are malformed or use parts of
+	// RFC 5545 that are not supported.
+	ErrInvalid = errors.New("invalid recurrence rule")
+
+	weekdays = map[string]time.Weekday{
+		"SU": time.Sunday,
+		"MO": time.Monday,
+		"TU": time.Tuesday,
+		"WE": time.Wednesday,
+		"TH": time.Thursday,
+		"FR": time.Friday,
+		"SA": time.Saturday,
+	}
+)
+
+// WeekdayNum is a day of the week of a BYDAY list. A non-zero N picks the Nth
+// such day of the month or year, counting from its end when negative.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule is an RFC 5545 recurrence rule. Occurrences are whole days at the time
+// of day of the start, so the BYHOUR, BYMINUTE and BYSECOND parts are not
+// supported, nor are BYYEARDAY, BYWEEKNO and BYSETPOS.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+
+	until         time.Time
+	untilSet      bool
+	untilLocal    bool
+	untilEndOfDay bool
+}
+
+// Parse parses a rule such as "FREQ=MONTHLY;BYDAY=-1FR", with or without its
+// "RRULE:" prefix.
+func Parse(text string) (rule Rule, err error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	text = strings.TrimPrefix(text, "RRULE:")
+	if text == "" || len(text) > MaxLength {
+		return rule, fmt.Errorf("%w: must be between 1 and %d characters", ErrInvalid, MaxLength)
+	}
+
+	rule = Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(text, ";") {
+		name, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return rule, fmt.Errorf("%w: %q is not a NAME=VALUE part", ErrInvalid, part)
+		}
+		if seen[name] {
+			return rule, fmt.Errorf("%w: %s is given twice", ErrInvalid, name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = value
+			default:
+				return rule, fmt.Errorf("%w: FREQ=%s is not supported", ErrInvalid, value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			rule.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			err = rule.parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "BYMONTH":
+			rule.ByMonth, err = parseByMonth(value)
+		case "WKST":
+			weekday, ok := weekdays[value]
+			if !ok {
+				return rule, fmt.Errorf("%w: WKST=%s is not a weekday", ErrInvalid, value)
+			}
+			rule.WeekStart = weekday
+		default:
+			return rule, fmt.Errorf("%w: %s is not supported", ErrInvalid, name)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	err = rule.validate()
+	return
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return fmt.Errorf("%w: FREQ is required", ErrInvalid)
+	}
+	if r.Count > 0 && r.untilSet {
+		return fmt.Errorf("%w: COUNT and UNTIL cannot be given together", ErrInvalid)
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return fmt.Errorf("%w: BYMONTHDAY cannot be given with FREQ=WEEKLY", ErrInvalid)
+	}
+	for _, day := range r.ByDay {
+		if day.N == 0 {
+			continue
+		}
+		if r.Freq == Daily || r.Freq == Weekly {
+			return fmt.Errorf("%w: numbered BYDAY needs FREQ=MONTHLY or FREQ=YEARLY", ErrInvalid)
+		}
+		if (r.Freq == Monthly || len(r.ByMonth) > 0) && (day.N > 5 || day.N < -5) {
+			return fmt.Errorf("%w: a month has at most 5 of each weekday", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// parseUntil reads UNTIL as a UTC date-time ("20240131T090000Z"), a local
+// date-time ("20240131T090000") or a date ("20240131"), which includes the
+// whole day. Local values are resolved in the location of the start.
+func (r *Rule) parseUntil(value string) (err error) {
+	layout := "20060102T150405Z"
+	switch {
+	case len(value) == len("20060102"):
+		layout = "20060102"
+		r.untilLocal, r.untilEndOfDay = true, true
+	case !strings.HasSuffix(value, "Z"):
+		layout = "20060102T150405"
+		r.untilLocal = true
+	}
+	r.until, err = time.Parse(layout, value)
+	if err != nil {
+		return fmt.Errorf("%w: UNTIL=%s is not a date or date-time", ErrInvalid, value)
+	}
+	r.untilSet = true
+	return
+}
+
+func parsePositive(name string, value string) (n int, err error) {
+	n, err = strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive number", ErrInvalid, name)
+	}
+	return n, nil
+}
+
+func parseByDay(value string) (days []WeekdayNum, err error) {
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%w: BYDAY %q is not a weekday", ErrInvalid, item)
+		}
+		weekday, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%w: BYDAY %q is not a weekday", ErrInvalid, item)
+		}
+		day := WeekdayNum{Weekday: weekday}
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			day.N, err = strconv.Atoi(strings.TrimPrefix(ordinal, "+"))
+			if err != nil || day.N == 0 || day.N > 53 || day.N < -53 {
+				return nil, fmt.Errorf("%w: BYDAY %q has an invalid ordinal", ErrInvalid, item)
+			}
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseByMonthDay(value string) (days []int, err error) {
+	for _, item := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(strings.TrimPrefix(item, "+"))
+		if err != nil || day == 0 || day > 31 || day < -31 {
+			return nil, fmt.Errorf("%w: BYMONTHDAY %q is not a day of the month", ErrInvalid, item)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseByMonth(value string) (months []time.Month, err error) {
+	for _, item := range strings.Split(value, ",") {
+		month, err := strconv.Atoi(item)
+		if err != nil || month < 1 || month > 12 {
+			return nil, fmt.Errorf("%w: BYMONTH %q is not a month", ErrInvalid, item)
+		}
+		months = append(months, time.Month(month))
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+	return months, nil
+}
+
+// Next returns the first occurrence of the rule strictly after after, for a
+// recurrence starting at start. Occurrences fall at the time of day of start
+// in its location. It returns false once the recurrence has ended.
+func (r Rule) Next(start time.Time, after time.Time) (next time.Time, ok bool) {
+	location := start.Location()
+	until, hasUntil := r.resolveUntil(location)
+
+	period := 0
+	if r.Count == 0 {
+		period = r.periodsBefore(start, after.In(location))
+	}
+	count := 0
+	for i := 0; i < maxPeriods; i, period = i+1, period+1 {
+		for _, day := range r.expand(start, period) {
+			occurrence := at(day, start)
+			if occurrence.Before(start) {
+				continue
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return time.Time{}, false
+			}
+			if hasUntil && occurrence.After(until) {
+				return time.Time{}, false
+			}
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r Rule) resolveUntil(location *time.Location) (until time.Time, ok bool) {
+	if !r.untilSet {
+		return
+	}
+	if !r.untilLocal {
+		return r.until, true
+	}
+	until = time.Date(r.until.Year(), r.until.Month(), r.until.Day(),
+		r.until.Hour(), r.until.Minute(), r.until.Second(), 0, location)
+	if r.untilEndOfDay {
+		until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return until, true
+}
+
+// periodsBefore returns how many whole periods can be skipped between start
+// and after without missing an occurrence. It is only valid without COUNT,
+// which needs every occurrence since start to be counted.
+func (r Rule) periodsBefore(start time.Time, after time.Time) int {
+	if !after.After(start) {
+		return 0
+	}
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = daysBetween(date(start), date(after))
+	case Weekly:
+		periods = daysBetween(date(start), date(after)) / 7
+	case Monthly:
+		periods = (after.Year()-start.Year())*12 + int(after.Month()) - int(start.Month())
+	case Yearly:
+		periods = after.Year() - start.Year()
+	}
+	if skipped := periods/r.Interval - 1; skipped > 0 {
+		return skipped
+	}
+	return 0
+}
+
+// expand returns the days of the given period of the recurrence, in order.
+func (r Rule) expand(start time.Time, period int) (days []time.Time) {
+	first := date(start)
+	switch r.Freq {
+	case Daily:
+		day := first.AddDate(0, 0, period*r.Interval)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(first.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := first.AddDate(0, 0, period*r.Interval*7-offset)
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if r.matchesMonth(day) && r.inWeek(day, start.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := time.Date(first.Year(), first.Month()+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(month) {
+			days = r.monthDays(month, start.Day())
+		}
+	case Yearly:
+		year := first.Year() + period*r.Interval
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.ByMonth {
+				days = append(days, r.monthDays(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), start.Day())...)
+			}
+		case len(r.ByMonthDay) > 0:
+			for month := time.January; month <= time.December; month++ {
+				days = append(days, r.monthDays(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), start.Day())...)
+			}
+		case len(r.ByDay) > 0:
+			january := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			days = r.spanWeekdays(january, daysBetween(january, january.AddDate(1, 0, 0)))
+		default:
+			day := time.Date(year, first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+			if day.Day() == first.Day() {
+				days = append(days, day)
+			}
+		}
+	}
+	return sortedDays(days)
+}
+
+// monthDays returns the days of the month starting at month given by
+// BYMONTHDAY, limited by BYDAY, else by BYDAY, else the day of the start.
+// Days the month does not have are skipped.
+func (r Rule) monthDays(month time.Time, startDay int) (days []time.Time) {
+	length := daysBetween(month, month.AddDate(0, 1, 0))
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay < 0 {
+				monthDay += length + 1
+			}
+			if monthDay < 1 || monthDay > length {
+				continue
+			}
+			day := month.AddDate(0, 0, monthDay-1)
+			if r.matchesWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case len(r.ByDay) > 0:
+		days = r.spanWeekdays(month, length)
+	case startDay <= length:
+		days = append(days, month.AddDate(0, 0, startDay-1))
+	}
+	return
+}
+
+// spanWeekdays returns the days of BYDAY among the length days from first,
+// numbered ones counting within that span.
+func (r Rule) spanWeekdays(first time.Time, length int) (days []time.Time) {
+	for _, weekday := range r.ByDay {
+		var matches []time.Time
+		for day := first.AddDate(0, 0, (int(weekday.Weekday)-int(first.Weekday())+7)%7); daysBetween(first, day) < length; day = day.AddDate(0, 0, 7) {
+			matches = append(matches, day)
+		}
+		switch {
+		case weekday.N == 0:
+			days = append(days, matches...)
+		case weekday.N > 0 && weekday.N <= len(matches):
+			days = append(days, matches[weekday.N-1])
+		case weekday.N < 0 && -weekday.N <= len(matches):
+			days = append(days, matches[len(matches)+weekday.N])
+		}
+	}
+	return
+}
+
+func (r Rule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, month := range r.ByMonth {
+		if day.Month() == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := daysBetween(time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC),
+		time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC))
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || length+monthDay+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// inWeek tells whether a day of a weekly recurrence is one of BYDAY, or the
+// weekday of the start without it.
+func (r Rule) inWeek(day time.Time, startWeekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return day.Weekday() == startWeekday
+	}
+	return r.matchesWeekday(day)
+}
+
+// at returns day at the time of day of start in its location. A time skipped
+// by a daylight saving change is taken with the offset from before the change,
+// as RFC 5545 requires, which moves it forward by the change.
+func at(day time.Time, start time.Time) time.Time {
+	occurrence := time.Date(day.Year(), day.Month(), day.Day(),
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	skipped := time.Duration(start.Hour()-occurrence.Hour())*time.Hour +
+		time.Duration(start.Minute()-occurrence.Minute())*time.Minute
+	if skipped > 0 {
+		occurrence = occurrence.Add(skipped)
+	}
+	return occurrence
+}
+
+// date returns the calendar day of t, as midnight UTC.
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func sortedDays(days []time.Time) []time.Time {
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	unique := days[:0]
+	for _, day := range days {
+		if len(unique) == 0 || !day.Equal(unique[len(unique)-1]) {
+			unique = append(unique, day)
+		}
+	}
+	return unique
+}
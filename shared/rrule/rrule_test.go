@@ -0,0 +1,351 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%s): %v", name, err)
+	}
+	return location
+}
+
+// occurrences returns up to n occurrences of rule after after, and whether the
+// recurrence ended before n were found.
+func occurrences(rule Rule, start time.Time, after time.Time, n int) (found []time.Time, ended bool) {
+	for len(found) < n {
+		next, ok := rule.Next(start, after)
+		if !ok {
+			return found, true
+		}
+		found = append(found, next)
+		after = next
+	}
+	return found, false
+}
+
+func TestNext(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		// after defaults to just before start
+		after time.Time
+		want  []time.Time
+		// ended tells whether the recurrence ends after want
+		ended bool
+	}{
+		{
+			name:  "daily across spring forward keeps the time of day",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2024, 3, 9, 9, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 3, 9, 9, 0, 0, 0, newYork),
+				time.Date(2024, 3, 10, 9, 0, 0, 0, newYork),
+				time.Date(2024, 3, 11, 9, 0, 0, 0, newYork),
+			},
+		},
+		{
+			name:  "daily at a time skipped by spring forward moves forward",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2024, 3, 9, 2, 30, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 3, 9, 2, 30, 0, 0, newYork),
+				time.Date(2024, 3, 10, 7, 30, 0, 0, time.UTC),
+				time.Date(2024, 3, 11, 2, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name:  "daily at a time repeated by fall back takes the first",
+			rule:  "FREQ=DAILY",
+			start: time.Date(2024, 11, 2, 1, 30, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 11, 2, 1, 30, 0, 0, newYork),
+				time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC),
+				time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "weekly across fall back keeps the time of day",
+			rule:  "FREQ=WEEKLY;BYDAY=MO",
+			start: time.Date(2024, 10, 28, 9, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 10, 28, 13, 0, 0, 0, time.UTC),
+				time.Date(2024, 11, 4, 14, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "last Friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 1, 26, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 23, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 29, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 26, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 5, 31, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "second Monday of March every year",
+			rule:  "FREQ=YEARLY;BYMONTH=3;BYDAY=2MO",
+			start: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC),
+				time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "31st of the month skips short months",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 7, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 8, 31, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "monthly from the 31st without BYMONTHDAY skips short months",
+			rule:  "FREQ=MONTHLY",
+			start: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "COUNT counts from the start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			after: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC),
+			},
+			ended: true,
+		},
+		{
+			name:  "COUNT long after the start",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=500",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			after: time.Date(2043, 2, 2, 9, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2043, 2, 16, 9, 0, 0, 0, time.UTC),
+			},
+			ended: true,
+		},
+		{
+			name:  "COUNT ended before after",
+			rule:  "FREQ=MONTHLY;COUNT=2",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			after: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			ended: true,
+		},
+		{
+			name:  "UNTIL as a date includes the whole day",
+			rule:  "FREQ=DAILY;UNTIL=20240103",
+			start: time.Date(2024, 1, 1, 23, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 1, 1, 23, 0, 0, 0, newYork),
+				time.Date(2024, 1, 2, 23, 0, 0, 0, newYork),
+				time.Date(2024, 1, 3, 23, 0, 0, 0, newYork),
+			},
+			ended: true,
+		},
+		{
+			name:  "UNTIL as a local date-time is in the location of the start",
+			rule:  "FREQ=DAILY;UNTIL=20240102T090000",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 1, 1, 9, 0, 0, 0, newYork),
+				time.Date(2024, 1, 2, 9, 0, 0, 0, newYork),
+			},
+			ended: true,
+		},
+		{
+			name:  "UNTIL in UTC",
+			rule:  "FREQ=DAILY;UNTIL=20240102T135959Z",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2024, 1, 1, 9, 0, 0, 0, newYork),
+			},
+			ended: true,
+		},
+		{
+			name:  "WKST=MO from RFC 5545",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			start: time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+				time.Date(1997, 8, 10, 9, 0, 0, 0, newYork),
+				time.Date(1997, 8, 19, 9, 0, 0, 0, newYork),
+				time.Date(1997, 8, 24, 9, 0, 0, 0, newYork),
+			},
+			ended: true,
+		},
+		{
+			name:  "WKST=SU from RFC 5545",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			start: time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(1997, 8, 5, 9, 0, 0, 0, newYork),
+				time.Date(1997, 8, 17, 9, 0, 0, 0, newYork),
+				time.Date(1997, 8, 19, 9, 0, 0, 0, newYork),
+				time.Date(1997, 8, 31, 9, 0, 0, 0, newYork),
+			},
+			ended: true,
+		},
+		{
+			name:  "30th of February never occurs",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			ended: true,
+		},
+		{
+			name:  "31st of April never occurs",
+			rule:  "FREQ=MONTHLY;BYMONTH=4;BYMONTHDAY=31",
+			start: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+			ended: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			after := tt.after
+			if after.IsZero() {
+				after = tt.start.Add(-time.Nanosecond)
+			}
+
+			got, ended := occurrences(rule, tt.start, after, len(tt.want)+1)
+			if !tt.ended && len(got) == len(tt.want)+1 {
+				got, ended = got[:len(tt.want)], false
+			}
+			if ended != tt.ended || len(got) != len(tt.want) {
+				t.Fatalf("got %v, ended %v; want %v, ended %v", got, ended, tt.want, tt.ended)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Fatalf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestNextSkipsAhead checks that skipping the periods before after finds the
+// occurrence that walking every occurrence from the start finds.
+func TestNextSkipsAhead(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	start := time.Date(2023, 1, 31, 8, 15, 0, 0, berlin)
+
+	for _, text := range []string{
+		"FREQ=DAILY;INTERVAL=3",
+		"FREQ=DAILY;BYDAY=MO,WE;BYMONTH=3,10",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH,SU;WKST=SU",
+		"FREQ=MONTHLY;INTERVAL=5;BYMONTHDAY=1,-1",
+		"FREQ=MONTHLY;BYDAY=-1FR,1MO",
+		"FREQ=YEARLY;INTERVAL=2;BYDAY=20MO",
+	} {
+		rule, err := Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", text, err)
+		}
+
+		walked := start.Add(-time.Nanosecond)
+		for _, after := range []time.Time{
+			time.Date(2023, 3, 26, 1, 0, 0, 0, berlin),
+			time.Date(2024, 2, 29, 8, 15, 0, 0, berlin),
+			time.Date(2026, 10, 25, 12, 0, 0, 0, berlin),
+		} {
+			var want time.Time
+			for {
+				next, ok := rule.Next(start, walked)
+				if !ok {
+					t.Fatalf("%s ended before %v", text, after)
+				}
+				walked = next
+				if next.After(after) {
+					want = next
+					break
+				}
+			}
+
+			got, ok := rule.Next(start, after)
+			if !ok || !got.Equal(want) {
+				t.Fatalf("%s after %v = %v, %v; want %v", text, after, got, ok, want)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	rule, err := Parse("rrule:freq=monthly;interval=2;byday=mo,+2tu,-1fr;bymonth=12,1;wkst=su")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if rule.Freq != Monthly || rule.Interval != 2 || rule.WeekStart != time.Sunday {
+		t.Fatalf("Parse = %+v", rule)
+	}
+	wantByDay := []WeekdayNum{{Weekday: time.Monday}, {Weekday: time.Tuesday, N: 2}, {Weekday: time.Friday, N: -1}}
+	if len(rule.ByDay) != len(wantByDay) {
+		t.Fatalf("BYDAY = %v, want %v", rule.ByDay, wantByDay)
+	}
+	for i := range wantByDay {
+		if rule.ByDay[i] != wantByDay[i] {
+			t.Fatalf("BYDAY = %v, want %v", rule.ByDay, wantByDay)
+		}
+	}
+	if len(rule.ByMonth) != 2 || rule.ByMonth[0] != time.January || rule.ByMonth[1] != time.December {
+		t.Fatalf("BYMONTH = %v, want sorted", rule.ByMonth)
+	}
+
+	for _, text := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=DAILY;UNTIL=2024-01-01",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;WKST=XX",
+		"FREQ=DAILY;",
+	} {
+		if _, err := Parse(text); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalid", text, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	// HighlightStart and HighlightEnd enclose the matched words of a highlight.
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+
+	ellipsis = "…"
+)
+
+// Highlight returns text as HTML with the words matching query marked. With a
+// width above zero, only a fragment of about width bytes around the first
+// match is returned. It returns nothing when no word of text matches.
+func Highlight(text string, query Query, width int) string {
+	var matches []Token
+	for _, token := range Tokenize(text) {
+		for _, term := range query.Terms {
+			if term.Matches(token) {
+				matches = append(matches, token)
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	start, end := 0, len(text)
+	if width > 0 && len(text) > width {
+		start = matches[0].Start - width/4
+		if start < 0 {
+			start = 0
+		}
+		end = start + width
+		if end > len(text) {
+			end, start = len(text), len(text)-width
+		}
+		start, end = wordBoundary(text, start, -1), wordBoundary(text, end, 1)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+	position := start
+	for _, match := range matches {
+		if match.Start < start || match.End > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[position:match.Start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(text[match.Start:match.End]))
+		b.WriteString(HighlightEnd)
+		position = match.End
+	}
+	b.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+// wordBoundary moves offset in direction to the nearest space, so that a
+// fragment does not cut a word or a multi-byte character.
+func wordBoundary(text string, offset, direction int) int {
+	for offset > 0 && offset < len(text) && text[offset] != ' ' {
+		offset += direction
+	}
+	return offset
+}
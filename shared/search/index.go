@@ -0,0 +1,214 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// BM25 parameters: k1 limits how much repeating a word raises the score,
+	// b how much longer documents are penalized.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// matches on the same stem or a prefix count for less than the word itself
+	stemMatchWeight   = 0.7
+	prefixMatchWeight = 0.5
+
+	// maxPrefixExpansions limits the words a prefix term is expanded into.
+	maxPrefixExpansions = 100
+)
+
+// Field is a part of a document with the weight its words count for.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Hit is a document matching a query and its relevance.
+type Hit struct {
+	ID    string
+	Score float64
+}
+
+type document struct {
+	length   float64
+	surfaces []string
+	stems    []string
+}
+
+// Index is an in-memory inverted index ranking documents by BM25. It is safe
+// for concurrent use.
+type Index struct {
+	mu          sync.RWMutex
+	docs        map[string]document
+	surfaces    map[string]map[string]float64
+	stems       map[string]map[string]float64
+	totalLength float64
+	// sorted are the keys of surfaces in order, for prefix lookups.
+	sorted []string
+}
+
+// NewIndex returns an empty Index.
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]document),
+		surfaces: make(map[string]map[string]float64),
+		stems:    make(map[string]map[string]float64),
+	}
+}
+
+// Add indexes the fields of a document, replacing what was indexed for id.
+func (x *Index) Add(id string, fields ...Field) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+
+	surfaces := make(map[string]float64)
+	stems := make(map[string]float64)
+	var doc document
+	for _, field := range fields {
+		for _, token := range Tokenize(field.Text) {
+			surfaces[token.Surface] += field.Weight
+			stems[token.Stem] += field.Weight
+			doc.length += field.Weight
+		}
+	}
+	for surface, frequency := range surfaces {
+		if x.surfaces[surface] == nil {
+			x.surfaces[surface] = make(map[string]float64)
+			i := sort.SearchStrings(x.sorted, surface)
+			x.sorted = append(x.sorted, "")
+			copy(x.sorted[i+1:], x.sorted[i:])
+			x.sorted[i] = surface
+		}
+		x.surfaces[surface][id] = frequency
+		doc.surfaces = append(doc.surfaces, surface)
+	}
+	for stem, frequency := range stems {
+		if x.stems[stem] == nil {
+			x.stems[stem] = make(map[string]float64)
+		}
+		x.stems[stem][id] = frequency
+		doc.stems = append(doc.stems, stem)
+	}
+	x.docs[id] = doc
+	x.totalLength += doc.length
+}
+
+// Remove drops a document from the index.
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+}
+
+func (x *Index) remove(id string) {
+	doc, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	for _, surface := range doc.surfaces {
+		delete(x.surfaces[surface], id)
+		if len(x.surfaces[surface]) == 0 {
+			delete(x.surfaces, surface)
+			i := sort.SearchStrings(x.sorted, surface)
+			x.sorted = append(x.sorted[:i], x.sorted[i+1:]...)
+		}
+	}
+	for _, stem := range doc.stems {
+		delete(x.stems[stem], id)
+		if len(x.stems[stem]) == 0 {
+			delete(x.stems, stem)
+		}
+	}
+	delete(x.docs, id)
+	x.totalLength -= doc.length
+}
+
+// Len returns the number of documents indexed.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Search returns the documents matching every term of query, most relevant
+// first.
+func (x *Index) Search(query Query) (hits []Hit) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(x.docs) == 0 {
+		return
+	}
+
+	var scores map[string]float64
+	for _, term := range query.Terms {
+		termScores := x.scoreTerm(term)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id, score := range scores {
+			if termScore, ok := termScores[id]; ok {
+				scores[id] = score + termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits = make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return
+}
+
+// scoreTerm scores the documents matching term by their best kind of match.
+func (x *Index) scoreTerm(term Term) map[string]float64 {
+	scores := make(map[string]float64)
+	add := func(postings map[string]float64, weight float64) {
+		for id, score := range x.bm25(postings) {
+			if score*weight > scores[id] {
+				scores[id] = score * weight
+			}
+		}
+	}
+
+	add(x.surfaces[term.Surface], 1)
+	add(x.stems[term.Stem], stemMatchWeight)
+	if term.Prefix {
+		i := sort.SearchStrings(x.sorted, term.Surface)
+		for n := 0; i < len(x.sorted) && n < maxPrefixExpansions && strings.HasPrefix(x.sorted[i], term.Surface); i, n = i+1, n+1 {
+			if x.sorted[i] != term.Surface {
+				add(x.surfaces[x.sorted[i]], prefixMatchWeight)
+			}
+		}
+	}
+	return scores
+}
+
+// bm25 scores the documents of the postings of a word.
+func (x *Index) bm25(postings map[string]float64) map[string]float64 {
+	scores := make(map[string]float64, len(postings))
+	if len(postings) == 0 {
+		return scores
+	}
+	n := float64(len(x.docs))
+	idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+	averageLength := x.totalLength / n
+	for id, frequency := range postings {
+		norm := 1 - bm25B + bm25B*x.docs[id].length/averageLength
+		scores[id] = idf * frequency * (bm25K1 + 1) / (frequency + bm25K1*norm)
+	}
+	return scores
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// MaxQueryLength is the longest query accepted, in bytes.
+	MaxQueryLength = 256
+	// MaxQueryTerms is the most words a query may search for.
+	MaxQueryTerms = 16
+)
+
+var (
+	// ErrEmptyQuery is returned for queries without a searchable word.
+	ErrEmptyQuery = errors.New("must contain a word to search for")
+	// ErrQueryTooLong is returned for queries over MaxQueryLength or MaxQueryTerms.
+	ErrQueryTooLong = errors.New("is too long")
+)
+
+// Term is a word to search for. A prefix term matches every word starting
+// with it.
+type Term struct {
+	Token
+	Prefix bool
+}
+
+// Query is a parsed search. A document matches when it matches all terms.
+type Query struct {
+	Text  string
+	Terms []Term
+}
+
+// ParseQuery parses the words of text into a Query. A word followed by "*" is
+// a prefix term, and so is the last word unless text ends in a space, to
+// serve search as you type.
+func ParseQuery(text string) (query Query, err error) {
+	if len(text) > MaxQueryLength {
+		return query, ErrQueryTooLong
+	}
+	query.Text = text
+	tokens := Tokenize(text)
+	for i, token := range tokens {
+		prefix := strings.HasPrefix(text[token.End:], "*")
+		if i == len(tokens)-1 && !strings.HasSuffix(text, " ") {
+			prefix = true
+		}
+		query.Terms = append(query.Terms, Term{Token: token, Prefix: prefix})
+	}
+	switch {
+	case len(query.Terms) == 0:
+		return query, ErrEmptyQuery
+	case len(query.Terms) > MaxQueryTerms:
+		return query, ErrQueryTooLong
+	}
+	return
+}
+
+// Matches tells whether token is a hit for term: the same word, a word with
+// the same stem or, for prefix terms, a word starting with the term.
+func (t Term) Matches(token Token) bool {
+	return token.Surface == t.Surface || token.Stem == t.Stem ||
+		(t.Prefix && strings.HasPrefix(token.Surface, t.Surface))
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minStemLength is the shortest stem an affix may be stripped down to.
+const minStemLength = 4
+
+// Token is a word of a text. Start and End are the byte offsets of the word
+// in the text, Surface is the word in lower case and Stem its root.
+type Token struct {
+	Surface string
+	Stem    string
+	Start   int
+	End     int
+}
+
+// Tokenize splits text into words, dropping English and Indonesian stop words.
+func Tokenize(text string) (tokens []Token) {
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		surface := strings.ToLower(text[start:i])
+		if !stopWords[surface] {
+			tokens = append(tokens, Token{Surface: surface, Stem: Stem(surface), Start: start, End: i})
+		}
+		start = -1
+	}
+	return
+}
+
+// Stem reduces a lower case word to its root. Indonesian affixes are tried
+// first, as they are the more regular, then English suffixes. Words are left
+// alone when stripping would leave fewer than minStemLength letters.
+func Stem(word string) string {
+	if stem := stemIndonesian(word); stem != word {
+		return stem
+	}
+	return stemEnglish(word)
+}
+
+var (
+	// Indonesian particles, possessive pronouns and derivational suffixes, in
+	// the order they are attached to a word from the outside in.
+	indonesianSuffixes = [][]string{
+		{"lah", "kah", "tah", "pun"},
+		{"nya", "ku", "mu"},
+		{"kan", "an", "i"},
+	}
+	// Indonesian prefixes, longest first so that "meng" is tried before "me".
+	indonesianPrefixes = []string{
+		"meng", "meny", "mem", "men", "peng", "peny", "pem", "pen",
+		"ber", "ter", "per", "di", "ke", "se",
+	}
+	// English plural endings, then verb and adverb endings.
+	englishSuffixes = [][]string{
+		{"ies", "es", "s"},
+		{"ingly", "edly", "ing", "ed", "ly"},
+	}
+)
+
+// stemIndonesian strips the suffixes of word and then its prefix. When the
+// prefix cannot be stripped from what is left, as the suffix was part of the
+// root, the prefix alone is stripped.
+func stemIndonesian(word string) string {
+	stem := trimSuffixes(word, indonesianSuffixes)
+	if trimmed, ok := trimIndonesianPrefix(stem); ok {
+		return trimmed
+	}
+	if trimmed, ok := trimIndonesianPrefix(word); ok {
+		return trimmed
+	}
+	return stem
+}
+
+func trimIndonesianPrefix(word string) (string, bool) {
+	for _, prefix := range indonesianPrefixes {
+		if trimmed := strings.TrimPrefix(word, prefix); trimmed != word && letters(trimmed) >= minStemLength {
+			return trimmed, true
+		}
+	}
+	// "me" and "pe" only assimilate before these consonants
+	for _, prefix := range []string{"me", "pe"} {
+		if trimmed := strings.TrimPrefix(word, prefix); trimmed != word && letters(trimmed) >= minStemLength &&
+			strings.ContainsAny(trimmed[:1], "lmnrwy") {
+			return trimmed, true
+		}
+	}
+	return word, false
+}
+
+func stemEnglish(word string) string {
+	if strings.HasSuffix(word, "ss") {
+		return word
+	}
+	if trimmed := strings.TrimSuffix(word, "ies"); trimmed != word && letters(trimmed) >= minStemLength {
+		return trimmed + "y"
+	}
+	stem := trimSuffixes(word, englishSuffixes)
+	// "planning" is "plan", but "falling" stays "fall"
+	if n := len(stem); stem != word && n > minStemLength && stem[n-1] == stem[n-2] &&
+		strings.ContainsAny(stem[n-1:], "bdgmnprt") {
+		stem = stem[:n-1]
+	}
+	return stem
+}
+
+// trimSuffixes strips at most one suffix of each group from word, in order.
+func trimSuffixes(word string, groups [][]string) string {
+	for _, suffixes := range groups {
+		for _, suffix := range suffixes {
+			if trimmed := strings.TrimSuffix(word, suffix); trimmed != word && letters(trimmed) >= minStemLength {
+				word = trimmed
+				break
+			}
+		}
+	}
+	return word
+}
+
+func letters(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// stopWords are common English and Indonesian words that carry no meaning on
+// their own.
+var stopWords = func() map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.Fields(`
+		a an and are as at be by for from has have in is it its of on or that
+		the this to was were will with
+		ada adalah akan atau dan dari dengan di ini itu ke kami kita mereka
+		oleh pada saya untuk yang juga sudah telah tidak bisa dalam`) {
+		words[word] = true
+	}
+	return words
+}()
@@ -1,23 +1,28 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
+	chiMiddleware "github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 	"github.com/rs/zerolog/log"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/tarkiman/go/configs"
 	"github.com/tarkiman/go/docs"
 	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/internal/domain/task"
+	"github.com/tarkiman/go/internal/outbox"
 	"github.com/tarkiman/go/shared/logger"
+	"github.com/tarkiman/go/transport/http/middleware"
 	"github.com/tarkiman/go/transport/http/response"
 	"github.com/tarkiman/go/transport/http/router"
 )
@@ -39,19 +44,29 @@ const (
 
 // HTTP is the HTTP server.
 type HTTP struct {
-	Config *configs.Config
-	DB     *infras.OracleConn
-	Router router.Router
-	State  ServerState
-	mux    *chi.Mux
+	Config              *configs.Config
+	DB                  *infras.OracleConn
+	Router              router.Router
+	Consistency         *middleware.Consistency
+	OutboxRelay         *outbox.Relay
+	TrashPurger         *task.TrashPurger
+	RecurrenceScheduler *task.RecurrenceScheduler
+	State               ServerState
+	mux                 *chi.Mux
+	stopBackground      context.CancelFunc
+	background          sync.WaitGroup
 }
 
 // ProvideHTTP is the provider for HTTP.
-func ProvideHTTP(db *infras.OracleConn, config *configs.Config, router router.Router) *HTTP {
+func ProvideHTTP(db *infras.OracleConn, config *configs.Config, router router.Router, consistency *middleware.Consistency, outboxRelay *outbox.Relay, trashPurger *task.TrashPurger, recurrenceScheduler *task.RecurrenceScheduler) *HTTP {
 	return &HTTP{
-		DB:     db,
-		Config: config,
-		Router: router,
+		DB:                  db,
+		Config:              config,
+		Router:              router,
+		Consistency:         consistency,
+		OutboxRelay:         outboxRelay,
+		TrashPurger:         trashPurger,
+		RecurrenceScheduler: recurrenceScheduler,
 	}
 }
 
@@ -62,7 +77,13 @@ func (h *HTTP) SetupAndServe() {
 	h.setupMiddleware()
 	h.setupSwaggerDocs()
 	h.setupRoutes()
+	background, stopBackground := context.WithCancel(context.Background())
+	h.stopBackground = stopBackground
 	h.setupGracefulShutdown()
+	h.setupReplicaLagMonitor(background)
+	h.setupOutboxRelay(background)
+	h.setupTrashPurger(background)
+	h.setupRecurrenceScheduler(background)
 	h.State = ServerStateReady
 
 	h.logServerInfo()
@@ -87,9 +108,37 @@ func (h *HTTP) setupSwaggerDocs() {
 
 func (h *HTTP) setupRoutes() {
 	h.mux.Get("/health", h.HealthCheck)
+	h.mux.Get("/health/ready", h.ReadinessCheck)
+	h.mux.Get("/health/replication", h.ReplicationCheck)
 	h.Router.SetupRoutes(h.mux)
 }
 
+func (h *HTTP) setupReplicaLagMonitor(ctx context.Context) {
+	interval := time.Duration(h.Config.DB.Oracle.ReplicaLagCheckSeconds) * time.Second
+	h.runInBackground(func() { h.DB.MonitorReplicaLag(ctx, interval) })
+}
+
+func (h *HTTP) setupOutboxRelay(ctx context.Context) {
+	h.runInBackground(func() { h.OutboxRelay.Run(ctx) })
+}
+
+func (h *HTTP) setupTrashPurger(ctx context.Context) {
+	h.runInBackground(func() { h.TrashPurger.Run(ctx) })
+}
+
+func (h *HTTP) setupRecurrenceScheduler(ctx context.Context) {
+	h.runInBackground(func() { h.RecurrenceScheduler.Run(ctx) })
+}
+
+// runInBackground runs a background loop that the shutdown waits for.
+func (h *HTTP) runInBackground(run func()) {
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		run()
+	}()
+}
+
 func (h *HTTP) setupGracefulShutdown() {
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
@@ -109,15 +158,21 @@ func (h *HTTP) respondToSigterm(done chan os.Signal) {
 
 	log.Info().Int64("seconds", shutdownConfig.CleanupPeriodSeconds).Msg("Entering cleanup period.")
 	h.State = ServerStateInCleanupPeriod
+	// the background loops stop with the requests, finishing their current run
+	h.stopBackground()
 	time.Sleep(time.Duration(shutdownConfig.CleanupPeriodSeconds) * time.Second)
+	h.background.Wait()
 
 	log.Info().Msg("Cleaning up completed. Shutting down now.")
 }
 
 func (h *HTTP) setupMiddleware() {
-	h.mux.Use(middleware.Logger)
-	h.mux.Use(middleware.Recoverer)
+	h.mux.Use(chiMiddleware.RequestID)
+	h.mux.Use(chiMiddleware.Logger)
+	h.mux.Use(chiMiddleware.Recoverer)
 	h.mux.Use(h.serverStateMiddleware)
+	h.mux.Use(middleware.RequestMetadata)
+	h.mux.Use(h.Consistency.ReadAfterWrite)
 	// h.setupCORS()
 }
 
@@ -190,3 +245,69 @@ func (h *HTTP) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 	response.WithMessage(w, http.StatusOK, "OK")
 }
+
+// ReadinessStatus describes whether the server can take traffic.
+type ReadinessStatus struct {
+	Ready     bool                `json:"ready"`
+	Databases []infras.RoleStatus `json:"databases"`
+}
+
+// ReadinessCheck pings every database role and reports its connection pool
+// statistics. Usually required by Kubernetes to decide whether to route
+// traffic to this instance.
+// @Summary Readiness Check
+// @Description Per database role ping status and connection pool statistics
+// @Tags service
+// @Produce json
+// @Success 200 {object} ReadinessStatus
+// @Failure 503 {object} ReadinessStatus
+// @Router /health/ready [get]
+func (h *HTTP) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	status := ReadinessStatus{
+		Ready:     h.State == ServerStateReady,
+		Databases: h.DB.Status(r.Context()),
+	}
+	for _, db := range status.Databases {
+		if !db.Up {
+			status.Ready = false
+		}
+	}
+
+	if !status.Ready {
+		response.WithJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, status)
+}
+
+// ReplicationStatus describes the measured lag of the read replica.
+type ReplicationStatus struct {
+	LagMillis      int64     `json:"lagMillis"`
+	MeasuredAt     time.Time `json:"measuredAt"`
+	ReadAfterWrite string    `json:"readAfterWriteWindow"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// ReplicationCheck reports the replication lag of the read replica and the
+// resulting read-after-write window.
+// @Summary Replication Check
+// @Description Replica lag measured through a heartbeat written on the primary
+// @Tags service
+// @Produce json
+// @Success 200 {object} ReplicationStatus
+// @Failure 503 {object} ReplicationStatus
+// @Router /health/replication [get]
+func (h *HTTP) ReplicationCheck(w http.ResponseWriter, r *http.Request) {
+	lag, measuredAt, err := h.DB.ReplicaLag.Get()
+	status := ReplicationStatus{
+		LagMillis:      lag.Milliseconds(),
+		MeasuredAt:     measuredAt,
+		ReadAfterWrite: h.DB.ReadAfterWriteWindow().String(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+		response.WithJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	response.WithJSON(w, http.StatusOK, status)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared"
 	"github.com/tarkiman/go/shared/oauth"
 	"github.com/tarkiman/go/transport/http/response"
 )
@@ -61,7 +62,7 @@ func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
 		accessToken := r.Header.Get(HeaderAuthorization)
 		token := oauth.New(a.db.Read, oauth.Config{})
 
-		parseToken, err := token.ParseWithAccessToken(accessToken, r.Method, r.RequestURI)
+		parseToken, err := token.ParseWithAccessToken(r.Context(), accessToken, r.Method, r.RequestURI)
 		if err != nil {
 			response.WithMessage(w, http.StatusUnauthorized, err.Error())
 			return
@@ -72,11 +73,20 @@ func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
 			return
 		}
 
-		headerUserId := r.Header.Get("x-userid")
-		if headerUserId == "" {
-			r.Header.Set("x-userid", parseToken.UserID.String)
+		if parseToken.UserID.Valid {
+			roles, err := token.ResolveRoles(r.Context(), parseToken.UserID.String)
+			if err != nil {
+				response.WithError(w, err)
+				return
+			}
+			r = r.WithContext(shared.WithRoles(r.Context(), roles))
 		}
 
+		// the acting user is the one the token was issued to, whatever the
+		// client claims in its own header
+		r.Header.Set(HeaderUserID, parseToken.UserID.String)
+		r = r.WithContext(shared.WithActor(r.Context(), parseToken.UserID.String))
+
 		headerAccessToken := r.Header.Get("x-access-token")
 		if headerAccessToken == "" {
 			r.Header.Set("x-access-token", parseToken.AccessToken)
@@ -86,6 +96,19 @@ func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
 	})
 }
 
+// OptionalClientCredential authenticates requests carrying an access token as
+// ClientCredential does, and lets requests without one through anonymously.
+func (a *Authentication) OptionalClientCredential(next http.Handler) http.Handler {
+	authenticated := a.ClientCredential(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderAuthorization) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
 // func (a *Authentication) ClientCredentialWithQueryParameter(next http.Handler) http.Handler {
 // 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 // 		params := r.URL.Query()
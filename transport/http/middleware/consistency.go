@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tarkiman/go/infras"
+	"github.com/tarkiman/go/shared/cursor"
+)
+
+const (
+	// HeaderReadPrimaryUntil carries the read-after-write token for clients
+	// that do not keep cookies.
+	HeaderReadPrimaryUntil = "X-Read-Primary-Until"
+	// CookieReadPrimaryUntil carries the read-after-write token for browsers.
+	CookieReadPrimaryUntil = "read_primary_until"
+)
+
+// Consistency routes a caller's reads to the primary database for a while
+// after the caller wrote, so that it reads its own writes even when the
+// read replica lags behind.
+type Consistency struct {
+	db     *infras.OracleConn
+	signer *cursor.Signer
+}
+
+// readPrimaryToken is the payload of a read-after-write token.
+type readPrimaryToken struct {
+	// Until is the Unix time in milliseconds until which reads go to the
+	// primary.
+	Until int64 `json:"until"`
+}
+
+// ProvideConsistency is the provider for Consistency.
+func ProvideConsistency(db *infras.OracleConn, signer *cursor.Signer) *Consistency {
+	return &Consistency{
+		db:     db,
+		signer: signer.For(cursor.PurposeReadPrimary),
+	}
+}
+
+// ReadAfterWrite hands out a token on every write request and honours it on
+// the following requests. The token holds the time until which reads go to
+// the primary, fixed when it is issued from the window at that time, and is
+// signed so that a caller can neither forge nor extend it.
+func (c *Consistency) ReadAfterWrite(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			until, ok := c.readPrimaryUntil(r)
+			if ok && time.Now().Before(until) {
+				ctx = infras.WithPrimaryReads(ctx)
+			}
+		default:
+			// reads made while handling a write must see the latest data too
+			ctx = infras.WithPrimaryReads(ctx)
+
+			window := c.db.ReadAfterWriteWindow()
+			token, err := c.signer.Sign(readPrimaryToken{Until: time.Now().Add(window).UnixMilli()})
+			if err != nil {
+				break
+			}
+			w.Header().Set(HeaderReadPrimaryUntil, token)
+			http.SetCookie(w, &http.Cookie{
+				Name:     CookieReadPrimaryUntil,
+				Value:    token,
+				Path:     "/",
+				MaxAge:   int(window.Seconds()) + 1,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (c *Consistency) readPrimaryUntil(r *http.Request) (until time.Time, ok bool) {
+	token := r.Header.Get(HeaderReadPrimaryUntil)
+	if token == "" {
+		cookie, err := r.Cookie(CookieReadPrimaryUntil)
+		if err != nil {
+			return
+		}
+		token = cookie.Value
+	}
+
+	var payload readPrimaryToken
+	if err := c.signer.Verify(token, &payload); err != nil {
+		return
+	}
+	return time.UnixMilli(payload.Until), true
+}
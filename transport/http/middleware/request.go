@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	chiMiddleware "github.com/go-chi/chi/middleware"
+	"github.com/tarkiman/go/shared"
+)
+
+// HeaderUserID carries the ID of the user making the request.
+const HeaderUserID = "x-userid"
+
+// RequestMetadata puts the request ID into the request context, so that layers
+// below the handlers can record it. It must run after chi's RequestID
+// middleware. The acting user is not taken from the client supplied
+// HeaderUserID: only Authentication puts it into the context, from the access
+// token.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := shared.WithRequestID(r.Context(), chiMiddleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -37,8 +37,15 @@ func WithJSON(w http.ResponseWriter, code int, jsonPayload interface{}) {
 
 // WithError sends a response with an error message
 func WithError(w http.ResponseWriter, err error) {
+	if failure.IsRetryable(err) {
+		w.Header().Set("Retry-After", "1")
+	}
 	code := failure.GetCode(err)
 	errMsg := err.Error()
+	if data := failure.GetData(err); data != nil {
+		respond(w, code, Base{Error: &errMsg, Data: &data})
+		return
+	}
 	respond(w, code, Base{Error: &errMsg})
 }
 
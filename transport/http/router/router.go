@@ -7,7 +7,8 @@ import (
 
 // DomainHandlers is a struct that contains all domain-specific handlers.
 type DomainHandlers struct {
-	TaskHandler handlers.TaskHandler
+	TaskHandler  handlers.TaskHandler
+	LabelHandler handlers.LabelHandler
 }
 
 // Router is the router struct containing handlers.
@@ -26,5 +27,6 @@ func ProvideRouter(domainHandlers DomainHandlers) Router {
 func (r *Router) SetupRoutes(mux *chi.Mux) {
 	mux.Route("/v1", func(rc chi.Router) {
 		r.DomainHandlers.TaskHandler.Router(rc)
+		r.DomainHandlers.LabelHandler.Router(rc)
 	})
 }
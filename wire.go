@@ -9,6 +9,8 @@ import (
 	"github.com/tarkiman/go/infras"
 	"github.com/tarkiman/go/internal/domain/task"
 	"github.com/tarkiman/go/internal/handlers"
+	"github.com/tarkiman/go/internal/outbox"
+	"github.com/tarkiman/go/shared/cursor"
 	"github.com/tarkiman/go/transport/http"
 	"github.com/tarkiman/go/transport/http/middleware"
 	"github.com/tarkiman/go/transport/http/router"
@@ -23,6 +25,8 @@ var configurations = wire.NewSet(
 var persistences = wire.NewSet(
 	// infras.ProvideMySQLConn,
 	infras.ProvideOracleConn,
+	infras.ProvideCache,
+	infras.ProvideStorage,
 )
 
 // Wiring for domain Task.
@@ -30,15 +34,56 @@ var domainTask = wire.NewSet(
 	// TaskService interface and implementation
 	task.ProvideTaskServiceImpl,
 	wire.Bind(new(task.TaskService), new(*task.TaskServiceImpl)),
+	// signed list cursors
+	cursor.ProvideSigner,
+	// full-text search
+	task.ProvideTaskSearcher,
+	// status workflow
+	task.ProvideWorkflow,
 	// TaskRepository interface and implementation
 	// task.ProvideTaskRepositoryMySQL,
 	// wire.Bind(new(task.TaskRepository), new(*task.TaskRepositoryMySQL)),
 	task.ProvideTaskRepositoryOracle,
-	wire.Bind(new(task.TaskRepository), new(*task.TaskRepositoryOracle)),
+	// cached TaskRepository decorating the Oracle implementation
+	task.ProvideTaskRepositoryCache,
+	wire.Bind(new(task.TaskRepository), new(*task.TaskRepositoryCache)),
+	// trash retention
+	task.ProvideTrashPurger,
+	// recurring Tasks
+	task.ProvideRecurrenceScheduler,
+	// labels
+	task.ProvideLabelServiceImpl,
+	wire.Bind(new(task.LabelService), new(*task.LabelServiceImpl)),
+	task.ProvideLabelRepositoryOracle,
+	wire.Bind(new(task.LabelRepository), new(*task.LabelRepositoryOracle)),
+	// label changes invalidate the cached Tasks carrying the label
+	wire.Bind(new(task.TaskInvalidator), new(*task.TaskRepositoryCache)),
+	// checklists
+	task.ProvideChecklistRepositoryOracle,
+	wire.Bind(new(task.ChecklistRepository), new(*task.ChecklistRepositoryOracle)),
+	// dependencies
+	task.ProvideDependencyRepositoryOracle,
+	wire.Bind(new(task.DependencyRepository), new(*task.DependencyRepositoryOracle)),
+	task.ProvideCommentRepositoryOracle,
+	wire.Bind(new(task.CommentRepository), new(*task.CommentRepositoryOracle)),
+	task.ProvideAttachmentRepositoryOracle,
+	wire.Bind(new(task.AttachmentRepository), new(*task.AttachmentRepositoryOracle)),
+	task.ProvideRecurrenceRepositoryOracle,
+	wire.Bind(new(task.RecurrenceRepository), new(*task.RecurrenceRepositoryOracle)),
+)
+
+// Wiring for the outbox.
+var domainOutbox = wire.NewSet(
+	// OutboxRepository interface and implementation
+	outbox.ProvideOutboxRepositoryOracle,
+	wire.Bind(new(outbox.OutboxRepository), new(*outbox.OutboxRepositoryOracle)),
+	outbox.ProvidePublisher,
+	outbox.ProvideRelay,
 )
 
 // Wiring for all domains.
 var domains = wire.NewSet(
+	domainOutbox,
 	domainTask,
 )
 
@@ -46,10 +91,15 @@ var authMiddleware = wire.NewSet(
 	middleware.ProvideAuthentication,
 )
 
+var consistencyMiddleware = wire.NewSet(
+	middleware.ProvideConsistency,
+)
+
 // Wiring for HTTP routing.
 var routing = wire.NewSet(
 	wire.Struct(new(router.DomainHandlers), "*"),
 	handlers.ProvideTaskHandler,
+	handlers.ProvideLabelHandler,
 	router.ProvideRouter,
 )
 
@@ -62,6 +112,7 @@ func InitializeService() *http.HTTP {
 		persistences,
 		// middleware
 		authMiddleware,
+		consistencyMiddleware,
 		// domains
 		domains,
 		// routing